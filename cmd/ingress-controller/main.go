@@ -1,14 +1,33 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog"
 
 	envoyserver "knative.dev/net-kourier/pkg/envoy/server"
 
+	"github.com/kuadrant/kcp-ingress/pkg/acme"
+	dnsprovider "github.com/kuadrant/kcp-ingress/pkg/dns"
+	"github.com/kuadrant/kcp-ingress/pkg/dns/inmemory"
+	"github.com/kuadrant/kcp-ingress/pkg/dns/rfc2136"
+	"github.com/kuadrant/kcp-ingress/pkg/reconciler/certificate"
 	"github.com/kuadrant/kcp-ingress/pkg/reconciler/dns"
+	"github.com/kuadrant/kcp-ingress/pkg/reconciler/domainclaim"
 	"github.com/kuadrant/kcp-ingress/pkg/reconciler/ingress"
 )
 
@@ -19,9 +38,50 @@ var kubecontext = flag.String("context", "", "Context to use in the Kubeconfig f
 
 var domain = flag.String("domain", "kcp-apps.127.0.0.1.nip.io", "The domain to use to expose ingresses")
 
+var hostnameTemplate = flag.String("hostname-template", ingress.DefaultHostnameTemplate, "The template of the hosts generated for the root Ingresses, rendered with the .Name, .Namespace, .Workspace and .Domain fields")
+
+var ingressClass = flag.String("ingress-class", ingress.DefaultIngressClass, "The name of the IngressClass registered for the controller, only the Ingresses of that class are reconciled")
+var defaultIngressClass = flag.Bool("default-ingress-class", true, "Mark the IngressClass as the default one, so that the Ingresses without a class are also reconciled")
+var leafIngressClass = flag.String("leaf-ingress-class", "", "The class set on the leaf Ingresses, for the ingress controllers of the clusters to pick them up, the default class of the clusters applies if empty")
+
+var clusterGeoLabel = flag.String("cluster-geo-label", "region", "The label of the Clusters holding their geographical location, the DNS targets exposed by the clusters are tagged with")
+var clusterGeo = flag.String("cluster-geo", "", "Comma-separated list of cluster=location pairs, e.g., kcp-cluster-a=eu,kcp-cluster-b=us, the DNS targets exposed by the clusters without the location label are tagged with")
+
+var dnsProvider = flag.String("dns-provider", "inmemory", "The DNS provider to publish DNSRecords to (inmemory, rfc2136)")
+
+var rfc2136Nameserver = flag.String("rfc2136-nameserver", "", "The host:port address of the authoritative DNS server that accepts dynamic updates")
+var rfc2136TSIGKeyName = flag.String("rfc2136-tsig-keyname", "", "The name of the TSIG key used to sign the dynamic updates, the updates are not signed if empty")
+var rfc2136TSIGSecret = flag.String("rfc2136-tsig-secret", "", "The base64 encoded secret of the TSIG key")
+var rfc2136TSIGSecretAlg = flag.String("rfc2136-tsig-secret-alg", "hmac-sha256", "The algorithm of the TSIG key (hmac-md5, hmac-sha1, hmac-sha256, hmac-sha512)")
+
+var dnsOwnerID = flag.String("dns-owner-id", "kcp-ingress", "The identifier of this controller instance in the ownership TXT records, to share zones with other instances")
+
+var healthCheckInterval = flag.Duration("health-check-interval", 30*time.Second, "The interval between the probes of the health checked DNS targets")
+
+var driftDetectionInterval = flag.Duration("dns-drift-detection-interval", 5*time.Minute, "The interval between the comparisons of the DNSRecords with the records published to the managed zones, 0 to disable")
+
+var metricsPort = flag.Uint("metrics-port", 8080, "The port the Prometheus metrics are served on, at /metrics, 0 to disable")
+
+var domainClaimVerificationInterval = flag.Duration("domain-claim-verification-interval", time.Minute, "The interval between the verifications of the DomainClaims that are not verified yet")
+var domainClaimSecret = flag.String("domain-claim-secret", "kcp-ingress-domain-claim", "The name of the Secret the key the DomainClaim challenge values are derived with is kept in, which is created if it doesn't exist")
+var domainClaimNamespace = flag.String("domain-claim-namespace", "default", "The namespace of the Secret the DomainClaim challenge key is kept in")
+var domainClaimRevalidationInterval = flag.Duration("domain-claim-revalidation-interval", 10*time.Minute, "The interval between the verifications of the DomainClaims that are verified, which are withdrawn once their challenge record is removed")
+
+var acmeDirectory = flag.String("acme-directory", "", "The URL of the ACME server directory to obtain the certificates of the generated hosts from, e.g., https://localhost:14000/dir for Pebble, disabled if empty")
+var acmeEmail = flag.String("acme-email", "", "The contact email of the ACME account")
+var acmeChallenge = flag.String("acme-challenge", "dns-01", "The type of the ACME challenges to validate the generated hosts with (dns-01, http-01)")
+var acmeCAFile = flag.String("acme-ca-file", "", "Path to the PEM encoded CA certificates to trust the ACME server with, in addition to the system ones")
+var acmeAccountSecret = flag.String("acme-account-secret", "kcp-ingress-acme-account", "The name of the Secret the ACME account key is kept in, which is created if it doesn't exist")
+var acmeAccountNamespace = flag.String("acme-account-namespace", "default", "The namespace of the Secret the ACME account key is kept in")
+var acmeRenewBefore = flag.Duration("acme-renew-before", 30*24*time.Hour, "The time before their expiry the certificates are renewed")
+
+var dnsServerEnable = flag.Bool("dns-server", false, "Start an authoritative DNS server for the domain")
+var dnsServerPort = flag.Uint("dns-server-port", 53, "Authoritative DNS server port")
+
 var envoyEnableXDS = flag.Bool("envoyxds", false, "Start an Envoy control plane")
 var envoyXDSPort = flag.Uint("envoyxds-port", 18000, "Envoy control plane port")
 var envoyListenPort = flag.Uint("envoy-listener-port", 80, "Envoy default listener port")
+var envoyDNSLookupFamily = flag.String("envoy-dns-lookup-family", "V4_ONLY", "The DNS lookup family Envoy resolves the load-balancers hostnames with (V4_ONLY, V6_ONLY, V4_PREFERRED, AUTO, ALL)")
 
 func main() {
 	flag.Parse()
@@ -38,18 +98,163 @@ func main() {
 		klog.Fatal(err)
 	}
 
+	challengeKey, err := domainclaim.LoadChallengeKey(context.TODO(), kubernetes.NewForConfigOrDie(r), *domainClaimNamespace, *domainClaimSecret)
+	if err != nil {
+		klog.Fatalf("Failed to load the DomainClaim challenge key: %v", err)
+	}
+
+	acmeCertificates := *acmeDirectory != ""
 	controllerConfig := &ingress.ControllerConfig{
-		Cfg:    r,
-		Domain: domain,
+		Cfg:                 r,
+		Domain:              domain,
+		ClusterGeoLabel:     clusterGeoLabel,
+		ClusterGeo:          clusterGeo,
+		HostnameTemplate:    hostnameTemplate,
+		IngressClass:        ingressClass,
+		DefaultIngressClass: defaultIngressClass,
+		LeafIngressClass:    leafIngressClass,
+		ACMECertificates:    &acmeCertificates,
+		ClaimChallengeKey:   challengeKey,
 	}
 
 	if *envoyEnableXDS {
 		controllerConfig.EnvoyXDS = envoyserver.NewXdsServer(*envoyXDSPort, nil)
 		controllerConfig.EnvoyListenPort = envoyListenPort
+		controllerConfig.EnvoyDNSLookupFamily = envoyDNSLookupFamily
+	}
+
+	if *metricsPort > 0 {
+		go func() {
+			mux := http.NewServeMux()
+			mux.Handle("/metrics", promhttp.Handler())
+			if err := http.ListenAndServe(net.JoinHostPort("", strconv.Itoa(int(*metricsPort))), mux); err != nil {
+				klog.Fatalf("Failed to serve the metrics: %v", err)
+			}
+		}()
+	}
+
+	ingressController := ingress.NewController(controllerConfig)
+	go ingressController.Start(numThreads)
+
+	if acmeCertificates {
+		certificateControllerConfig := &certificate.ControllerConfig{
+			Cfg:         r,
+			Issuer:      newACMEIssuer(r),
+			RenewBefore: acmeRenewBefore,
+			IsHandled:   ingressController.IsHandled,
+		}
+		if *dnsServerEnable {
+			// The challenge records of the domain are answered by the embedded DNS server
+			certificateControllerConfig.ServedDomain = domain
+		}
+		switch *acmeChallenge {
+		case acme.DNS01:
+		case acme.HTTP01:
+			// The HTTP-01 challenges are served by the Envoy listener
+			certificateControllerConfig.Solver = ingressController.HTTP01Solver()
+			if certificateControllerConfig.Solver == nil {
+				klog.Fatalf("the %s ACME challenges require the Envoy control plane", acme.HTTP01)
+			}
+		default:
+			klog.Fatalf("unsupported ACME challenge %q", *acmeChallenge)
+		}
+
+		go func() {
+			certificate.NewController(certificateControllerConfig).Start(numThreads)
+		}()
 	}
 
 	go func() {
-		ingress.NewController(controllerConfig).Start(numThreads)
+		domainclaim.NewController(&domainclaim.ControllerConfig{
+			Cfg:                  r,
+			ChallengeKey:         challengeKey,
+			VerificationInterval: domainClaimVerificationInterval,
+			RevalidationInterval: domainClaimRevalidationInterval,
+		}).Start(numThreads)
 	}()
-	dns.NewController(&dns.ControllerConfig{Cfg: r}).Start(numThreads)
+
+	switch *dnsProvider {
+	case "inmemory", "rfc2136":
+	default:
+		klog.Fatalf("unsupported DNS provider %q", *dnsProvider)
+	}
+
+	dnsControllerConfig := &dns.ControllerConfig{
+		Cfg:                    r,
+		NewProvider:            newDNSProvider,
+		OwnerID:                dnsOwnerID,
+		Domain:                 domain,
+		HealthCheckInterval:    healthCheckInterval,
+		DriftDetectionInterval: driftDetectionInterval,
+	}
+
+	if *dnsServerEnable {
+		dnsControllerConfig.DNSServerPort = dnsServerPort
+	}
+
+	dns.NewController(dnsControllerConfig).Start(numThreads)
+}
+
+// inmemoryProvider is shared by all the zones, so that the records are kept
+// across reconciliations.
+var inmemoryProvider = inmemory.NewProvider()
+
+// newDNSProvider is the dnsprovider.ProviderFactory of the managed zones, that
+// defaults to the provider set with the flags.
+func newDNSProvider(name string, credentials map[string][]byte) (dnsprovider.Provider, error) {
+	if name == "" {
+		name = *dnsProvider
+	}
+
+	switch name {
+	case "inmemory":
+		return inmemoryProvider, nil
+	case "rfc2136":
+		return rfc2136.NewProvider(rfc2136.Config{
+			Nameserver:    *rfc2136Nameserver,
+			TSIGKeyName:   *rfc2136TSIGKeyName,
+			TSIGSecret:    *rfc2136TSIGSecret,
+			TSIGSecretAlg: *rfc2136TSIGSecretAlg,
+		}.Override(credentials))
+	default:
+		return nil, fmt.Errorf("unsupported DNS provider %q", name)
+	}
+}
+
+// newACMEIssuer returns the ACME issuer set with the flags, with the account
+// key kept in a Secret.
+func newACMEIssuer(cfg *rest.Config) *acme.Issuer {
+	key, err := acme.LoadAccountKey(context.TODO(), kubernetes.NewForConfigOrDie(cfg), *acmeAccountNamespace, *acmeAccountSecret)
+	if err != nil {
+		klog.Fatalf("Failed to load the ACME account key: %v", err)
+	}
+
+	client := http.DefaultClient
+	if *acmeCAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		ca, err := ioutil.ReadFile(*acmeCAFile)
+		if err != nil {
+			klog.Fatal(err)
+		}
+		if !pool.AppendCertsFromPEM(ca) {
+			klog.Fatalf("no CA certificate found in %q", *acmeCAFile)
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+		client = &http.Client{Transport: transport}
+	}
+
+	issuer, err := acme.NewIssuer(acme.Config{
+		DirectoryURL: *acmeDirectory,
+		Email:        *acmeEmail,
+		HTTPClient:   client,
+		Key:          key,
+	})
+	if err != nil {
+		klog.Fatal(err)
+	}
+	return issuer
 }
@@ -0,0 +1,68 @@
+package acme
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+)
+
+// accountKeyKey is the key of the PEM encoded account key in the Secret data.
+const accountKeyKey = "key.pem"
+
+// LoadAccountKey returns the ACME account key held by the Secret, which is
+// created with a new key if it doesn't exist, so that the same account is used
+// across restarts.
+func LoadAccountKey(ctx context.Context, client kubernetes.Interface, namespace, name string) (crypto.Signer, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		return decodeKey(secret.Data[accountKeyKey])
+	}
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, err
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{accountKeyKey: keyPEM},
+	}
+	klog.Infof("creating ACME account key Secret %q", name)
+	_, err = client.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Another instance created it in the meantime
+		return LoadAccountKey(ctx, client, namespace, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func decodeKey(keyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil || block.Type != "EC PRIVATE KEY" {
+		return nil, fmt.Errorf("no PEM encoded EC private key found")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
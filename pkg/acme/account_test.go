@@ -0,0 +1,82 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLoadAccountKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		wantKey []byte
+		wantErr bool
+	}{
+		{
+			name: "new key",
+		},
+		{
+			name: "existing key",
+			objects: []runtime.Object{&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "acme-account"},
+				Data:       map[string][]byte{accountKeyKey: keyPEM},
+			}},
+			wantKey: keyPEM,
+		},
+		{
+			name: "invalid key",
+			objects: []runtime.Object{&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "acme-account"},
+				Data:       map[string][]byte{accountKeyKey: []byte("invalid")},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(tt.objects...)
+
+			got, err := LoadAccountKey(context.Background(), client, "default", "acme-account")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			gotPEM, err := encodeKey(got)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantKey != nil && string(gotPEM) != string(tt.wantKey) {
+				t.Errorf("the key of the Secret is not loaded")
+			}
+
+			// The key is kept in the Secret, so that it's loaded again afterwards
+			secret, err := client.CoreV1().Secrets("default").Get(context.Background(), "acme-account", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(secret.Data[accountKeyKey]) != string(gotPEM) {
+				t.Errorf("the key is not kept in the Secret")
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package acme
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"k8s.io/klog"
+)
+
+// The types of the ACME challenges supported by the solvers.
+const (
+	DNS01  = "dns-01"
+	HTTP01 = "http-01"
+)
+
+// Solver presents the responses to the ACME challenges of a given type, so
+// that the ACME server can validate the control of the hosts.
+type Solver interface {
+	// Type returns the type of the challenges the solver presents, e.g.,
+	// dns-01.
+	Type() string
+	// Present makes the response to the challenge available for the host,
+	// and returns once the ACME server can validate it.
+	Present(ctx context.Context, host, token, response string) error
+	// CleanUp withdraws the response to the challenge.
+	CleanUp(ctx context.Context, host, token string) error
+}
+
+// Config holds the configuration of the ACME issuer.
+type Config struct {
+	// DirectoryURL is the URL of the ACME server directory, e.g.,
+	// https://localhost:14000/dir for a local Pebble server.
+	DirectoryURL string
+	// Email is the contact of the ACME account, if not empty.
+	Email string
+	// HTTPClient is the client of the ACME server, e.g., trusting its CA.
+	// The default client is used if nil.
+	HTTPClient *http.Client
+	// Key is the key of the ACME account. A key is generated if nil, i.e., a
+	// new account is registered.
+	Key crypto.Signer
+}
+
+// Issuer obtains the certificates of hosts from an ACME server, with an
+// account that is registered on first use, or found by its key if it's
+// already registered.
+type Issuer struct {
+	mu         sync.Mutex
+	client     *acme.Client
+	email      string
+	registered bool
+}
+
+func NewIssuer(config Config) (*Issuer, error) {
+	if config.DirectoryURL == "" {
+		return nil, fmt.Errorf("an ACME directory URL is required")
+	}
+	key := config.Key
+	if key == nil {
+		var err error
+		if key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Issuer{
+		client: &acme.Client{
+			Key:          key,
+			DirectoryURL: config.DirectoryURL,
+			HTTPClient:   config.HTTPClient,
+			UserAgent:    "kcp-ingress",
+		},
+		email: config.Email,
+	}, nil
+}
+
+// Obtain orders a certificate for the host, and has the solver present the
+// responses to its challenges. It returns the PEM encoded certificate chain,
+// and private key.
+func (i *Issuer) Obtain(ctx context.Context, host string, solver Solver) ([]byte, []byte, error) {
+	if err := i.register(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	order, err := i.client.AuthorizeOrder(ctx, acme.DomainIDs(host))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to order certificate for %q: %w", host, err)
+	}
+	for _, url := range order.AuthzURLs {
+		if err := i.authorize(ctx, host, url, solver); err != nil {
+			return nil, nil, err
+		}
+	}
+	order, err = i.client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to order certificate for %q: %w", host, err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{host}}, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	chain, _, err := i.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to finalize certificate for %q: %w", host, err)
+	}
+
+	var certPEM []byte
+	for _, der := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	keyPEM, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	klog.Infof("obtained certificate for %q", host)
+	return certPEM, keyPEM, nil
+}
+
+// authorize has the solver present the response to the challenge of the
+// authorization, unless it's valid already, and waits for the authorization
+// to be validated.
+func (i *Issuer) authorize(ctx context.Context, host, url string, solver Solver) error {
+	authz, err := i.client.GetAuthorization(ctx, url)
+	if err != nil {
+		return err
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	var challenge *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == solver.Type() {
+			challenge = c
+			break
+		}
+	}
+	if challenge == nil {
+		return fmt.Errorf("no %s challenge offered for %q", solver.Type(), host)
+	}
+
+	var response string
+	switch challenge.Type {
+	case DNS01:
+		response, err = i.client.DNS01ChallengeRecord(challenge.Token)
+	case HTTP01:
+		response, err = i.client.HTTP01ChallengeResponse(challenge.Token)
+	default:
+		err = fmt.Errorf("unsupported challenge type %q", challenge.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := solver.Present(ctx, host, challenge.Token, response); err != nil {
+		return fmt.Errorf("failed to present %s challenge for %q: %w", challenge.Type, host, err)
+	}
+	defer func() {
+		if err := solver.CleanUp(context.Background(), host, challenge.Token); err != nil {
+			klog.Errorf("failed to clean up %s challenge for %q: %v", challenge.Type, host, err)
+		}
+	}()
+
+	if _, err := i.client.Accept(ctx, challenge); err != nil {
+		return fmt.Errorf("failed to accept %s challenge for %q: %w", challenge.Type, host, err)
+	}
+	if _, err := i.client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return fmt.Errorf("failed to validate %s challenge for %q: %w", challenge.Type, host, err)
+	}
+	return nil
+}
+
+func (i *Issuer) register(ctx context.Context) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.registered {
+		return nil
+	}
+	account := &acme.Account{}
+	if i.email != "" {
+		account.Contact = []string{"mailto:" + i.email}
+	}
+	if _, err := i.client.Register(ctx, account, acme.AcceptTOS); err != nil && !errors.Is(err, acme.ErrAccountAlreadyExists) {
+		return fmt.Errorf("failed to register ACME account: %w", err)
+	}
+	i.registered = true
+	return nil
+}
+
+func encodeKey(key crypto.Signer) ([]byte, error) {
+	ec, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	der, err := x509.MarshalECPrivateKey(ec)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+// HTTP01ChallengePath returns the path the response to the HTTP-01 challenge
+// is served at.
+func HTTP01ChallengePath(token string) string {
+	return "/.well-known/acme-challenge/" + token
+}
+
+// DNS01ChallengeName returns the name of the TXT record holding the response
+// to the DNS-01 challenge for the host.
+func DNS01ChallengeName(host string) string {
+	return "_acme-challenge." + host
+}
+
+// RenewalTime returns the time the PEM encoded certificate should be renewed
+// at, i.e., renewBefore its expiry, or the zero time if it's not valid for the
+// host, and should be obtained right away.
+func RenewalTime(certPEM []byte, host string, renewBefore time.Duration) time.Time {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return time.Time{}
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil || cert.VerifyHostname(host) != nil {
+		return time.Time{}
+	}
+	return cert.NotAfter.Add(-renewBefore)
+}
+
+// CertificateSecretName returns the name of the Secret holding the certificate
+// obtained for the generated host of the Ingress.
+func CertificateSecretName(ingress string) string {
+	return ingress + "-acme-tls"
+}
@@ -0,0 +1,404 @@
+package acme
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/acme"
+)
+
+const testHost = "app.kcp-apps.example.com"
+
+// fakeSolver records the responses to the challenges it presents.
+type fakeSolver struct {
+	typ string
+	// corrupt presents a wrong response
+	corrupt    bool
+	presentErr error
+
+	mu        sync.Mutex
+	responses map[string]string
+	cleanedUp []string
+}
+
+func (s *fakeSolver) Type() string {
+	return s.typ
+}
+
+func (s *fakeSolver) Present(_ context.Context, host, token, response string) error {
+	if s.presentErr != nil {
+		return s.presentErr
+	}
+	if s.corrupt {
+		response += "-corrupt"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[host+"/"+token] = response
+	return nil
+}
+
+func (s *fakeSolver) CleanUp(_ context.Context, host, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.responses, host+"/"+token)
+	s.cleanedUp = append(s.cleanedUp, host+"/"+token)
+	return nil
+}
+
+func (s *fakeSolver) response(host, token string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.responses[host+"/"+token]
+}
+
+// acmeServer is an ACME server serving a single order, which validates the
+// challenges against the responses presented by the solver, and issues the
+// certificates with a self-signed CA.
+type acmeServer struct {
+	*httptest.Server
+	t      *testing.T
+	client *acme.Client
+	solver *fakeSolver
+	caKey  *ecdsa.PrivateKey
+	ca     *x509.Certificate
+
+	mu          sync.Mutex
+	host        string
+	authzStatus string
+	orderStatus string
+	cert        []byte
+}
+
+func newACMEServer(t *testing.T, client *acme.Client, solver *fakeSolver) *acmeServer {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Fake ACME CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, caKey.Public(), caKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s := &acmeServer{t: t, client: client, solver: solver, caKey: caKey, ca: ca, authzStatus: acme.StatusPending, orderStatus: acme.StatusPending}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *acmeServer) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Replay-Nonce", fmt.Sprintf("nonce-%d", time.Now().UnixNano()))
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	var payload []byte
+	if r.Method == http.MethodPost {
+		var jws struct {
+			Payload string `json:"payload"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var err error
+		if payload, err = base64.RawURLEncoding.DecodeString(jws.Payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/directory":
+		s.write(w, http.StatusOK, map[string]string{
+			"newNonce":   s.URL + "/nonce",
+			"newAccount": s.URL + "/account",
+			"newOrder":   s.URL + "/order",
+		})
+	case r.URL.Path == "/nonce":
+	case r.URL.Path == "/account":
+		w.Header().Set("Location", s.URL+"/account/1")
+		s.write(w, http.StatusCreated, map[string]string{"status": acme.StatusValid})
+	case r.URL.Path == "/order":
+		var order struct {
+			Identifiers []struct{ Value string }
+		}
+		if err := json.Unmarshal(payload, &order); err != nil || len(order.Identifiers) != 1 {
+			http.Error(w, "invalid order", http.StatusBadRequest)
+			return
+		}
+		s.host = order.Identifiers[0].Value
+		w.Header().Set("Location", s.URL+"/order/1")
+		s.write(w, http.StatusCreated, s.order())
+	case r.URL.Path == "/order/1":
+		w.Header().Set("Location", s.URL+"/order/1")
+		s.write(w, http.StatusOK, s.order())
+	case r.URL.Path == "/authz/1":
+		s.write(w, http.StatusOK, s.authz())
+	case strings.HasPrefix(r.URL.Path, "/challenge/"):
+		typ := strings.TrimPrefix(r.URL.Path, "/challenge/")
+		s.validate(typ)
+		s.write(w, http.StatusOK, s.challenge(typ))
+	case r.URL.Path == "/finalize":
+		var finalize struct {
+			CSR string `json:"csr"`
+		}
+		if err := json.Unmarshal(payload, &finalize); err != nil || s.orderStatus != acme.StatusReady {
+			http.Error(w, "invalid finalization", http.StatusForbidden)
+			return
+		}
+		if err := s.issue(finalize.CSR); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Location", s.URL+"/order/1")
+		s.write(w, http.StatusOK, s.order())
+	case r.URL.Path == "/certificate":
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		_, _ = w.Write(s.cert)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *acmeServer) write(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func (s *acmeServer) order() map[string]interface{} {
+	order := map[string]interface{}{
+		"status":         s.orderStatus,
+		"identifiers":    []map[string]string{{"type": "dns", "value": s.host}},
+		"authorizations": []string{s.URL + "/authz/1"},
+		"finalize":       s.URL + "/finalize",
+	}
+	if s.orderStatus == acme.StatusValid {
+		order["certificate"] = s.URL + "/certificate"
+	}
+	return order
+}
+
+func (s *acmeServer) authz() map[string]interface{} {
+	return map[string]interface{}{
+		"status":     s.authzStatus,
+		"identifier": map[string]string{"type": "dns", "value": s.host},
+		"challenges": []map[string]string{s.challenge(DNS01), s.challenge(HTTP01)},
+	}
+}
+
+func (s *acmeServer) challenge(typ string) map[string]string {
+	return map[string]string{
+		"type":   typ,
+		"url":    s.URL + "/challenge/" + typ,
+		"token":  typ + "-token",
+		"status": s.authzStatus,
+	}
+}
+
+// validate checks the response presented by the solver for the challenge.
+func (s *acmeServer) validate(typ string) {
+	var want string
+	var err error
+	switch typ {
+	case DNS01:
+		want, err = s.client.DNS01ChallengeRecord(typ + "-token")
+	case HTTP01:
+		want, err = s.client.HTTP01ChallengeResponse(typ + "-token")
+	}
+	if err != nil {
+		s.t.Errorf("unexpected error: %v", err)
+	}
+
+	if got := s.solver.response(s.host, typ+"-token"); got == "" || got != want {
+		s.authzStatus = acme.StatusInvalid
+		s.orderStatus = acme.StatusInvalid
+		return
+	}
+	s.authzStatus = acme.StatusValid
+	s.orderStatus = acme.StatusReady
+}
+
+func (s *acmeServer) issue(csrB64 string) error {
+	der, err := base64.RawURLEncoding.DecodeString(csrB64)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		DNSNames:     csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(90 * 24 * time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, s.ca, csr.PublicKey, s.caKey)
+	if err != nil {
+		return err
+	}
+	s.cert = append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.ca.Raw})...)
+	s.orderStatus = acme.StatusValid
+	return nil
+}
+
+func TestIssuerObtain(t *testing.T) {
+	tests := []struct {
+		name   string
+		solver *fakeSolver
+		// wantCleanUp is whether the solver presented, and cleaned up, a response
+		wantCleanUp bool
+		wantErr     bool
+	}{
+		{
+			name:        "DNS-01 challenge",
+			solver:      &fakeSolver{typ: DNS01},
+			wantCleanUp: true,
+		},
+		{
+			name:        "HTTP-01 challenge",
+			solver:      &fakeSolver{typ: HTTP01},
+			wantCleanUp: true,
+		},
+		{
+			name:        "invalid response",
+			solver:      &fakeSolver{typ: HTTP01, corrupt: true},
+			wantCleanUp: true,
+			wantErr:     true,
+		},
+		{
+			name:    "challenge not offered",
+			solver:  &fakeSolver{typ: "tls-alpn-01"},
+			wantErr: true,
+		},
+		{
+			name:    "response not presented",
+			solver:  &fakeSolver{typ: DNS01, presentErr: fmt.Errorf("zone not managed")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.solver.responses = map[string]string{}
+			server := newACMEServer(t, &acme.Client{Key: key}, tt.solver)
+
+			issuer, err := NewIssuer(Config{DirectoryURL: server.URL + "/directory", Key: key})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			certPEM, keyPEM, err := issuer.Obtain(ctx, testHost, tt.solver)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := len(tt.solver.cleanedUp) > 0; got != tt.wantCleanUp {
+				t.Errorf("cleaned up = %v, want %v", got, tt.wantCleanUp)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if RenewalTime(certPEM, testHost, 0).IsZero() {
+				t.Errorf("the certificate is not valid for %q", testHost)
+			}
+			if _, err := decodeKey(keyPEM); err != nil {
+				t.Errorf("invalid private key: %v", err)
+			}
+		})
+	}
+}
+
+func TestRenewalTime(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	notAfter := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Second)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{testHost},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	tests := []struct {
+		name    string
+		certPEM []byte
+		host    string
+		want    time.Time
+	}{
+		{
+			name:    "valid certificate",
+			certPEM: certPEM,
+			host:    testHost,
+			want:    notAfter.Add(-7 * 24 * time.Hour),
+		},
+		{
+			name:    "certificate of another host",
+			certPEM: certPEM,
+			host:    "other.kcp-apps.example.com",
+		},
+		{
+			name:    "not a certificate",
+			certPEM: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: []byte("key")}),
+			host:    testHost,
+		},
+		{
+			name: "missing certificate",
+			host: testHost,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RenewalTime(tt.certPEM, tt.host, 7*24*time.Hour); !got.Equal(tt.want) {
+				t.Errorf("RenewalTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
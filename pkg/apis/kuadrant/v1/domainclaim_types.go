@@ -0,0 +1,103 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Domain",type="string",JSONPath=".spec.domain"
+// +kubebuilder:printcolumn:name="Verified",type="string",JSONPath=".status.conditions[?(@.type=='Verified')].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// DomainClaim claims a custom domain for the Ingresses of its namespace. The
+// hosts under the domain are only exposed once the control of the domain has
+// been proven, by publishing the challenge TXT record in the domain zone.
+type DomainClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// spec is the specification of the desired behavior of the domainClaim.
+	Spec DomainClaimSpec `json:"spec"`
+	// status is the most recently observed status of the domainClaim.
+	Status DomainClaimStatus `json:"status,omitempty"`
+}
+
+// DomainClaimSpec contains the details of a domain claim.
+type DomainClaimSpec struct {
+	// domain is the claimed domain, e.g., "example.com". The claim covers
+	// the domain and its subdomains.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Domain string `json:"domain"`
+}
+
+// DomainClaimStatus is the most recently observed status of a domain claim.
+type DomainClaimStatus struct {
+	// challengeRecord is the name of the TXT record to publish in the domain
+	// zone, with the challenge value, to prove the control of the domain.
+	// +optional
+	ChallengeRecord string `json:"challengeRecord,omitempty"`
+
+	// challenge is the value of the challenge TXT record.
+	// +optional
+	Challenge string `json:"challenge,omitempty"`
+
+	// observedGeneration is the most recently observed generation of the
+	// DomainClaim.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// conditions are any conditions associated with the claim.
+	//
+	// The "Verified" condition is set once the challenge TXT record has been
+	// found. Otherwise, its reason and message describe why the verification
+	// failed, and it's retried periodically.
+	Conditions []DomainClaimCondition `json:"conditions,omitempty"`
+}
+
+var (
+	// Verified means the control of the claimed domain has been proven.
+	DomainClaimVerifiedConditionType = "Verified"
+)
+
+// DomainClaimCondition is just the standard condition fields.
+type DomainClaimCondition struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Type string `json:"type"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Status             string      `json:"status"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	Reason             string      `json:"reason,omitempty"`
+	Message            string      `json:"message,omitempty"`
+}
+
+// IsVerified returns whether the control of the claimed domain has been
+// proven, for the current generation of the claim.
+func (d *DomainClaim) IsVerified() bool {
+	if d.Status.ObservedGeneration != d.Generation {
+		return false
+	}
+	for _, condition := range d.Status.Conditions {
+		if condition.Type == DomainClaimVerifiedConditionType {
+			return condition.Status == string(metav1.ConditionTrue)
+		}
+	}
+	return false
+}
+
+// +kubebuilder:object:root=true
+
+// DomainClaimList contains a list of domainclaims.
+type DomainClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DomainClaim `json:"items"`
+}
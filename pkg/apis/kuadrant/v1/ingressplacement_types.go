@@ -0,0 +1,121 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Min",type="integer",JSONPath=".spec.minReplicas"
+// +kubebuilder:printcolumn:name="Max",type="integer",JSONPath=".spec.maxReplicas"
+// +kubebuilder:printcolumn:name="Satisfied",type="string",JSONPath=".status.conditions[?(@.type=='Satisfied')].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// IngressPlacement controls the clusters the root Ingresses it selects, in
+// its namespace, are placed on, i.e., get leaf Ingresses for.
+type IngressPlacement struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// spec is the specification of the desired behavior of the ingressPlacement.
+	Spec IngressPlacementSpec `json:"spec"`
+	// status is the most recently observed status of the ingressPlacement.
+	Status IngressPlacementStatus `json:"status,omitempty"`
+}
+
+// IngressPlacementSpec contains the details of an ingress placement.
+type IngressPlacementSpec struct {
+	// ingressSelector selects the root Ingresses the placement applies to.
+	// All the root Ingresses of the namespace are selected if empty. When
+	// several placements select an Ingress, the oldest one applies.
+	//
+	// +optional
+	IngressSelector *metav1.LabelSelector `json:"ingressSelector,omitempty"`
+	// clusterSelector selects the clusters the Ingresses can be placed on, by
+	// their labels. All the clusters can be selected if empty.
+	//
+	// +optional
+	ClusterSelector *metav1.LabelSelector `json:"clusterSelector,omitempty"`
+	// minReplicas is the minimum number of clusters the Ingresses must be
+	// placed on. If fewer clusters can be selected, the current placement of
+	// the Ingresses is kept until the placement can be satisfied.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+	// maxReplicas is the maximum number of clusters the Ingresses are placed
+	// on. The Ingresses are placed on all the selected clusters if unset.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+	// spreadConstraints spread the Ingresses evenly across the values of
+	// cluster labels, e.g., the region. The clusters without the labels are
+	// not selected.
+	//
+	// +optional
+	SpreadConstraints []SpreadConstraint `json:"spreadConstraints,omitempty"`
+}
+
+// SpreadConstraint spreads the Ingresses across the values of a cluster label.
+type SpreadConstraint struct {
+	// topologyKey is the key of the cluster label, e.g., "region".
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	TopologyKey string `json:"topologyKey"`
+	// maxSkew is the maximum difference between the number of clusters an
+	// Ingress is placed on, for any two values of the label. Defaults to 1.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxSkew *int32 `json:"maxSkew,omitempty"`
+}
+
+// IngressPlacementStatus is the most recently observed status of an ingress
+// placement.
+type IngressPlacementStatus struct {
+	// observedGeneration is the most recently observed generation of the
+	// IngressPlacement.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// conditions are any conditions associated with the placement.
+	//
+	// The "Satisfied" condition is set once enough clusters can be selected
+	// to place the Ingresses on at least minReplicas clusters. Otherwise, its
+	// message tells how many clusters can be selected, and the Ingresses are
+	// kept on their current clusters.
+	Conditions []IngressPlacementCondition `json:"conditions,omitempty"`
+}
+
+var (
+	// Satisfied means the Ingresses can be placed on enough clusters.
+	IngressPlacementSatisfiedConditionType = "Satisfied"
+)
+
+// IngressPlacementCondition is just the standard condition fields.
+type IngressPlacementCondition struct {
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Type string `json:"type"`
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Status             string      `json:"status"`
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	Reason             string      `json:"reason,omitempty"`
+	Message            string      `json:"message,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// IngressPlacementList contains a list of ingressplacements.
+type IngressPlacementList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []IngressPlacement `json:"items"`
+}
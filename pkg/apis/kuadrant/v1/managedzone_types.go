@@ -0,0 +1,69 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Domain Name",type="string",JSONPath=".spec.domainName"
+// +kubebuilder:printcolumn:name="Provider",type="string",JSONPath=".spec.provider"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ManagedZone is a DNS hosted zone the DNSRecords are published to.
+type ManagedZone struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// spec is the specification of the desired behavior of the managedZone.
+	Spec ManagedZoneSpec `json:"spec"`
+}
+
+// ManagedZoneSpec contains the details of a managed zone.
+type ManagedZoneSpec struct {
+	// domainName is the domain of the zone, e.g., "kcp-apps.example.com".
+	// A DNSRecord is published to the zone with the longest domain name its
+	// dnsName is a subdomain of.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	DomainName string `json:"domainName"`
+	// id is the identifier the provider uses to find the DNS hosted zone.
+	// If empty, the domainName is used.
+	//
+	// +optional
+	ID string `json:"id,omitempty"`
+	// provider is the DNS provider the records are published with, e.g.,
+	// "rfc2136". If empty, the provider the controller is configured with
+	// is used.
+	//
+	// +optional
+	Provider string `json:"provider,omitempty"`
+	// credentialsSecretRef references the Secret holding the provider
+	// configuration and credentials for the zone, that override the ones the
+	// controller is configured with.
+	//
+	// +optional
+	CredentialsSecretRef *corev1.SecretReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// ZoneID returns the identifier of the zone the provider uses.
+func (z *ManagedZone) ZoneID() string {
+	if z.Spec.ID != "" {
+		return z.Spec.ID
+	}
+	return z.Spec.DomainName
+}
+
+// +kubebuilder:object:root=true
+
+// ManagedZoneList contains a list of managedzones.
+type ManagedZoneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ManagedZone `json:"items"`
+}
@@ -27,6 +27,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&DNSRecord{},
 		&DNSRecordList{},
+		&DomainClaim{},
+		&DomainClaimList{},
+		&IngressPlacement{},
+		&IngressPlacementList{},
+		&ManagedZone{},
+		&ManagedZoneList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
@@ -7,6 +7,10 @@ import (
 // +genclient
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="DNS Name",type="string",JSONPath=".spec.dnsName"
+// +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.recordType"
+// +kubebuilder:printcolumn:name="Published",type="string",JSONPath=".status.zones[0].conditions[?(@.type=='Published')].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 
 // DNSRecord is a DNS record managed by the HCG.
 type DNSRecord struct {
@@ -33,7 +37,7 @@ type DNSRecordSpec struct {
 	// +kubebuilder:validation:MinItems=1
 	// +required
 	Targets []string `json:"targets"`
-	// recordType is the DNS record type. For example, "A" or "CNAME".
+	// recordType is the DNS record type. For example, "A", "AAAA" or "CNAME".
 	// +kubebuilder:validation:Required
 	// +required
 	RecordType DNSRecordType `json:"recordType"`
@@ -45,6 +49,84 @@ type DNSRecordSpec struct {
 	// +kubebuilder:validation:Minimum=0
 	// +required
 	RecordTTL int64 `json:"recordTTL"`
+	// targetAttributes are the routing attributes of the targets, that the
+	// providers supporting weighted or geolocation routing honour. The
+	// targets without attributes have the default weight and no location.
+	//
+	// +optional
+	TargetAttributes []DNSTargetAttributes `json:"targetAttributes,omitempty"`
+	// healthCheck configures the probing of the targets. The unhealthy
+	// targets are withdrawn from the published answers, as long as at least
+	// one target remains for the DNS name. If unset, the targets are not
+	// probed, and are always considered healthy.
+	//
+	// +optional
+	HealthCheck *DNSHealthCheck `json:"healthCheck,omitempty"`
+}
+
+// DNSHealthCheck configures the probing of the record targets.
+type DNSHealthCheck struct {
+	// protocol is the protocol used to probe the targets. The HTTP and HTTPS
+	// probes succeed when the response status code is lower than 400, and
+	// the TCP probes when the connection is established. The HTTPS probes
+	// do not verify the certificate served by the targets.
+	//
+	// +kubebuilder:validation:Required
+	// +required
+	Protocol HealthCheckProtocol `json:"protocol"`
+	// port is the port the targets are probed on. If unset, the default is
+	// 443 for HTTPS, and 80 otherwise.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port *int64 `json:"port,omitempty"`
+	// path is the path of the HTTP and HTTPS probes requests, sent with the
+	// record DNS name as host. If empty, the default is "/".
+	//
+	// +optional
+	Path string `json:"path,omitempty"`
+	// interval is the interval between the probes of the targets, rounded up
+	// to the second. If unset, the default interval of the controller is used.
+	//
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+}
+
+// HealthCheckProtocol is the protocol of a health check probe.
+// +kubebuilder:validation:Enum=HTTP;HTTPS;TCP
+type HealthCheckProtocol string
+
+const (
+	HealthCheckProtocolHTTP  HealthCheckProtocol = "HTTP"
+	HealthCheckProtocolHTTPS HealthCheckProtocol = "HTTPS"
+	HealthCheckProtocolTCP   HealthCheckProtocol = "TCP"
+)
+
+// DNSTargetAttributes are the routing attributes of a record target.
+type DNSTargetAttributes struct {
+	// target is the record target the attributes apply to.
+	//
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +required
+	Target string `json:"target"`
+	// cluster is the name of the physical cluster the target is exposed by.
+	//
+	// +optional
+	Cluster string `json:"cluster,omitempty"`
+	// weight is the relative weight of the target, compared to the other
+	// targets of the records with the same DNS name. If zero, the target is
+	// drained, i.e., no longer answered. If unset, the default is 1.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Weight *int64 `json:"weight,omitempty"`
+	// geo is the geographical location of the target, e.g., "eu" or
+	// "us-east-1", for the providers supporting geolocation routing.
+	//
+	// +optional
+	Geo string `json:"geo,omitempty"`
 }
 
 // DNSRecordStatus is the most recently observed status of each record.
@@ -60,6 +142,28 @@ type DNSRecordStatus struct {
 	// needs to retry the update for that specific zone.
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// driftCount is the number of times the record published to the zone has
+	// been found changed out-of-band, or missing, and has been fixed.
+	// +optional
+	DriftCount int64 `json:"driftCount,omitempty"`
+
+	// targets are the health of the record targets, when spec.healthCheck is
+	// set. The targets that have not been probed yet are not listed.
+	// +optional
+	Targets []DNSTargetStatus `json:"targets,omitempty"`
+}
+
+// DNSTargetStatus is the health of a record target.
+type DNSTargetStatus struct {
+	// target is the record target.
+	Target string `json:"target"`
+	// healthy is whether the latest probes of the target succeeded.
+	Healthy bool `json:"healthy"`
+	// lastTransitionTime is the time the target health last changed.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// message describes the latest probe failure, if any.
+	Message string `json:"message,omitempty"`
 }
 
 // DNSZone is used to define a DNS hosted zone.
@@ -92,14 +196,23 @@ type DNSZoneStatus struct {
 	DNSZone DNSZone `json:"dnsZone"`
 	// conditions are any conditions associated with the record in the zone.
 	//
-	// If publishing the record fails, the "Failed" condition will be set with a
-	// reason and message describing the cause of the failure.
+	// The "Published" condition is set when the record is published to the
+	// zone. If publishing the record fails, the "Failed" condition will be set
+	// with a reason and message describing the cause of the failure. The
+	// "Conflict" condition is set when the record already exists in the zone,
+	// and is owned by someone else.
 	Conditions []DNSZoneCondition `json:"conditions,omitempty"`
 }
 
 var (
 	// Failed means the record is not available within a zone.
 	DNSRecordFailedConditionType = "Failed"
+
+	// Published means the record is published to a zone.
+	DNSRecordPublishedConditionType = "Published"
+
+	// Conflict means the record is owned by someone else within a zone.
+	DNSRecordConflictConditionType = "Conflict"
 )
 
 // DNSZoneCondition is just the standard condition fields.
@@ -118,7 +231,7 @@ type DNSZoneCondition struct {
 }
 
 // DNSRecordType is a DNS resource record type.
-// +kubebuilder:validation:Enum=CNAME;A
+// +kubebuilder:validation:Enum=CNAME;A;AAAA;TXT
 type DNSRecordType string
 
 const (
@@ -127,6 +240,14 @@ const (
 
 	// ARecordType is an RFC 1035 A record.
 	ARecordType DNSRecordType = "A"
+
+	// AAAARecordType is an RFC 3596 AAAA record.
+	AAAARecordType DNSRecordType = "AAAA"
+
+	// TXTRecordType is an RFC 1035 TXT record. It is used by the providers,
+	// to keep track of the records ownership, and for the ACME DNS-01
+	// challenges.
+	TXTRecordType DNSRecordType = "TXT"
 )
 
 // +kubebuilder:object:root=true
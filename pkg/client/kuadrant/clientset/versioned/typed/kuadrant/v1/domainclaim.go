@@ -0,0 +1,179 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	scheme "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// DomainClaimsGetter has a method to return a DomainClaimInterface.
+// A group's client should implement this interface.
+type DomainClaimsGetter interface {
+	DomainClaims(namespace string) DomainClaimInterface
+}
+
+// DomainClaimInterface has methods to work with DomainClaim resources.
+type DomainClaimInterface interface {
+	Create(ctx context.Context, domainClaim *v1.DomainClaim, opts metav1.CreateOptions) (*v1.DomainClaim, error)
+	Update(ctx context.Context, domainClaim *v1.DomainClaim, opts metav1.UpdateOptions) (*v1.DomainClaim, error)
+	UpdateStatus(ctx context.Context, domainClaim *v1.DomainClaim, opts metav1.UpdateOptions) (*v1.DomainClaim, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.DomainClaim, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.DomainClaimList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.DomainClaim, err error)
+	DomainClaimExpansion
+}
+
+// domainClaims implements DomainClaimInterface
+type domainClaims struct {
+	client rest.Interface
+	ns     string
+}
+
+// newDomainClaims returns a DomainClaims
+func newDomainClaims(c *KuadrantV1Client, namespace string) *domainClaims {
+	return &domainClaims{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the domainClaim, and returns the corresponding domainClaim object, and an error if there is any.
+func (c *domainClaims) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.DomainClaim, err error) {
+	result = &v1.DomainClaim{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("domainclaims").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of DomainClaims that match those selectors.
+func (c *domainClaims) List(ctx context.Context, opts metav1.ListOptions) (result *v1.DomainClaimList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.DomainClaimList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("domainclaims").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested domainClaims.
+func (c *domainClaims) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("domainclaims").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a domainClaim and creates it.  Returns the server's representation of the domainClaim, and an error, if there is any.
+func (c *domainClaims) Create(ctx context.Context, domainClaim *v1.DomainClaim, opts metav1.CreateOptions) (result *v1.DomainClaim, err error) {
+	result = &v1.DomainClaim{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("domainclaims").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(domainClaim).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a domainClaim and updates it. Returns the server's representation of the domainClaim, and an error, if there is any.
+func (c *domainClaims) Update(ctx context.Context, domainClaim *v1.DomainClaim, opts metav1.UpdateOptions) (result *v1.DomainClaim, err error) {
+	result = &v1.DomainClaim{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("domainclaims").
+		Name(domainClaim.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(domainClaim).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *domainClaims) UpdateStatus(ctx context.Context, domainClaim *v1.DomainClaim, opts metav1.UpdateOptions) (result *v1.DomainClaim, err error) {
+	result = &v1.DomainClaim{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("domainclaims").
+		Name(domainClaim.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(domainClaim).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the domainClaim and deletes it. Returns an error if one occurs.
+func (c *domainClaims) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("domainclaims").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *domainClaims) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("domainclaims").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched domainClaim.
+func (c *domainClaims) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.DomainClaim, err error) {
+	result = &v1.DomainClaim{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("domainclaims").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -0,0 +1,126 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	kuadrantv1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeDomainClaims implements DomainClaimInterface
+type FakeDomainClaims struct {
+	Fake *FakeKuadrantV1
+	ns   string
+}
+
+var domainclaimsResource = schema.GroupVersionResource{Group: "kuadrant.dev", Version: "v1", Resource: "domainclaims"}
+
+var domainclaimsKind = schema.GroupVersionKind{Group: "kuadrant.dev", Version: "v1", Kind: "DomainClaim"}
+
+// Get takes name of the domainClaim, and returns the corresponding domainClaim object, and an error if there is any.
+func (c *FakeDomainClaims) Get(ctx context.Context, name string, options v1.GetOptions) (result *kuadrantv1.DomainClaim, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(domainclaimsResource, c.ns, name), &kuadrantv1.DomainClaim{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.DomainClaim), err
+}
+
+// List takes label and field selectors, and returns the list of DomainClaims that match those selectors.
+func (c *FakeDomainClaims) List(ctx context.Context, opts v1.ListOptions) (result *kuadrantv1.DomainClaimList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(domainclaimsResource, domainclaimsKind, c.ns, opts), &kuadrantv1.DomainClaimList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &kuadrantv1.DomainClaimList{ListMeta: obj.(*kuadrantv1.DomainClaimList).ListMeta}
+	for _, item := range obj.(*kuadrantv1.DomainClaimList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested domainClaims.
+func (c *FakeDomainClaims) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(domainclaimsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a domainClaim and creates it.  Returns the server's representation of the domainClaim, and an error, if there is any.
+func (c *FakeDomainClaims) Create(ctx context.Context, domainClaim *kuadrantv1.DomainClaim, opts v1.CreateOptions) (result *kuadrantv1.DomainClaim, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(domainclaimsResource, c.ns, domainClaim), &kuadrantv1.DomainClaim{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.DomainClaim), err
+}
+
+// Update takes the representation of a domainClaim and updates it. Returns the server's representation of the domainClaim, and an error, if there is any.
+func (c *FakeDomainClaims) Update(ctx context.Context, domainClaim *kuadrantv1.DomainClaim, opts v1.UpdateOptions) (result *kuadrantv1.DomainClaim, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(domainclaimsResource, c.ns, domainClaim), &kuadrantv1.DomainClaim{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.DomainClaim), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeDomainClaims) UpdateStatus(ctx context.Context, domainClaim *kuadrantv1.DomainClaim, opts v1.UpdateOptions) (*kuadrantv1.DomainClaim, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(domainclaimsResource, "status", c.ns, domainClaim), &kuadrantv1.DomainClaim{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.DomainClaim), err
+}
+
+// Delete takes name of the domainClaim and deletes it. Returns an error if one occurs.
+func (c *FakeDomainClaims) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(domainclaimsResource, c.ns, name), &kuadrantv1.DomainClaim{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeDomainClaims) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(domainclaimsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &kuadrantv1.DomainClaimList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched domainClaim.
+func (c *FakeDomainClaims) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *kuadrantv1.DomainClaim, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(domainclaimsResource, c.ns, name, pt, data, subresources...), &kuadrantv1.DomainClaim{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.DomainClaim), err
+}
@@ -0,0 +1,126 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	kuadrantv1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeIngressPlacements implements IngressPlacementInterface
+type FakeIngressPlacements struct {
+	Fake *FakeKuadrantV1
+	ns   string
+}
+
+var ingressplacementsResource = schema.GroupVersionResource{Group: "kuadrant.dev", Version: "v1", Resource: "ingressplacements"}
+
+var ingressplacementsKind = schema.GroupVersionKind{Group: "kuadrant.dev", Version: "v1", Kind: "IngressPlacement"}
+
+// Get takes name of the ingressPlacement, and returns the corresponding ingressPlacement object, and an error if there is any.
+func (c *FakeIngressPlacements) Get(ctx context.Context, name string, options v1.GetOptions) (result *kuadrantv1.IngressPlacement, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(ingressplacementsResource, c.ns, name), &kuadrantv1.IngressPlacement{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.IngressPlacement), err
+}
+
+// List takes label and field selectors, and returns the list of IngressPlacements that match those selectors.
+func (c *FakeIngressPlacements) List(ctx context.Context, opts v1.ListOptions) (result *kuadrantv1.IngressPlacementList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(ingressplacementsResource, ingressplacementsKind, c.ns, opts), &kuadrantv1.IngressPlacementList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &kuadrantv1.IngressPlacementList{ListMeta: obj.(*kuadrantv1.IngressPlacementList).ListMeta}
+	for _, item := range obj.(*kuadrantv1.IngressPlacementList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested ingressPlacements.
+func (c *FakeIngressPlacements) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(ingressplacementsResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a ingressPlacement and creates it.  Returns the server's representation of the ingressPlacement, and an error, if there is any.
+func (c *FakeIngressPlacements) Create(ctx context.Context, ingressPlacement *kuadrantv1.IngressPlacement, opts v1.CreateOptions) (result *kuadrantv1.IngressPlacement, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(ingressplacementsResource, c.ns, ingressPlacement), &kuadrantv1.IngressPlacement{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.IngressPlacement), err
+}
+
+// Update takes the representation of a ingressPlacement and updates it. Returns the server's representation of the ingressPlacement, and an error, if there is any.
+func (c *FakeIngressPlacements) Update(ctx context.Context, ingressPlacement *kuadrantv1.IngressPlacement, opts v1.UpdateOptions) (result *kuadrantv1.IngressPlacement, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(ingressplacementsResource, c.ns, ingressPlacement), &kuadrantv1.IngressPlacement{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.IngressPlacement), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeIngressPlacements) UpdateStatus(ctx context.Context, ingressPlacement *kuadrantv1.IngressPlacement, opts v1.UpdateOptions) (*kuadrantv1.IngressPlacement, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(ingressplacementsResource, "status", c.ns, ingressPlacement), &kuadrantv1.IngressPlacement{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.IngressPlacement), err
+}
+
+// Delete takes name of the ingressPlacement and deletes it. Returns an error if one occurs.
+func (c *FakeIngressPlacements) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(ingressplacementsResource, c.ns, name), &kuadrantv1.IngressPlacement{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeIngressPlacements) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(ingressplacementsResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &kuadrantv1.IngressPlacementList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched ingressPlacement.
+func (c *FakeIngressPlacements) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *kuadrantv1.IngressPlacement, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(ingressplacementsResource, c.ns, name, pt, data, subresources...), &kuadrantv1.IngressPlacement{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.IngressPlacement), err
+}
@@ -16,6 +16,18 @@ func (c *FakeKuadrantV1) DNSRecords(namespace string) v1.DNSRecordInterface {
 	return &FakeDNSRecords{c, namespace}
 }
 
+func (c *FakeKuadrantV1) DomainClaims(namespace string) v1.DomainClaimInterface {
+	return &FakeDomainClaims{c, namespace}
+}
+
+func (c *FakeKuadrantV1) IngressPlacements(namespace string) v1.IngressPlacementInterface {
+	return &FakeIngressPlacements{c, namespace}
+}
+
+func (c *FakeKuadrantV1) ManagedZones() v1.ManagedZoneInterface {
+	return &FakeManagedZones{c}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeKuadrantV1) RESTClient() rest.Interface {
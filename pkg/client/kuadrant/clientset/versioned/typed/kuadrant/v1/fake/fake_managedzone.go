@@ -0,0 +1,106 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	kuadrantv1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeManagedZones implements ManagedZoneInterface
+type FakeManagedZones struct {
+	Fake *FakeKuadrantV1
+}
+
+var managedzonesResource = schema.GroupVersionResource{Group: "kuadrant.dev", Version: "v1", Resource: "managedzones"}
+
+var managedzonesKind = schema.GroupVersionKind{Group: "kuadrant.dev", Version: "v1", Kind: "ManagedZone"}
+
+// Get takes name of the managedZone, and returns the corresponding managedZone object, and an error if there is any.
+func (c *FakeManagedZones) Get(ctx context.Context, name string, options v1.GetOptions) (result *kuadrantv1.ManagedZone, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(managedzonesResource, name), &kuadrantv1.ManagedZone{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.ManagedZone), err
+}
+
+// List takes label and field selectors, and returns the list of ManagedZones that match those selectors.
+func (c *FakeManagedZones) List(ctx context.Context, opts v1.ListOptions) (result *kuadrantv1.ManagedZoneList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(managedzonesResource, managedzonesKind, opts), &kuadrantv1.ManagedZoneList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &kuadrantv1.ManagedZoneList{ListMeta: obj.(*kuadrantv1.ManagedZoneList).ListMeta}
+	for _, item := range obj.(*kuadrantv1.ManagedZoneList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested managedZones.
+func (c *FakeManagedZones) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(managedzonesResource, opts))
+}
+
+// Create takes the representation of a managedZone and creates it.  Returns the server's representation of the managedZone, and an error, if there is any.
+func (c *FakeManagedZones) Create(ctx context.Context, managedZone *kuadrantv1.ManagedZone, opts v1.CreateOptions) (result *kuadrantv1.ManagedZone, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(managedzonesResource, managedZone), &kuadrantv1.ManagedZone{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.ManagedZone), err
+}
+
+// Update takes the representation of a managedZone and updates it. Returns the server's representation of the managedZone, and an error, if there is any.
+func (c *FakeManagedZones) Update(ctx context.Context, managedZone *kuadrantv1.ManagedZone, opts v1.UpdateOptions) (result *kuadrantv1.ManagedZone, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(managedzonesResource, managedZone), &kuadrantv1.ManagedZone{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.ManagedZone), err
+}
+
+// Delete takes name of the managedZone and deletes it. Returns an error if one occurs.
+func (c *FakeManagedZones) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteAction(managedzonesResource, name), &kuadrantv1.ManagedZone{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeManagedZones) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(managedzonesResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &kuadrantv1.ManagedZoneList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched managedZone.
+func (c *FakeManagedZones) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *kuadrantv1.ManagedZone, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(managedzonesResource, name, pt, data, subresources...), &kuadrantv1.ManagedZone{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*kuadrantv1.ManagedZone), err
+}
@@ -3,3 +3,9 @@
 package v1
 
 type DNSRecordExpansion interface{}
+
+type DomainClaimExpansion interface{}
+
+type IngressPlacementExpansion interface{}
+
+type ManagedZoneExpansion interface{}
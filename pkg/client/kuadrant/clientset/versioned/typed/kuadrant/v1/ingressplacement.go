@@ -0,0 +1,179 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	scheme "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// IngressPlacementsGetter has a method to return a IngressPlacementInterface.
+// A group's client should implement this interface.
+type IngressPlacementsGetter interface {
+	IngressPlacements(namespace string) IngressPlacementInterface
+}
+
+// IngressPlacementInterface has methods to work with IngressPlacement resources.
+type IngressPlacementInterface interface {
+	Create(ctx context.Context, ingressPlacement *v1.IngressPlacement, opts metav1.CreateOptions) (*v1.IngressPlacement, error)
+	Update(ctx context.Context, ingressPlacement *v1.IngressPlacement, opts metav1.UpdateOptions) (*v1.IngressPlacement, error)
+	UpdateStatus(ctx context.Context, ingressPlacement *v1.IngressPlacement, opts metav1.UpdateOptions) (*v1.IngressPlacement, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.IngressPlacement, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.IngressPlacementList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.IngressPlacement, err error)
+	IngressPlacementExpansion
+}
+
+// ingressPlacements implements IngressPlacementInterface
+type ingressPlacements struct {
+	client rest.Interface
+	ns     string
+}
+
+// newIngressPlacements returns a IngressPlacements
+func newIngressPlacements(c *KuadrantV1Client, namespace string) *ingressPlacements {
+	return &ingressPlacements{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the ingressPlacement, and returns the corresponding ingressPlacement object, and an error if there is any.
+func (c *ingressPlacements) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.IngressPlacement, err error) {
+	result = &v1.IngressPlacement{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("ingressplacements").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of IngressPlacements that match those selectors.
+func (c *ingressPlacements) List(ctx context.Context, opts metav1.ListOptions) (result *v1.IngressPlacementList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.IngressPlacementList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("ingressplacements").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested ingressPlacements.
+func (c *ingressPlacements) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("ingressplacements").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a ingressPlacement and creates it.  Returns the server's representation of the ingressPlacement, and an error, if there is any.
+func (c *ingressPlacements) Create(ctx context.Context, ingressPlacement *v1.IngressPlacement, opts metav1.CreateOptions) (result *v1.IngressPlacement, err error) {
+	result = &v1.IngressPlacement{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("ingressplacements").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(ingressPlacement).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a ingressPlacement and updates it. Returns the server's representation of the ingressPlacement, and an error, if there is any.
+func (c *ingressPlacements) Update(ctx context.Context, ingressPlacement *v1.IngressPlacement, opts metav1.UpdateOptions) (result *v1.IngressPlacement, err error) {
+	result = &v1.IngressPlacement{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("ingressplacements").
+		Name(ingressPlacement.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(ingressPlacement).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *ingressPlacements) UpdateStatus(ctx context.Context, ingressPlacement *v1.IngressPlacement, opts metav1.UpdateOptions) (result *v1.IngressPlacement, err error) {
+	result = &v1.IngressPlacement{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("ingressplacements").
+		Name(ingressPlacement.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(ingressPlacement).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the ingressPlacement and deletes it. Returns an error if one occurs.
+func (c *ingressPlacements) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("ingressplacements").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *ingressPlacements) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("ingressplacements").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched ingressPlacement.
+func (c *ingressPlacements) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.IngressPlacement, err error) {
+	result = &v1.IngressPlacement{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("ingressplacements").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -11,6 +11,9 @@ import (
 type KuadrantV1Interface interface {
 	RESTClient() rest.Interface
 	DNSRecordsGetter
+	DomainClaimsGetter
+	IngressPlacementsGetter
+	ManagedZonesGetter
 }
 
 // KuadrantV1Client is used to interact with features provided by the kuadrant.dev group.
@@ -22,6 +25,18 @@ func (c *KuadrantV1Client) DNSRecords(namespace string) DNSRecordInterface {
 	return newDNSRecords(c, namespace)
 }
 
+func (c *KuadrantV1Client) DomainClaims(namespace string) DomainClaimInterface {
+	return newDomainClaims(c, namespace)
+}
+
+func (c *KuadrantV1Client) IngressPlacements(namespace string) IngressPlacementInterface {
+	return newIngressPlacements(c, namespace)
+}
+
+func (c *KuadrantV1Client) ManagedZones() ManagedZoneInterface {
+	return newManagedZones(c)
+}
+
 // NewForConfig creates a new KuadrantV1Client for the given config.
 func NewForConfig(c *rest.Config) (*KuadrantV1Client, error) {
 	config := *c
@@ -0,0 +1,152 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	"time"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	scheme "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned/scheme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// ManagedZonesGetter has a method to return a ManagedZoneInterface.
+// A group's client should implement this interface.
+type ManagedZonesGetter interface {
+	ManagedZones() ManagedZoneInterface
+}
+
+// ManagedZoneInterface has methods to work with ManagedZone resources.
+type ManagedZoneInterface interface {
+	Create(ctx context.Context, managedZone *v1.ManagedZone, opts metav1.CreateOptions) (*v1.ManagedZone, error)
+	Update(ctx context.Context, managedZone *v1.ManagedZone, opts metav1.UpdateOptions) (*v1.ManagedZone, error)
+	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
+	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.ManagedZone, error)
+	List(ctx context.Context, opts metav1.ListOptions) (*v1.ManagedZoneList, error)
+	Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.ManagedZone, err error)
+	ManagedZoneExpansion
+}
+
+// managedZones implements ManagedZoneInterface
+type managedZones struct {
+	client rest.Interface
+}
+
+// newManagedZones returns a ManagedZones
+func newManagedZones(c *KuadrantV1Client) *managedZones {
+	return &managedZones{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the managedZone, and returns the corresponding managedZone object, and an error if there is any.
+func (c *managedZones) Get(ctx context.Context, name string, options metav1.GetOptions) (result *v1.ManagedZone, err error) {
+	result = &v1.ManagedZone{}
+	err = c.client.Get().
+		Resource("managedzones").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of ManagedZones that match those selectors.
+func (c *managedZones) List(ctx context.Context, opts metav1.ListOptions) (result *v1.ManagedZoneList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1.ManagedZoneList{}
+	err = c.client.Get().
+		Resource("managedzones").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested managedZones.
+func (c *managedZones) Watch(ctx context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("managedzones").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a managedZone and creates it.  Returns the server's representation of the managedZone, and an error, if there is any.
+func (c *managedZones) Create(ctx context.Context, managedZone *v1.ManagedZone, opts metav1.CreateOptions) (result *v1.ManagedZone, err error) {
+	result = &v1.ManagedZone{}
+	err = c.client.Post().
+		Resource("managedzones").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(managedZone).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a managedZone and updates it. Returns the server's representation of the managedZone, and an error, if there is any.
+func (c *managedZones) Update(ctx context.Context, managedZone *v1.ManagedZone, opts metav1.UpdateOptions) (result *v1.ManagedZone, err error) {
+	result = &v1.ManagedZone{}
+	err = c.client.Put().
+		Resource("managedzones").
+		Name(managedZone.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(managedZone).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the managedZone and deletes it. Returns an error if one occurs.
+func (c *managedZones) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("managedzones").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *managedZones) DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("managedzones").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched managedZone.
+func (c *managedZones) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (result *v1.ManagedZone, err error) {
+	result = &v1.ManagedZone{}
+	err = c.client.Patch(pt).
+		Resource("managedzones").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
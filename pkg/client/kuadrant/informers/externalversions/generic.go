@@ -39,6 +39,12 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=kuadrant.dev, Version=v1
 	case v1.SchemeGroupVersion.WithResource("dnsrecords"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Kuadrant().V1().DNSRecords().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("domainclaims"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Kuadrant().V1().DomainClaims().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("ingressplacements"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Kuadrant().V1().IngressPlacements().Informer()}, nil
+	case v1.SchemeGroupVersion.WithResource("managedzones"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Kuadrant().V1().ManagedZones().Informer()}, nil
 
 	}
 
@@ -0,0 +1,74 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	time "time"
+
+	kuadrantv1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	versioned "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned"
+	internalinterfaces "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/informers/externalversions/internalinterfaces"
+	v1 "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/listers/kuadrant/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// IngressPlacementInformer provides access to a shared informer and lister for
+// IngressPlacements.
+type IngressPlacementInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.IngressPlacementLister
+}
+
+type ingressPlacementInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewIngressPlacementInformer constructs a new informer for IngressPlacement type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewIngressPlacementInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredIngressPlacementInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredIngressPlacementInformer constructs a new informer for IngressPlacement type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredIngressPlacementInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.KuadrantV1().IngressPlacements(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.KuadrantV1().IngressPlacements(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&kuadrantv1.IngressPlacement{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *ingressPlacementInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredIngressPlacementInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *ingressPlacementInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&kuadrantv1.IngressPlacement{}, f.defaultInformer)
+}
+
+func (f *ingressPlacementInformer) Lister() v1.IngressPlacementLister {
+	return v1.NewIngressPlacementLister(f.Informer().GetIndexer())
+}
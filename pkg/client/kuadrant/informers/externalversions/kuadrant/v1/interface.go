@@ -10,6 +10,12 @@ import (
 type Interface interface {
 	// DNSRecords returns a DNSRecordInformer.
 	DNSRecords() DNSRecordInformer
+	// DomainClaims returns a DomainClaimInformer.
+	DomainClaims() DomainClaimInformer
+	// IngressPlacements returns a IngressPlacementInformer.
+	IngressPlacements() IngressPlacementInformer
+	// ManagedZones returns a ManagedZoneInformer.
+	ManagedZones() ManagedZoneInformer
 }
 
 type version struct {
@@ -27,3 +33,18 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 func (v *version) DNSRecords() DNSRecordInformer {
 	return &dNSRecordInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
+
+// DomainClaims returns a DomainClaimInformer.
+func (v *version) DomainClaims() DomainClaimInformer {
+	return &domainClaimInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// IngressPlacements returns a IngressPlacementInformer.
+func (v *version) IngressPlacements() IngressPlacementInformer {
+	return &ingressPlacementInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
+// ManagedZones returns a ManagedZoneInformer.
+func (v *version) ManagedZones() ManagedZoneInformer {
+	return &managedZoneInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
@@ -0,0 +1,73 @@
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	"context"
+	time "time"
+
+	kuadrantv1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	versioned "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned"
+	internalinterfaces "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/informers/externalversions/internalinterfaces"
+	v1 "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/listers/kuadrant/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// ManagedZoneInformer provides access to a shared informer and lister for
+// ManagedZones.
+type ManagedZoneInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1.ManagedZoneLister
+}
+
+type managedZoneInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+}
+
+// NewManagedZoneInformer constructs a new informer for ManagedZone type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewManagedZoneInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredManagedZoneInformer(client, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredManagedZoneInformer constructs a new informer for ManagedZone type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredManagedZoneInformer(client versioned.Interface, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.KuadrantV1().ManagedZones().List(context.TODO(), options)
+			},
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.KuadrantV1().ManagedZones().Watch(context.TODO(), options)
+			},
+		},
+		&kuadrantv1.ManagedZone{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *managedZoneInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredManagedZoneInformer(client, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *managedZoneInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&kuadrantv1.ManagedZone{}, f.defaultInformer)
+}
+
+func (f *managedZoneInformer) Lister() v1.ManagedZoneLister {
+	return v1.NewManagedZoneLister(f.Informer().GetIndexer())
+}
@@ -0,0 +1,83 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// DomainClaimLister helps list DomainClaims.
+// All objects returned here must be treated as read-only.
+type DomainClaimLister interface {
+	// List lists all DomainClaims in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.DomainClaim, err error)
+	// DomainClaims returns an object that can list and get DomainClaims.
+	DomainClaims(namespace string) DomainClaimNamespaceLister
+	DomainClaimListerExpansion
+}
+
+// domainClaimLister implements the DomainClaimLister interface.
+type domainClaimLister struct {
+	indexer cache.Indexer
+}
+
+// NewDomainClaimLister returns a new DomainClaimLister.
+func NewDomainClaimLister(indexer cache.Indexer) DomainClaimLister {
+	return &domainClaimLister{indexer: indexer}
+}
+
+// List lists all DomainClaims in the indexer.
+func (s *domainClaimLister) List(selector labels.Selector) (ret []*v1.DomainClaim, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.DomainClaim))
+	})
+	return ret, err
+}
+
+// DomainClaims returns an object that can list and get DomainClaims.
+func (s *domainClaimLister) DomainClaims(namespace string) DomainClaimNamespaceLister {
+	return domainClaimNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// DomainClaimNamespaceLister helps list and get DomainClaims.
+// All objects returned here must be treated as read-only.
+type DomainClaimNamespaceLister interface {
+	// List lists all DomainClaims in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.DomainClaim, err error)
+	// Get retrieves the DomainClaim from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.DomainClaim, error)
+	DomainClaimNamespaceListerExpansion
+}
+
+// domainClaimNamespaceLister implements the DomainClaimNamespaceLister
+// interface.
+type domainClaimNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all DomainClaims in the indexer for a given namespace.
+func (s domainClaimNamespaceLister) List(selector labels.Selector) (ret []*v1.DomainClaim, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.DomainClaim))
+	})
+	return ret, err
+}
+
+// Get retrieves the DomainClaim from the indexer for a given namespace and name.
+func (s domainClaimNamespaceLister) Get(name string) (*v1.DomainClaim, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("domainclaim"), name)
+	}
+	return obj.(*v1.DomainClaim), nil
+}
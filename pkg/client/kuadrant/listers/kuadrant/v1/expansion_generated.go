@@ -9,3 +9,23 @@ type DNSRecordListerExpansion interface{}
 // DNSRecordNamespaceListerExpansion allows custom methods to be added to
 // DNSRecordNamespaceLister.
 type DNSRecordNamespaceListerExpansion interface{}
+
+// DomainClaimListerExpansion allows custom methods to be added to
+// DomainClaimLister.
+type DomainClaimListerExpansion interface{}
+
+// DomainClaimNamespaceListerExpansion allows custom methods to be added to
+// DomainClaimNamespaceLister.
+type DomainClaimNamespaceListerExpansion interface{}
+
+// IngressPlacementListerExpansion allows custom methods to be added to
+// IngressPlacementLister.
+type IngressPlacementListerExpansion interface{}
+
+// IngressPlacementNamespaceListerExpansion allows custom methods to be added to
+// IngressPlacementNamespaceLister.
+type IngressPlacementNamespaceListerExpansion interface{}
+
+// ManagedZoneListerExpansion allows custom methods to be added to
+// ManagedZoneLister.
+type ManagedZoneListerExpansion interface{}
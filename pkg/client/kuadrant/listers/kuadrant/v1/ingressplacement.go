@@ -0,0 +1,83 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// IngressPlacementLister helps list IngressPlacements.
+// All objects returned here must be treated as read-only.
+type IngressPlacementLister interface {
+	// List lists all IngressPlacements in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.IngressPlacement, err error)
+	// IngressPlacements returns an object that can list and get IngressPlacements.
+	IngressPlacements(namespace string) IngressPlacementNamespaceLister
+	IngressPlacementListerExpansion
+}
+
+// ingressPlacementLister implements the IngressPlacementLister interface.
+type ingressPlacementLister struct {
+	indexer cache.Indexer
+}
+
+// NewIngressPlacementLister returns a new IngressPlacementLister.
+func NewIngressPlacementLister(indexer cache.Indexer) IngressPlacementLister {
+	return &ingressPlacementLister{indexer: indexer}
+}
+
+// List lists all IngressPlacements in the indexer.
+func (s *ingressPlacementLister) List(selector labels.Selector) (ret []*v1.IngressPlacement, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.IngressPlacement))
+	})
+	return ret, err
+}
+
+// IngressPlacements returns an object that can list and get IngressPlacements.
+func (s *ingressPlacementLister) IngressPlacements(namespace string) IngressPlacementNamespaceLister {
+	return ingressPlacementNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// IngressPlacementNamespaceLister helps list and get IngressPlacements.
+// All objects returned here must be treated as read-only.
+type IngressPlacementNamespaceLister interface {
+	// List lists all IngressPlacements in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.IngressPlacement, err error)
+	// Get retrieves the IngressPlacement from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.IngressPlacement, error)
+	IngressPlacementNamespaceListerExpansion
+}
+
+// ingressPlacementNamespaceLister implements the IngressPlacementNamespaceLister
+// interface.
+type ingressPlacementNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all IngressPlacements in the indexer for a given namespace.
+func (s ingressPlacementNamespaceLister) List(selector labels.Selector) (ret []*v1.IngressPlacement, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.IngressPlacement))
+	})
+	return ret, err
+}
+
+// Get retrieves the IngressPlacement from the indexer for a given namespace and name.
+func (s ingressPlacementNamespaceLister) Get(name string) (*v1.IngressPlacement, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("ingressplacement"), name)
+	}
+	return obj.(*v1.IngressPlacement), nil
+}
@@ -0,0 +1,52 @@
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ManagedZoneLister helps list ManagedZones.
+// All objects returned here must be treated as read-only.
+type ManagedZoneLister interface {
+	// List lists all ManagedZones in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1.ManagedZone, err error)
+	// Get retrieves the ManagedZone from the index for a given name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1.ManagedZone, error)
+	ManagedZoneListerExpansion
+}
+
+// managedZoneLister implements the ManagedZoneLister interface.
+type managedZoneLister struct {
+	indexer cache.Indexer
+}
+
+// NewManagedZoneLister returns a new ManagedZoneLister.
+func NewManagedZoneLister(indexer cache.Indexer) ManagedZoneLister {
+	return &managedZoneLister{indexer: indexer}
+}
+
+// List lists all ManagedZones in the indexer.
+func (s *managedZoneLister) List(selector labels.Selector) (ret []*v1.ManagedZone, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1.ManagedZone))
+	})
+	return ret, err
+}
+
+// Get retrieves the ManagedZone from the index for a given name.
+func (s *managedZoneLister) Get(name string) (*v1.ManagedZone, error) {
+	obj, exists, err := s.indexer.GetByKey(name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1.Resource("managedzone"), name)
+	}
+	return obj.(*v1.ManagedZone), nil
+}
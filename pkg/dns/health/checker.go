@@ -0,0 +1,309 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+const (
+	// probeTimeout bounds the duration of a single probe.
+	probeTimeout = 5 * time.Second
+	// failureThreshold is the number of consecutive failed probes after which
+	// a target is unhealthy.
+	failureThreshold = 3
+	// tick is the period the checks are scheduled at, and so the minimum
+	// interval between the probes of a record targets.
+	tick = time.Second
+)
+
+// Checker periodically probes the targets of the DNSRecords it is given, and
+// notifies the changes of their health.
+type Checker struct {
+	interval time.Duration
+	onChange func(key string)
+	probe    func(healthCheck v1.DNSHealthCheck, dnsName, target string) error
+
+	mu     sync.RWMutex
+	checks map[string]*check
+}
+
+// check holds the health check configuration of a record, and the health of
+// its targets.
+type check struct {
+	dnsName     string
+	healthCheck v1.DNSHealthCheck
+	targets     map[string]*targetHealth
+	// next is the time the targets are due to be probed
+	next time.Time
+}
+
+type targetHealth struct {
+	status   v1.DNSTargetStatus
+	probed   bool
+	failures int
+}
+
+// NewChecker returns a Checker that probes the targets every interval, unless
+// the records health checks set their own, and calls onChange with the key of
+// the records which targets health changed.
+func NewChecker(interval time.Duration, onChange func(key string)) *Checker {
+	return &Checker{
+		interval: interval,
+		onChange: onChange,
+		probe:    Probe,
+		checks:   map[string]*check{},
+	}
+}
+
+// Start probes the targets until stopCh is closed.
+func (c *Checker) Start(stopCh <-chan struct{}) {
+	wait.Until(func() { c.probeDue(time.Now()) }, tick, stopCh)
+}
+
+// Ensure starts probing the targets of the record, or updates the probes if
+// the record has changed. The health of the targets that are kept is preserved.
+func (c *Checker) Ensure(key string, record *v1.DNSRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, ok := c.checks[key]
+	if ok && current.dnsName == record.Spec.DNSName && equality.Semantic.DeepEqual(current.healthCheck, *record.Spec.HealthCheck) {
+		targets := make(map[string]*targetHealth, len(record.Spec.Targets))
+		for _, target := range record.Spec.Targets {
+			if health, ok := current.targets[target]; ok {
+				targets[target] = health
+			} else {
+				targets[target] = newTargetHealth(target)
+			}
+		}
+		current.targets = targets
+		return
+	}
+
+	check := &check{
+		dnsName:     record.Spec.DNSName,
+		healthCheck: *record.Spec.HealthCheck.DeepCopy(),
+		targets:     make(map[string]*targetHealth, len(record.Spec.Targets)),
+	}
+	for _, target := range record.Spec.Targets {
+		check.targets[target] = newTargetHealth(target)
+	}
+	c.checks[key] = check
+}
+
+// Remove stops probing the targets of the record.
+func (c *Checker) Remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.checks, key)
+}
+
+// Status returns the health of the targets of the record that have been
+// probed at least once.
+func (c *Checker) Status(key string) []v1.DNSTargetStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	check, ok := c.checks[key]
+	if !ok {
+		return nil
+	}
+
+	var statuses []v1.DNSTargetStatus
+	for _, health := range check.targets {
+		if health.probed {
+			statuses = append(statuses, health.status)
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Target < statuses[j].Target
+	})
+	return statuses
+}
+
+// intervalOf returns the interval between the probes of the check targets.
+func (c *Checker) intervalOf(check *check) time.Duration {
+	if check.healthCheck.Interval != nil {
+		return check.healthCheck.Interval.Duration
+	}
+	return c.interval
+}
+
+// probeDue probes the targets of the records which are due at now, and
+// schedules their next probes.
+func (c *Checker) probeDue(now time.Time) {
+	type probe struct {
+		key, dnsName, target string
+		healthCheck          v1.DNSHealthCheck
+		err                  error
+	}
+
+	c.mu.Lock()
+	var probes []*probe
+	for key, check := range c.checks {
+		if now.Before(check.next) {
+			continue
+		}
+		check.next = now.Add(c.intervalOf(check))
+		for target := range check.targets {
+			probes = append(probes, &probe{key: key, dnsName: check.dnsName, target: target, healthCheck: check.healthCheck})
+		}
+	}
+	c.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, p := range probes {
+		wg.Add(1)
+		go func(p *probe) {
+			defer wg.Done()
+			p.err = c.probe(p.healthCheck, p.dnsName, p.target)
+		}(p)
+	}
+	wg.Wait()
+
+	changed := map[string]struct{}{}
+	c.mu.Lock()
+	for _, p := range probes {
+		check, ok := c.checks[p.key]
+		if !ok {
+			continue
+		}
+		health, ok := check.targets[p.target]
+		if !ok {
+			continue
+		}
+		if health.record(p.err) {
+			klog.Infof("target %q of DNSRecord %q is healthy: %t", p.target, p.key, health.status.Healthy)
+			changed[p.key] = struct{}{}
+		}
+	}
+	c.mu.Unlock()
+
+	for key := range changed {
+		c.onChange(key)
+	}
+}
+
+func newTargetHealth(target string) *targetHealth {
+	return &targetHealth{
+		status: v1.DNSTargetStatus{
+			Target:  target,
+			Healthy: true,
+		},
+	}
+}
+
+// record records the result of a probe, and returns whether the target health
+// has changed.
+func (h *targetHealth) record(err error) bool {
+	previous := h.status
+	first := !h.probed
+	h.probed = true
+
+	if err == nil {
+		h.failures = 0
+		h.status.Healthy = true
+		h.status.Message = ""
+	} else {
+		h.failures++
+		h.status.Message = err.Error()
+		if h.failures >= failureThreshold {
+			h.status.Healthy = false
+		}
+	}
+
+	if first || previous.Healthy != h.status.Healthy {
+		h.status.LastTransitionTime = metav1.Now()
+		return true
+	}
+	return false
+}
+
+// Probe probes the target of a record, and returns an error if it is not
+// healthy. The HTTP and HTTPS probes are sent to the target with the record
+// DNS name as host, and server name for TLS. The certificate of the target is
+// not verified, as the probes check the availability of the target, which may
+// not serve a certificate valid for the DNS name, e.g., a load-balancer
+// address before the certificate of the host is issued.
+func Probe(healthCheck v1.DNSHealthCheck, dnsName, target string) error {
+	port := 80
+	if healthCheck.Protocol == v1.HealthCheckProtocolHTTPS {
+		port = 443
+	}
+	if healthCheck.Port != nil {
+		port = int(*healthCheck.Port)
+	}
+	address := net.JoinHostPort(target, strconv.Itoa(port))
+
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+
+	dialer := &net.Dialer{}
+
+	switch healthCheck.Protocol {
+	case v1.HealthCheckProtocolTCP:
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+
+	case v1.HealthCheckProtocolHTTP, v1.HealthCheckProtocolHTTPS:
+		path := healthCheck.Path
+		if path == "" {
+			path = "/"
+		}
+		scheme := "http"
+		if healthCheck.Protocol == v1.HealthCheckProtocolHTTPS {
+			scheme = "https"
+		}
+		url := fmt.Sprintf("%s://%s%s", scheme, net.JoinHostPort(dnsName, strconv.Itoa(port)), path)
+
+		client := &http.Client{
+			Transport: &http.Transport{
+				// Connect to the target, whatever the DNS name resolves to
+				DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, network, address)
+				},
+				TLSClientConfig:   &tls.Config{ServerName: dnsName, InsecureSkipVerify: true},
+				DisableKeepAlives: true,
+			},
+			// Redirects are a valid response, and may point elsewhere
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			return fmt.Errorf("unexpected status code %d", resp.StatusCode)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported health check protocol %q", healthCheck.Protocol)
+	}
+}
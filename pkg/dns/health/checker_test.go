@@ -0,0 +1,325 @@
+package health
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+const dnsName = "app.kcp-apps.example.com"
+
+// serverPort returns the port the test server listens on.
+func serverPort(t *testing.T, server *httptest.Server) *int64 {
+	t.Helper()
+	_, p, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	port, err := strconv.ParseInt(p, 10, 64)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return &port
+}
+
+func TestProbe(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The probes are sent with the DNS name as host
+		if host, _, err := net.SplitHostPort(r.Host); err != nil || host != dnsName {
+			w.WriteHeader(http.StatusMisdirectedRequest)
+			return
+		}
+		switch r.URL.Path {
+		case "/healthz":
+			w.WriteHeader(http.StatusOK)
+		case "/redirect":
+			http.Redirect(w, r, "https://example.org/", http.StatusFound)
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	httpServer := httptest.NewServer(handler)
+	defer httpServer.Close()
+	// The test server certificate is not valid for the DNS name
+	httpsServer := httptest.NewTLSServer(handler)
+	defer httpsServer.Close()
+
+	closed := httptest.NewServer(handler)
+	closedPort := serverPort(t, closed)
+	closed.Close()
+
+	tests := []struct {
+		name        string
+		healthCheck v1.DNSHealthCheck
+		wantErr     bool
+	}{
+		{
+			name:        "HTTP probe",
+			healthCheck: v1.DNSHealthCheck{Protocol: v1.HealthCheckProtocolHTTP, Port: serverPort(t, httpServer), Path: "/healthz"},
+		},
+		{
+			name:        "HTTP probe, redirected",
+			healthCheck: v1.DNSHealthCheck{Protocol: v1.HealthCheckProtocolHTTP, Port: serverPort(t, httpServer), Path: "/redirect"},
+		},
+		{
+			name:        "HTTP probe, error status code",
+			healthCheck: v1.DNSHealthCheck{Protocol: v1.HealthCheckProtocolHTTP, Port: serverPort(t, httpServer)},
+			wantErr:     true,
+		},
+		{
+			name:        "HTTPS probe, with a certificate not valid for the DNS name",
+			healthCheck: v1.DNSHealthCheck{Protocol: v1.HealthCheckProtocolHTTPS, Port: serverPort(t, httpsServer), Path: "/healthz"},
+		},
+		{
+			name:        "HTTPS probe, error status code",
+			healthCheck: v1.DNSHealthCheck{Protocol: v1.HealthCheckProtocolHTTPS, Port: serverPort(t, httpsServer)},
+			wantErr:     true,
+		},
+		{
+			name:        "TCP probe",
+			healthCheck: v1.DNSHealthCheck{Protocol: v1.HealthCheckProtocolTCP, Port: serverPort(t, httpServer)},
+		},
+		{
+			name:        "TCP probe, connection refused",
+			healthCheck: v1.DNSHealthCheck{Protocol: v1.HealthCheckProtocolTCP, Port: closedPort},
+			wantErr:     true,
+		},
+		{
+			name:        "HTTP probe, connection refused",
+			healthCheck: v1.DNSHealthCheck{Protocol: v1.HealthCheckProtocolHTTP, Port: closedPort, Path: "/healthz"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Probe(tt.healthCheck, dnsName, "127.0.0.1")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Probe() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTargetHealthRecord(t *testing.T) {
+	failed := errors.New("connection refused")
+
+	tests := []struct {
+		name        string
+		probes      []error
+		wantHealthy bool
+		wantChanges int
+	}{
+		{
+			name:        "first successful probe",
+			probes:      []error{nil},
+			wantHealthy: true,
+			wantChanges: 1,
+		},
+		{
+			name:        "failed probes under the threshold",
+			probes:      []error{nil, failed, failed},
+			wantHealthy: true,
+			wantChanges: 1,
+		},
+		{
+			name:        "failed probes reaching the threshold",
+			probes:      []error{nil, failed, failed, failed},
+			wantHealthy: false,
+			wantChanges: 2,
+		},
+		{
+			name:        "failed probes interrupted by a successful one",
+			probes:      []error{failed, failed, nil, failed, failed},
+			wantHealthy: true,
+			wantChanges: 1,
+		},
+		{
+			name:        "successful probe after the threshold",
+			probes:      []error{failed, failed, failed, failed, nil},
+			wantHealthy: true,
+			wantChanges: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := newTargetHealth("10.0.0.1")
+			changes := 0
+			for _, err := range tt.probes {
+				if health.record(err) {
+					changes++
+				}
+			}
+			if health.status.Healthy != tt.wantHealthy {
+				t.Errorf("healthy = %v, want %v", health.status.Healthy, tt.wantHealthy)
+			}
+			if changes != tt.wantChanges {
+				t.Errorf("changes = %d, want %d", changes, tt.wantChanges)
+			}
+			if tt.probes[len(tt.probes)-1] != nil && health.status.Message == "" {
+				t.Errorf("message is empty, want the probe error")
+			}
+		})
+	}
+}
+
+func TestCheckerProbeDue(t *testing.T) {
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	newRecord := func(name string, interval *metav1.Duration) *v1.DNSRecord {
+		return &v1.DNSRecord{
+			Spec: v1.DNSRecordSpec{
+				DNSName:     name + ".kcp-apps.example.com",
+				Targets:     []string{"10.0.0.1", "10.0.0.2"},
+				HealthCheck: &v1.DNSHealthCheck{Protocol: v1.HealthCheckProtocolTCP, Interval: interval},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		record *v1.DNSRecord
+		// at are the elapsed times the due probes are run at
+		at         []time.Duration
+		wantProbes int
+	}{
+		{
+			name:       "first probes",
+			record:     newRecord("app", nil),
+			at:         []time.Duration{0},
+			wantProbes: 2,
+		},
+		{
+			name:       "probes not due yet with the default interval",
+			record:     newRecord("app", nil),
+			at:         []time.Duration{0, 10 * time.Second, 29 * time.Second},
+			wantProbes: 2,
+		},
+		{
+			name:       "probes due with the default interval",
+			record:     newRecord("app", nil),
+			at:         []time.Duration{0, 10 * time.Second, 30 * time.Second},
+			wantProbes: 4,
+		},
+		{
+			name:       "probes due with the record interval",
+			record:     newRecord("app", &metav1.Duration{Duration: 10 * time.Second}),
+			at:         []time.Duration{0, 10 * time.Second, 15 * time.Second, 20 * time.Second},
+			wantProbes: 6,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			probes := 0
+			c := NewChecker(30*time.Second, func(string) {})
+			c.probe = func(v1.DNSHealthCheck, string, string) error {
+				mu.Lock()
+				defer mu.Unlock()
+				probes++
+				return nil
+			}
+			c.Ensure("default/app", tt.record)
+
+			for _, elapsed := range tt.at {
+				c.probeDue(now.Add(elapsed))
+			}
+			if probes != tt.wantProbes {
+				t.Errorf("probes = %d, want %d", probes, tt.wantProbes)
+			}
+		})
+	}
+}
+
+func TestCheckerEnsure(t *testing.T) {
+	record := &v1.DNSRecord{
+		Spec: v1.DNSRecordSpec{
+			DNSName:     dnsName,
+			Targets:     []string{"10.0.0.1", "10.0.0.2"},
+			HealthCheck: &v1.DNSHealthCheck{Protocol: v1.HealthCheckProtocolTCP},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		update func(record *v1.DNSRecord)
+		// wantTargets are the targets reported as probed, and unhealthy
+		wantTargets []string
+	}{
+		{
+			name:        "unchanged record",
+			update:      func(record *v1.DNSRecord) {},
+			wantTargets: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name: "added target",
+			update: func(record *v1.DNSRecord) {
+				record.Spec.Targets = append(record.Spec.Targets, "10.0.0.3")
+			},
+			wantTargets: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name: "removed target",
+			update: func(record *v1.DNSRecord) {
+				record.Spec.Targets = record.Spec.Targets[:1]
+			},
+			wantTargets: []string{"10.0.0.1"},
+		},
+		{
+			name: "changed health check",
+			update: func(record *v1.DNSRecord) {
+				record.Spec.HealthCheck.Interval = &metav1.Duration{Duration: 10 * time.Second}
+			},
+		},
+		{
+			name: "changed DNS name",
+			update: func(record *v1.DNSRecord) {
+				record.Spec.DNSName = "other.kcp-apps.example.com"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(time.Second, func(string) {})
+			c.probe = func(v1.DNSHealthCheck, string, string) error {
+				return errors.New("connection refused")
+			}
+			c.Ensure("default/app", record.DeepCopy())
+			now := time.Now()
+			for i := 0; i < failureThreshold; i++ {
+				c.probeDue(now.Add(time.Duration(i) * time.Second))
+			}
+
+			updated := record.DeepCopy()
+			tt.update(updated)
+			c.Ensure("default/app", updated)
+
+			var targets []string
+			for _, status := range c.Status("default/app") {
+				if status.Healthy {
+					t.Errorf("target %q is healthy, want its health preserved", status.Target)
+				}
+				targets = append(targets, status.Target)
+			}
+			if len(targets) != len(tt.wantTargets) {
+				t.Fatalf("targets = %v, want %v", targets, tt.wantTargets)
+			}
+			for i := range targets {
+				if targets[i] != tt.wantTargets[i] {
+					t.Errorf("targets = %v, want %v", targets, tt.wantTargets)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package inmemory
+
+import (
+	"strings"
+	"sync"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns"
+)
+
+var _ dns.Provider = &Provider{}
+
+// Provider is a dns.Provider that keeps the records in memory. It is meant
+// for tests and local development, where no real DNS server is available.
+type Provider struct {
+	mu    sync.RWMutex
+	zones map[string]map[string]v1.DNSRecordSpec
+}
+
+func NewProvider() *Provider {
+	return &Provider{
+		zones: make(map[string]map[string]v1.DNSRecordSpec),
+	}
+}
+
+func (p *Provider) Ensure(record *v1.DNSRecord, zone v1.DNSZone) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	records, ok := p.zones[zone.ID]
+	if !ok {
+		records = make(map[string]v1.DNSRecordSpec)
+		p.zones[zone.ID] = records
+	}
+	records[recordKey(record.Spec)] = *record.Spec.DeepCopy()
+	return nil
+}
+
+func (p *Provider) Delete(record *v1.DNSRecord, zone v1.DNSZone) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.zones[zone.ID], recordKey(record.Spec))
+	return nil
+}
+
+func (p *Provider) List(zone v1.DNSZone) ([]v1.DNSRecordSpec, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	records := make([]v1.DNSRecordSpec, 0, len(p.zones[zone.ID]))
+	for _, spec := range p.zones[zone.ID] {
+		records = append(records, *spec.DeepCopy())
+	}
+	return records, nil
+}
+
+func (p *Provider) Get(dnsName string, recordType v1.DNSRecordType, zone v1.DNSZone) (*v1.DNSRecordSpec, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, spec := range p.zones[zone.ID] {
+		if normalize(spec.DNSName) == normalize(dnsName) && spec.RecordType == recordType {
+			return spec.DeepCopy(), nil
+		}
+	}
+	return nil, nil
+}
+
+func recordKey(spec v1.DNSRecordSpec) string {
+	return spec.DNSName + "/" + string(spec.RecordType)
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
@@ -0,0 +1,109 @@
+package inmemory
+
+import (
+	"testing"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+func TestProvider(t *testing.T) {
+	zone := v1.DNSZone{ID: "kcp-apps.example.com"}
+	record := func(recordType v1.DNSRecordType, targets ...string) *v1.DNSRecord {
+		return &v1.DNSRecord{Spec: v1.DNSRecordSpec{DNSName: "app.kcp-apps.example.com", RecordType: recordType, Targets: targets}}
+	}
+
+	tests := []struct {
+		name string
+		// update changes the records of the zone
+		update      func(t *testing.T, p *Provider)
+		getName     string
+		getType     v1.DNSRecordType
+		wantTargets []string
+		wantRecords int
+	}{
+		{
+			name: "created record",
+			update: func(t *testing.T, p *Provider) {
+				ensure(t, p, record(v1.ARecordType, "10.0.0.1"), zone)
+			},
+			getName:     "app.kcp-apps.example.com",
+			getType:     v1.ARecordType,
+			wantTargets: []string{"10.0.0.1"},
+			wantRecords: 1,
+		},
+		{
+			name: "updated record, fully qualified and case insensitive",
+			update: func(t *testing.T, p *Provider) {
+				ensure(t, p, record(v1.ARecordType, "10.0.0.1"), zone)
+				ensure(t, p, record(v1.ARecordType, "10.0.0.2"), zone)
+			},
+			getName:     "App.kcp-apps.example.com.",
+			getType:     v1.ARecordType,
+			wantTargets: []string{"10.0.0.2"},
+			wantRecords: 1,
+		},
+		{
+			name: "record of another type",
+			update: func(t *testing.T, p *Provider) {
+				ensure(t, p, record(v1.ARecordType, "10.0.0.1"), zone)
+				ensure(t, p, record(v1.AAAARecordType, "::1"), zone)
+			},
+			getName:     "app.kcp-apps.example.com",
+			getType:     v1.AAAARecordType,
+			wantTargets: []string{"::1"},
+			wantRecords: 2,
+		},
+		{
+			name: "record of another zone",
+			update: func(t *testing.T, p *Provider) {
+				ensure(t, p, record(v1.ARecordType, "10.0.0.1"), v1.DNSZone{ID: "example.com"})
+			},
+			getName: "app.kcp-apps.example.com",
+			getType: v1.ARecordType,
+		},
+		{
+			name: "deleted record",
+			update: func(t *testing.T, p *Provider) {
+				ensure(t, p, record(v1.ARecordType, "10.0.0.1"), zone)
+				if err := p.Delete(record(v1.ARecordType), zone); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+			getName: "app.kcp-apps.example.com",
+			getType: v1.ARecordType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewProvider()
+			tt.update(t, p)
+
+			got, err := p.Get(tt.getName, tt.getType, zone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			switch {
+			case tt.wantTargets == nil && got != nil:
+				t.Errorf("Get() = %v, want nil", got)
+			case tt.wantTargets != nil && (got == nil || len(got.Targets) != 1 || got.Targets[0] != tt.wantTargets[0]):
+				t.Errorf("Get() = %v, want targets %v", got, tt.wantTargets)
+			}
+
+			records, err := p.List(zone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(records) != tt.wantRecords {
+				t.Errorf("List() = %v, want %d records", records, tt.wantRecords)
+			}
+		})
+	}
+}
+
+func ensure(t *testing.T, p *Provider, record *v1.DNSRecord, zone v1.DNSZone) {
+	t.Helper()
+	if err := p.Ensure(record, zone); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
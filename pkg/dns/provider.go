@@ -0,0 +1,29 @@
+package dns
+
+import (
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+// Provider knows how to publish DNSRecords to the DNS zones it manages.
+type Provider interface {
+	// Ensure will create or update the record in the zone. The record targets
+	// are the ones to publish, i.e., the drained and unhealthy targets have
+	// already been removed, while spec.targetAttributes are kept for the
+	// providers supporting weighted or geolocation routing.
+	Ensure(record *v1.DNSRecord, zone v1.DNSZone) error
+
+	// Delete will delete the record from the zone.
+	Delete(record *v1.DNSRecord, zone v1.DNSZone) error
+
+	// List returns the records currently published in the zone.
+	List(zone v1.DNSZone) ([]v1.DNSRecordSpec, error)
+
+	// Get returns the record with the given name and type currently published
+	// in the zone, or nil if there is none, without listing the whole zone.
+	Get(dnsName string, recordType v1.DNSRecordType, zone v1.DNSZone) (*v1.DNSRecordSpec, error)
+}
+
+// ProviderFactory returns the Provider with the given name, configured with
+// the given credentials, e.g., read from a ManagedZone credentials Secret.
+// If name is empty, the default provider is returned.
+type ProviderFactory func(name string, credentials map[string][]byte) (Provider, error)
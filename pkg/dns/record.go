@@ -0,0 +1,45 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+// DefaultTTL is the TTL of the records which spec does not set one.
+const DefaultTTL = 30
+
+// TTL returns the TTL in seconds the record should be published with.
+func TTL(spec v1.DNSRecordSpec) uint32 {
+	if spec.RecordTTL == 0 {
+		return DefaultTTL
+	}
+	return uint32(spec.RecordTTL)
+}
+
+// Validate checks the record targets match its type, i.e., the A and AAAA
+// records targets are respectively IPv4 and IPv6 addresses, a CNAME record
+// has a single target, and a TXT record has at least one.
+func Validate(spec v1.DNSRecordSpec) error {
+	switch spec.RecordType {
+	case v1.ARecordType, v1.AAAARecordType:
+		for _, target := range spec.Targets {
+			ip := net.ParseIP(target)
+			if ip == nil || (ip.To4() != nil) != (spec.RecordType == v1.ARecordType) {
+				return fmt.Errorf("invalid target %q for %s record %q", target, spec.RecordType, spec.DNSName)
+			}
+		}
+	case v1.CNAMERecordType:
+		if len(spec.Targets) != 1 {
+			return fmt.Errorf("CNAME record %q must have a single target, got %d", spec.DNSName, len(spec.Targets))
+		}
+	case v1.TXTRecordType:
+		if len(spec.Targets) == 0 {
+			return fmt.Errorf("TXT record %q must have at least one target", spec.DNSName)
+		}
+	default:
+		return fmt.Errorf("unsupported record type %q for record %q", spec.RecordType, spec.DNSName)
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns"
+)
+
+const (
+	// txtPrefix is prepended to the record type, to form the label of the TXT
+	// record holding the ownership of a record, e.g., the ownership of the A
+	// record of app.kcp-apps.example.com is held by the TXT record of
+	// _kcp-ingress-a.app.kcp-apps.example.com.
+	txtPrefix = "_kcp-ingress-"
+
+	heritage      = "kcp-ingress"
+	heritageLabel = "heritage"
+	ownerLabel    = "kcp-ingress/owner"
+	resourceLabel = "kcp-ingress/resource"
+)
+
+var _ dns.Provider = &TXTRegistry{}
+
+// ConflictError is returned when a record is not owned by the registry owner,
+// and thus cannot be modified nor deleted.
+type ConflictError struct {
+	DNSName    string
+	RecordType v1.DNSRecordType
+	Owner      string
+}
+
+func (e *ConflictError) Error() string {
+	if e.Owner == "" {
+		return fmt.Sprintf("%s record %q already exists and is not owned by any controller", e.RecordType, e.DNSName)
+	}
+	return fmt.Sprintf("%s record %q is owned by %q", e.RecordType, e.DNSName, e.Owner)
+}
+
+// IsConflict returns whether the error is, or wraps, a ConflictError.
+func IsConflict(err error) bool {
+	var conflict *ConflictError
+	return errors.As(err, &conflict)
+}
+
+// TXTRegistry is a dns.Provider that keeps track of the ownership of the
+// records it publishes, with companion TXT records holding the owner ID and
+// the DNSRecord the records are published for. It refuses to modify or delete
+// the records it does not own, so that several controllers can safely share
+// the same zone.
+type TXTRegistry struct {
+	provider dns.Provider
+	ownerID  string
+}
+
+func NewTXTRegistry(provider dns.Provider, ownerID string) *TXTRegistry {
+	return &TXTRegistry{
+		provider: provider,
+		ownerID:  ownerID,
+	}
+}
+
+// Ensure publishes the record, and its ownership TXT record, unless the record
+// already exists and is owned by someone else.
+// A record owned by the registry owner, but published for another DNSRecord,
+// is handed over to that record, e.g., when the root Ingress takes over the
+// records of its leaves.
+func (r *TXTRegistry) Ensure(record *v1.DNSRecord, zone v1.DNSZone) error {
+	resource, err := r.owned(record, zone)
+	if err != nil {
+		return err
+	}
+
+	// Claim the ownership first, so that the record is never published without it
+	if resource != Resource(record) {
+		if err := r.provider.Ensure(r.ownershipRecord(record), zone); err != nil {
+			return err
+		}
+	}
+
+	return r.provider.Ensure(record, zone)
+}
+
+// Delete removes the record, and its ownership TXT record, unless the record
+// is owned by someone else.
+func (r *TXTRegistry) Delete(record *v1.DNSRecord, zone v1.DNSZone) error {
+	resource, err := r.owned(record, zone)
+	if err != nil {
+		return err
+	}
+	if resource != Resource(record) {
+		// Either the record doesn't exist, or it's been handed over to another
+		// DNSRecord, there is nothing to delete
+		return nil
+	}
+
+	if err := r.provider.Delete(record, zone); err != nil {
+		return err
+	}
+
+	return r.provider.Delete(r.ownershipRecord(record), zone)
+}
+
+// Get returns the record with the given name and type, if it is owned by the
+// registry owner.
+func (r *TXTRegistry) Get(dnsName string, recordType v1.DNSRecordType, zone v1.DNSZone) (*v1.DNSRecordSpec, error) {
+	txt, err := r.provider.Get(ownershipName(dnsName, recordType), v1.TXTRecordType, zone)
+	if err != nil || txt == nil || len(txt.Targets) == 0 {
+		return nil, err
+	}
+	if labels := parseLabels(txt.Targets[0]); labels == nil || labels[ownerLabel] != r.ownerID {
+		return nil, nil
+	}
+	return r.provider.Get(dnsName, recordType, zone)
+}
+
+// Owned is a record owned by the registry owner, along with the DNSRecord it
+// is published for.
+type Owned struct {
+	Spec     v1.DNSRecordSpec
+	Resource string
+}
+
+// List returns the records of the zone that are owned by the registry owner.
+func (r *TXTRegistry) List(zone v1.DNSZone) ([]v1.DNSRecordSpec, error) {
+	owned, err := r.ListOwned(zone)
+	if err != nil {
+		return nil, err
+	}
+
+	specs := make([]v1.DNSRecordSpec, 0, len(owned))
+	for _, o := range owned {
+		specs = append(specs, o.Spec)
+	}
+	return specs, nil
+}
+
+// ListOwned returns the records of the zone that are owned by the registry
+// owner, along with the DNSRecords they are published for.
+func (r *TXTRegistry) ListOwned(zone v1.DNSZone) ([]Owned, error) {
+	records, err := r.provider.List(zone)
+	if err != nil {
+		return nil, err
+	}
+
+	ownerships := map[string]map[string]string{}
+	for _, record := range records {
+		if record.RecordType == v1.TXTRecordType && len(record.Targets) > 0 {
+			ownerships[normalize(record.DNSName)] = parseLabels(record.Targets[0])
+		}
+	}
+
+	var owned []Owned
+	for _, record := range records {
+		if isOwnershipRecord(record) {
+			continue
+		}
+		labels := ownerships[ownershipName(record.DNSName, record.RecordType)]
+		if labels != nil && labels[ownerLabel] == r.ownerID {
+			owned = append(owned, Owned{Spec: record, Resource: labels[resourceLabel]})
+		}
+	}
+
+	return owned, nil
+}
+
+// DeleteOwned removes a record owned by the registry owner, and its ownership
+// TXT record, whatever the DNSRecord it is published for, e.g., an orphan
+// record which DNSRecord no longer exists.
+func (r *TXTRegistry) DeleteOwned(owned Owned, zone v1.DNSZone) error {
+	record := &v1.DNSRecord{Spec: owned.Spec}
+	if err := r.provider.Delete(record, zone); err != nil {
+		return err
+	}
+
+	return r.provider.Delete(r.ownershipRecord(record), zone)
+}
+
+// owned returns the DNSRecord the record is published for, if it is owned by
+// the registry owner, an empty string if it doesn't exist, or a ConflictError
+// if it is owned by someone else. Only the record and its ownership TXT record
+// are looked up, as listing the whole zone on every change may be expensive,
+// e.g., a zone transfer.
+func (r *TXTRegistry) owned(record *v1.DNSRecord, zone v1.DNSZone) (string, error) {
+	txt, err := r.provider.Get(ownershipName(record.Spec.DNSName, record.Spec.RecordType), v1.TXTRecordType, zone)
+	if err != nil {
+		return "", err
+	}
+	var labels map[string]string
+	if txt != nil && len(txt.Targets) > 0 {
+		labels = parseLabels(txt.Targets[0])
+	}
+
+	if labels == nil {
+		existing, err := r.provider.Get(record.Spec.DNSName, record.Spec.RecordType, zone)
+		if err != nil {
+			return "", err
+		}
+		if existing != nil {
+			return "", &ConflictError{DNSName: record.Spec.DNSName, RecordType: record.Spec.RecordType}
+		}
+		return "", nil
+	}
+
+	if owner := labels[ownerLabel]; owner != r.ownerID {
+		return "", &ConflictError{DNSName: record.Spec.DNSName, RecordType: record.Spec.RecordType, Owner: owner}
+	}
+
+	return labels[resourceLabel], nil
+}
+
+// ownershipRecord returns the TXT record holding the ownership of the record.
+func (r *TXTRegistry) ownershipRecord(record *v1.DNSRecord) *v1.DNSRecord {
+	txt := record.DeepCopy()
+	txt.Spec = v1.DNSRecordSpec{
+		DNSName:    ownershipName(record.Spec.DNSName, record.Spec.RecordType),
+		RecordType: v1.TXTRecordType,
+		RecordTTL:  record.Spec.RecordTTL,
+		Targets: []string{fmt.Sprintf("%s=%s,%s=%s,%s=%s",
+			heritageLabel, heritage,
+			ownerLabel, r.ownerID,
+			resourceLabel, Resource(record))},
+	}
+	return txt
+}
+
+func ownershipName(dnsName string, recordType v1.DNSRecordType) string {
+	return txtPrefix + strings.ToLower(string(recordType)) + "." + normalize(dnsName)
+}
+
+// Resource identifies the DNSRecord a record is published for.
+func Resource(record *v1.DNSRecord) string {
+	parts := []string{"dnsrecord"}
+	if record.ClusterName != "" {
+		parts = append(parts, record.ClusterName)
+	}
+	return strings.Join(append(parts, record.Namespace, record.Name), "/")
+}
+
+// isOwnershipRecord returns whether the record is an ownership TXT record,
+// rather than a TXT record published for a DNSRecord.
+func isOwnershipRecord(record v1.DNSRecordSpec) bool {
+	return record.RecordType == v1.TXTRecordType && len(record.Targets) > 0 && parseLabels(record.Targets[0]) != nil
+}
+
+// parseLabels parses the key=value pairs of an ownership TXT record, and
+// returns nil if it has not been created by a kcp-ingress registry.
+func parseLabels(txt string) map[string]string {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(strings.Trim(txt, `"`), ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) == 2 {
+			labels[kv[0]] = kv[1]
+		}
+	}
+	if labels[heritageLabel] != heritage {
+		return nil
+	}
+	return labels
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
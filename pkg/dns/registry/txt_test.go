@@ -0,0 +1,221 @@
+package registry
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns/inmemory"
+)
+
+var zone = v1.DNSZone{ID: "kcp-apps.example.com"}
+
+func newRecord(name, dnsName string, targets ...string) *v1.DNSRecord {
+	return &v1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: v1.DNSRecordSpec{
+			DNSName:    dnsName,
+			RecordType: v1.ARecordType,
+			RecordTTL:  60,
+			Targets:    targets,
+		},
+	}
+}
+
+func TestTXTRegistryEnsure(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup publishes the records of the zone before the record is ensured
+		setup        func(t *testing.T, provider *inmemory.Provider)
+		record       *v1.DNSRecord
+		wantConflict bool
+		wantTargets  []string
+	}{
+		{
+			name:        "new record",
+			setup:       func(t *testing.T, provider *inmemory.Provider) {},
+			record:      newRecord("app", "app.kcp-apps.example.com", "10.0.0.1"),
+			wantTargets: []string{"10.0.0.1"},
+		},
+		{
+			name: "record owned by the owner",
+			setup: func(t *testing.T, provider *inmemory.Provider) {
+				ensure(t, NewTXTRegistry(provider, "owner"), newRecord("app", "app.kcp-apps.example.com", "10.0.0.1"))
+			},
+			record:      newRecord("app", "app.kcp-apps.example.com", "10.0.0.2"),
+			wantTargets: []string{"10.0.0.2"},
+		},
+		{
+			name: "record owned by another owner",
+			setup: func(t *testing.T, provider *inmemory.Provider) {
+				ensure(t, NewTXTRegistry(provider, "other"), newRecord("app", "app.kcp-apps.example.com", "10.0.0.1"))
+			},
+			record:       newRecord("app", "app.kcp-apps.example.com", "10.0.0.2"),
+			wantConflict: true,
+			wantTargets:  []string{"10.0.0.1"},
+		},
+		{
+			name: "record owned by the owner for another DNSRecord",
+			setup: func(t *testing.T, provider *inmemory.Provider) {
+				ensure(t, NewTXTRegistry(provider, "owner"), newRecord("other", "app.kcp-apps.example.com", "10.0.0.1"))
+			},
+			// The record is handed over, e.g., from a leaf to the root Ingress
+			record:      newRecord("app", "app.kcp-apps.example.com", "10.0.0.2"),
+			wantTargets: []string{"10.0.0.2"},
+		},
+		{
+			name: "record without owner",
+			setup: func(t *testing.T, provider *inmemory.Provider) {
+				if err := provider.Ensure(newRecord("manual", "app.kcp-apps.example.com", "10.0.0.1"), zone); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+			record:       newRecord("app", "app.kcp-apps.example.com", "10.0.0.2"),
+			wantConflict: true,
+			wantTargets:  []string{"10.0.0.1"},
+		},
+		{
+			name: "record of another type without owner",
+			setup: func(t *testing.T, provider *inmemory.Provider) {
+				record := newRecord("manual", "app.kcp-apps.example.com", "::1")
+				record.Spec.RecordType = v1.AAAARecordType
+				if err := provider.Ensure(record, zone); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			},
+			record:      newRecord("app", "app.kcp-apps.example.com", "10.0.0.2"),
+			wantTargets: []string{"10.0.0.2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := inmemory.NewProvider()
+			tt.setup(t, provider)
+			registry := NewTXTRegistry(provider, "owner")
+
+			err := registry.Ensure(tt.record, zone)
+			if got := IsConflict(err); got != tt.wantConflict {
+				t.Fatalf("conflict = %v, want %v: %v", got, tt.wantConflict, err)
+			}
+			if err != nil && !tt.wantConflict {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			published, err := provider.Get(tt.record.Spec.DNSName, tt.record.Spec.RecordType, zone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if published == nil || !equalTargets(published.Targets, tt.wantTargets) {
+				t.Errorf("published record = %v, want targets %v", published, tt.wantTargets)
+			}
+
+			// The record is owned for the ensured DNSRecord
+			if !tt.wantConflict {
+				resource, err := registry.owned(tt.record, zone)
+				if err != nil || resource != Resource(tt.record) {
+					t.Errorf("owned() = %q, %v, want %q", resource, err, Resource(tt.record))
+				}
+			}
+		})
+	}
+}
+
+func TestTXTRegistryDelete(t *testing.T) {
+	tests := []struct {
+		name         string
+		owner        string
+		record       *v1.DNSRecord
+		wantConflict bool
+		wantDeleted  bool
+	}{
+		{
+			name:        "record owned by the owner",
+			owner:       "owner",
+			record:      newRecord("app", "app.kcp-apps.example.com"),
+			wantDeleted: true,
+		},
+		{
+			name:         "record owned by another owner",
+			owner:        "other",
+			record:       newRecord("app", "app.kcp-apps.example.com"),
+			wantConflict: true,
+		},
+		{
+			// The record has been handed over to another DNSRecord
+			name:   "record owned by the owner for another DNSRecord",
+			owner:  "owner",
+			record: newRecord("other", "app.kcp-apps.example.com"),
+		},
+		{
+			name:   "missing record",
+			owner:  "owner",
+			record: newRecord("app", "missing.kcp-apps.example.com"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := inmemory.NewProvider()
+			ensure(t, NewTXTRegistry(provider, tt.owner), newRecord("app", "app.kcp-apps.example.com", "10.0.0.1"))
+			registry := NewTXTRegistry(provider, "owner")
+
+			err := registry.Delete(tt.record, zone)
+			if got := IsConflict(err); got != tt.wantConflict {
+				t.Fatalf("conflict = %v, want %v: %v", got, tt.wantConflict, err)
+			}
+			if err != nil && !tt.wantConflict {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			records, err := provider.List(zone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// The ownership TXT record is deleted along with the record
+			if got := len(records) == 0; got != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v: %v", got, tt.wantDeleted, records)
+			}
+		})
+	}
+}
+
+func TestTXTRegistryListOwned(t *testing.T) {
+	provider := inmemory.NewProvider()
+	ensure(t, NewTXTRegistry(provider, "owner"), newRecord("app", "app.kcp-apps.example.com", "10.0.0.1"))
+	ensure(t, NewTXTRegistry(provider, "other"), newRecord("other", "other.kcp-apps.example.com", "10.0.0.2"))
+	if err := provider.Ensure(newRecord("manual", "manual.kcp-apps.example.com", "10.0.0.3"), zone); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	owned, err := NewTXTRegistry(provider, "owner").ListOwned(zone)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(owned) != 1 {
+		t.Fatalf("owned records = %v, want only app.kcp-apps.example.com", owned)
+	}
+	if owned[0].Spec.DNSName != "app.kcp-apps.example.com" || owned[0].Resource != "dnsrecord/default/app" {
+		t.Errorf("owned record = %v, want app.kcp-apps.example.com for dnsrecord/default/app", owned[0])
+	}
+}
+
+func ensure(t *testing.T, registry *TXTRegistry, record *v1.DNSRecord) {
+	t.Helper()
+	if err := registry.Ensure(record, zone); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func equalTargets(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
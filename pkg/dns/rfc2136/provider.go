@@ -0,0 +1,320 @@
+package rfc2136
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	dnsprovider "github.com/kuadrant/kcp-ingress/pkg/dns"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+	tsigFudge      = 300
+)
+
+// Keys of the ManagedZone credentials Secret data, that override the
+// provider configuration.
+const (
+	NameserverKey    = "nameserver"
+	TSIGKeyNameKey   = "tsig-keyname"
+	TSIGSecretKey    = "tsig-secret"
+	TSIGSecretAlgKey = "tsig-secret-alg"
+)
+
+var _ dnsprovider.Provider = &Provider{}
+
+// Config holds the configuration of the RFC 2136 provider.
+type Config struct {
+	// Nameserver is the host:port address of the authoritative server,
+	// that accepts the dynamic updates for the managed zones.
+	Nameserver string
+	// TSIGKeyName is the name of the TSIG key used to sign the messages.
+	// The messages are not signed if empty.
+	TSIGKeyName string
+	// TSIGSecret is the base64 encoded secret of the TSIG key.
+	TSIGSecret string
+	// TSIGSecretAlg is the algorithm of the TSIG key, e.g., hmac-sha256.
+	TSIGSecretAlg string
+}
+
+// Override returns a copy of the configuration, with the values set in data,
+// e.g., read from a ManagedZone credentials Secret.
+func (c Config) Override(data map[string][]byte) Config {
+	if v, ok := data[NameserverKey]; ok {
+		c.Nameserver = string(v)
+	}
+	if v, ok := data[TSIGKeyNameKey]; ok {
+		c.TSIGKeyName = string(v)
+	}
+	if v, ok := data[TSIGSecretKey]; ok {
+		c.TSIGSecret = string(v)
+	}
+	if v, ok := data[TSIGSecretAlgKey]; ok {
+		c.TSIGSecretAlg = string(v)
+	}
+	return c
+}
+
+// Provider is a dns.Provider that publishes the records to an authoritative
+// DNS server, using RFC 2136 dynamic update messages, optionally signed with
+// TSIG, and lists them using zone transfers (AXFR).
+type Provider struct {
+	config Config
+	client *dns.Client
+}
+
+func NewProvider(config Config) (*Provider, error) {
+	if config.Nameserver == "" {
+		return nil, fmt.Errorf("a nameserver is required")
+	}
+	if _, _, err := net.SplitHostPort(config.Nameserver); err != nil {
+		config.Nameserver = net.JoinHostPort(config.Nameserver, "53")
+	}
+
+	client := &dns.Client{Net: "tcp", Timeout: defaultTimeout}
+
+	if config.TSIGKeyName != "" {
+		if config.TSIGSecret == "" {
+			return nil, fmt.Errorf("a secret is required for TSIG key %q", config.TSIGKeyName)
+		}
+		config.TSIGKeyName = dns.Fqdn(config.TSIGKeyName)
+		alg, err := tsigAlgorithm(config.TSIGSecretAlg)
+		if err != nil {
+			return nil, err
+		}
+		config.TSIGSecretAlg = alg
+		client.TsigSecret = map[string]string{config.TSIGKeyName: config.TSIGSecret}
+	}
+
+	return &Provider{
+		config: config,
+		client: client,
+	}, nil
+}
+
+// Ensure replaces the record RRset in the zone, so that the published records
+// match the record targets.
+func (p *Provider) Ensure(record *v1.DNSRecord, zone v1.DNSZone) error {
+	zoneName, name, err := names(record, zone)
+	if err != nil {
+		return err
+	}
+
+	rrs := make([]dns.RR, 0, len(record.Spec.Targets))
+	for _, target := range record.Spec.Targets {
+		rr, err := newRR(name, record.Spec.RecordType, dnsprovider.TTL(record.Spec), target)
+		if err != nil {
+			return err
+		}
+		rrs = append(rrs, rr)
+	}
+
+	m := new(dns.Msg)
+	m.SetUpdate(zoneName)
+	m.RemoveRRset([]dns.RR{rrsetHeader(name, record.Spec.RecordType)})
+	m.Insert(rrs)
+
+	return p.update(m)
+}
+
+// Delete removes the record RRset from the zone.
+func (p *Provider) Delete(record *v1.DNSRecord, zone v1.DNSZone) error {
+	zoneName, name, err := names(record, zone)
+	if err != nil {
+		return err
+	}
+
+	m := new(dns.Msg)
+	m.SetUpdate(zoneName)
+	m.RemoveRRset([]dns.RR{rrsetHeader(name, record.Spec.RecordType)})
+
+	return p.update(m)
+}
+
+// List transfers the zone from the nameserver, and returns its RRsets of the
+// record types supported by DNSRecords.
+func (p *Provider) List(zone v1.DNSZone) ([]v1.DNSRecordSpec, error) {
+	m := new(dns.Msg)
+	m.SetAxfr(dns.Fqdn(zone.ID))
+	p.sign(m)
+
+	t := &dns.Transfer{TsigSecret: p.client.TsigSecret}
+	envelopes, err := t.In(m, p.config.Nameserver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to transfer zone %q: %w", zone.ID, err)
+	}
+
+	rrsets := map[string]*v1.DNSRecordSpec{}
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			return nil, fmt.Errorf("failed to transfer zone %q: %w", zone.ID, envelope.Error)
+		}
+		for _, rr := range envelope.RR {
+			recordType, target, ok := fromRR(rr)
+			if !ok {
+				continue
+			}
+			name := strings.TrimSuffix(rr.Header().Name, ".")
+			key := name + "/" + string(recordType)
+			spec, ok := rrsets[key]
+			if !ok {
+				spec = &v1.DNSRecordSpec{
+					DNSName:    name,
+					RecordType: recordType,
+					RecordTTL:  int64(rr.Header().Ttl),
+				}
+				rrsets[key] = spec
+			}
+			spec.Targets = append(spec.Targets, target)
+		}
+	}
+
+	records := make([]v1.DNSRecordSpec, 0, len(rrsets))
+	for _, spec := range rrsets {
+		records = append(records, *spec)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].DNSName == records[j].DNSName {
+			return records[i].RecordType < records[j].RecordType
+		}
+		return records[i].DNSName < records[j].DNSName
+	})
+
+	return records, nil
+}
+
+// Get queries the nameserver for the record RRset, rather than transferring
+// the whole zone.
+func (p *Provider) Get(dnsName string, recordType v1.DNSRecordType, zone v1.DNSZone) (*v1.DNSRecordSpec, error) {
+	name := dns.Fqdn(dnsName)
+	if !dns.IsSubDomain(dns.Fqdn(zone.ID), name) {
+		return nil, fmt.Errorf("record %q is not in zone %q", dnsName, zone.ID)
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion(name, dns.StringToType[string(recordType)])
+	m.RecursionDesired = false
+	p.sign(m)
+
+	r, _, err := p.client.Exchange(m, p.config.Nameserver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", p.config.Nameserver, err)
+	}
+	if r.Rcode != dns.RcodeSuccess && r.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("query of %q rejected by %s: %s", dnsName, p.config.Nameserver, dns.RcodeToString[r.Rcode])
+	}
+
+	var spec *v1.DNSRecordSpec
+	for _, rr := range r.Answer {
+		rrType, target, ok := fromRR(rr)
+		// A query may be answered with the CNAME of the name instead
+		if !ok || rrType != recordType || !strings.EqualFold(rr.Header().Name, name) {
+			continue
+		}
+		if spec == nil {
+			spec = &v1.DNSRecordSpec{
+				DNSName:    strings.TrimSuffix(rr.Header().Name, "."),
+				RecordType: recordType,
+				RecordTTL:  int64(rr.Header().Ttl),
+			}
+		}
+		spec.Targets = append(spec.Targets, target)
+	}
+	return spec, nil
+}
+
+func (p *Provider) update(m *dns.Msg) error {
+	p.sign(m)
+
+	r, _, err := p.client.Exchange(m, p.config.Nameserver)
+	if err != nil {
+		return fmt.Errorf("failed to send update to %s: %w", p.config.Nameserver, err)
+	}
+	if r.Rcode != dns.RcodeSuccess {
+		return fmt.Errorf("update rejected by %s: %s", p.config.Nameserver, dns.RcodeToString[r.Rcode])
+	}
+
+	return nil
+}
+
+func (p *Provider) sign(m *dns.Msg) {
+	if p.config.TSIGKeyName != "" {
+		m.SetTsig(p.config.TSIGKeyName, p.config.TSIGSecretAlg, tsigFudge, time.Now().Unix())
+	}
+}
+
+// names returns the fully qualified zone and record names, and checks the
+// record belongs to the zone.
+func names(record *v1.DNSRecord, zone v1.DNSZone) (string, string, error) {
+	zoneName := dns.Fqdn(zone.ID)
+	name := dns.Fqdn(record.Spec.DNSName)
+	if !dns.IsSubDomain(zoneName, name) {
+		return "", "", fmt.Errorf("record %q is not in zone %q", record.Spec.DNSName, zone.ID)
+	}
+	return zoneName, name, nil
+}
+
+func rrsetHeader(name string, recordType v1.DNSRecordType) dns.RR {
+	return &dns.ANY{Hdr: dns.RR_Header{Name: name, Rrtype: dns.StringToType[string(recordType)], Class: dns.ClassANY}}
+}
+
+func newRR(name string, recordType v1.DNSRecordType, ttl uint32, target string) (dns.RR, error) {
+	hdr := dns.RR_Header{Name: name, Rrtype: dns.StringToType[string(recordType)], Class: dns.ClassINET, Ttl: ttl}
+
+	switch recordType {
+	case v1.ARecordType:
+		ip := net.ParseIP(target).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IPv4 address %q for record %q", target, name)
+		}
+		return &dns.A{Hdr: hdr, A: ip}, nil
+	case v1.AAAARecordType:
+		ip := net.ParseIP(target)
+		if ip == nil || ip.To4() != nil {
+			return nil, fmt.Errorf("invalid IPv6 address %q for record %q", target, name)
+		}
+		return &dns.AAAA{Hdr: hdr, AAAA: ip}, nil
+	case v1.CNAMERecordType:
+		return &dns.CNAME{Hdr: hdr, Target: dns.Fqdn(target)}, nil
+	case v1.TXTRecordType:
+		return &dns.TXT{Hdr: hdr, Txt: []string{target}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", recordType)
+	}
+}
+
+func fromRR(rr dns.RR) (v1.DNSRecordType, string, bool) {
+	switch r := rr.(type) {
+	case *dns.A:
+		return v1.ARecordType, r.A.String(), true
+	case *dns.AAAA:
+		return v1.AAAARecordType, r.AAAA.String(), true
+	case *dns.CNAME:
+		return v1.CNAMERecordType, strings.TrimSuffix(r.Target, "."), true
+	case *dns.TXT:
+		return v1.TXTRecordType, strings.Join(r.Txt, ""), true
+	default:
+		return "", "", false
+	}
+}
+
+func tsigAlgorithm(alg string) (string, error) {
+	switch strings.ToLower(strings.TrimSuffix(alg, ".")) {
+	case "hmac-md5", "hmac-md5.sig-alg.reg.int":
+		return dns.HmacMD5, nil
+	case "hmac-sha1":
+		return dns.HmacSHA1, nil
+	case "", "hmac-sha256":
+		return dns.HmacSHA256, nil
+	case "hmac-sha512":
+		return dns.HmacSHA512, nil
+	default:
+		return "", fmt.Errorf("unsupported TSIG algorithm %q", alg)
+	}
+}
@@ -0,0 +1,260 @@
+package rfc2136
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+const (
+	testZone       = "kcp-apps.example.com"
+	testTSIGKey    = "kcp-ingress."
+	testTSIGSecret = "c2VjcmV0LWtleS1vZi1rY3AtaW5ncmVzcw=="
+)
+
+// server is an authoritative DNS server that keeps the zone in memory, and
+// accepts the dynamic updates and zone transfers, signed if tsig is set.
+type server struct {
+	tsig bool
+
+	mu  sync.Mutex
+	rrs []dns.RR
+}
+
+func (s *server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	if s.tsig && (r.IsTsig() == nil || w.TsigStatus() != nil) {
+		m.Rcode = dns.RcodeRefused
+		s.write(w, r, m)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.Question[0]
+	switch {
+	case r.Opcode == dns.OpcodeUpdate:
+		for _, rr := range r.Ns {
+			if rr.Header().Class == dns.ClassANY {
+				s.remove(rr.Header().Name, rr.Header().Rrtype)
+			} else {
+				s.rrs = append(s.rrs, rr)
+			}
+		}
+	case q.Qtype == dns.TypeAXFR:
+		soa := &dns.SOA{Hdr: dns.RR_Header{Name: q.Name, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: 60}, Ns: "ns." + q.Name, Mbox: "hostmaster." + q.Name}
+		m.Answer = append(append([]dns.RR{soa}, s.rrs...), soa)
+	default:
+		for _, rr := range s.rrs {
+			if strings.EqualFold(rr.Header().Name, q.Name) && rr.Header().Rrtype == q.Qtype {
+				m.Answer = append(m.Answer, rr)
+			}
+		}
+		if len(m.Answer) == 0 {
+			m.Rcode = dns.RcodeNameError
+		}
+	}
+	s.write(w, r, m)
+}
+
+func (s *server) remove(name string, rrtype uint16) {
+	rrs := s.rrs[:0]
+	for _, rr := range s.rrs {
+		if !strings.EqualFold(rr.Header().Name, name) || rr.Header().Rrtype != rrtype {
+			rrs = append(rrs, rr)
+		}
+	}
+	s.rrs = rrs
+}
+
+func (s *server) write(w dns.ResponseWriter, r, m *dns.Msg) {
+	if t := r.IsTsig(); t != nil {
+		m.SetTsig(t.Hdr.Name, t.Algorithm, tsigFudge, int64(t.TimeSigned))
+	}
+	_ = w.WriteMsg(m)
+}
+
+// startServer starts the server on a random local port, and returns its
+// address.
+func startServer(t *testing.T, s *server) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	started := make(chan struct{})
+	srv := &dns.Server{
+		Listener:          l,
+		Handler:           s,
+		TsigSecret:        map[string]string{testTSIGKey: testTSIGSecret},
+		NotifyStartedFunc: func() { close(started) },
+		// The default accept function rejects the updates
+		MsgAcceptFunc: func(dns.Header) dns.MsgAcceptAction { return dns.MsgAccept },
+	}
+	go func() { _ = srv.ActivateAndServe() }()
+	<-started
+	t.Cleanup(func() { _ = srv.Shutdown() })
+
+	return l.Addr().String()
+}
+
+func newRecord(recordType v1.DNSRecordType, dnsName string, targets ...string) *v1.DNSRecord {
+	return &v1.DNSRecord{
+		Spec: v1.DNSRecordSpec{
+			DNSName:    dnsName,
+			RecordType: recordType,
+			RecordTTL:  60,
+			Targets:    targets,
+		},
+	}
+}
+
+func TestProvider(t *testing.T) {
+	zone := v1.DNSZone{ID: testZone}
+
+	tests := []struct {
+		name string
+		tsig bool
+		// config returns the provider configuration, given the server address
+		config  func(addr string) Config
+		wantErr bool
+	}{
+		{
+			name:   "unsigned",
+			config: func(addr string) Config { return Config{Nameserver: addr} },
+		},
+		{
+			name: "signed with TSIG",
+			tsig: true,
+			config: func(addr string) Config {
+				return Config{Nameserver: addr, TSIGKeyName: "kcp-ingress", TSIGSecret: testTSIGSecret, TSIGSecretAlg: "hmac-sha256"}
+			},
+		},
+		{
+			name:    "not signed for a server requiring TSIG",
+			tsig:    true,
+			config:  func(addr string) Config { return Config{Nameserver: addr} },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := startServer(t, &server{tsig: tt.tsig})
+			p, err := NewProvider(tt.config(addr))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			err = p.Ensure(newRecord(v1.ARecordType, "app."+testZone, "10.0.0.1", "10.0.0.2"), zone)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// Ensuring the record again replaces its RRset
+			if err := p.Ensure(newRecord(v1.ARecordType, "app."+testZone, "10.0.0.3"), zone); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := p.Ensure(newRecord(v1.CNAMERecordType, "www."+testZone, "lb.example.com"), zone); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := p.Get("app."+testZone, v1.ARecordType, zone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got == nil || len(got.Targets) != 1 || got.Targets[0] != "10.0.0.3" {
+				t.Errorf("Get() = %v, want targets [10.0.0.3]", got)
+			}
+
+			records, err := p.List(zone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(records) != 2 || records[0].DNSName != "app."+testZone || records[1].Targets[0] != "lb.example.com" {
+				t.Errorf("List() = %v, want the A and CNAME records", records)
+			}
+
+			if err := p.Delete(newRecord(v1.ARecordType, "app."+testZone), zone); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err = p.Get("app."+testZone, v1.ARecordType, zone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != nil {
+				t.Errorf("Get() = %v after deletion, want nil", got)
+			}
+		})
+	}
+}
+
+func TestProviderValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		record  *v1.DNSRecord
+		wantErr bool
+	}{
+		{
+			name:    "missing nameserver",
+			config:  Config{},
+			wantErr: true,
+		},
+		{
+			name:    "TSIG key without secret",
+			config:  Config{Nameserver: "127.0.0.1", TSIGKeyName: "kcp-ingress"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported TSIG algorithm",
+			config:  Config{Nameserver: "127.0.0.1", TSIGKeyName: "kcp-ingress", TSIGSecret: testTSIGSecret, TSIGSecretAlg: "hmac-foo"},
+			wantErr: true,
+		},
+		{
+			name:    "record outside of the zone",
+			config:  Config{Nameserver: "127.0.0.1"},
+			record:  newRecord(v1.ARecordType, "app.example.org", "10.0.0.1"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid IPv4 address",
+			config:  Config{Nameserver: "127.0.0.1"},
+			record:  newRecord(v1.ARecordType, "app."+testZone, "::1"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid IPv6 address",
+			config:  Config{Nameserver: "127.0.0.1"},
+			record:  newRecord(v1.AAAARecordType, "app."+testZone, "10.0.0.1"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewProvider(tt.config)
+			if err == nil && tt.record != nil {
+				// The record is rejected before any message is sent
+				err = p.Ensure(tt.record, v1.DNSZone{ID: testZone})
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
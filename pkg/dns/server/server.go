@@ -0,0 +1,325 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"strings"
+
+	"github.com/miekg/dns"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	kuadrantv1lister "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/listers/kuadrant/v1"
+	dnsprovider "github.com/kuadrant/kcp-ingress/pkg/dns"
+)
+
+const (
+	// soaTTL is the TTL of the SOA record, and the negative caching TTL.
+	soaTTL = 60
+	// maxCNAMEChain bounds the in-zone CNAME chasing.
+	maxCNAMEChain = 8
+)
+
+// Server is an authoritative DNS server for a domain, that answers A, AAAA and
+// CNAME queries directly from the DNSRecords, honouring the targets weights
+// and health.
+// The DNSRecords under a ManagedZone are only answered once they have been
+// published to that zone, so that the records in conflict with the ones owned
+// by someone else are not answered either. The other ones, e.g., when no
+// ManagedZone covers the domain, are answered as soon as they are created.
+type Server struct {
+	port       uint
+	domain     string
+	lister     kuadrantv1lister.DNSRecordLister
+	zoneLister kuadrantv1lister.ManagedZoneLister
+}
+
+func NewServer(port uint, domain string, lister kuadrantv1lister.DNSRecordLister, zoneLister kuadrantv1lister.ManagedZoneLister) *Server {
+	return &Server{
+		port:       port,
+		domain:     dns.CanonicalName(domain),
+		lister:     lister,
+		zoneLister: zoneLister,
+	}
+}
+
+// ListenAndServe starts serving queries over UDP and TCP, and blocks until
+// one of the listeners fails.
+func (s *Server) ListenAndServe() error {
+	addr := fmt.Sprintf(":%d", s.port)
+	errCh := make(chan error, 2)
+	for _, network := range []string{"udp", "tcp"} {
+		server := &dns.Server{Addr: addr, Net: network, Handler: s}
+		go func() {
+			errCh <- server.ListenAndServe()
+		}()
+	}
+	klog.Infof("Serving DNS zone %q on %s", s.domain, addr)
+	return <-errCh
+}
+
+// ServeDNS implements dns.Handler.
+func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+
+	if len(r.Question) != 1 || r.Opcode != dns.OpcodeQuery {
+		m.SetRcode(r, dns.RcodeNotImplemented)
+		s.write(w, m)
+		return
+	}
+
+	q := r.Question[0]
+	name := dns.CanonicalName(q.Name)
+	if !dns.IsSubDomain(s.domain, name) {
+		m.Authoritative = false
+		m.SetRcode(r, dns.RcodeRefused)
+		s.write(w, m)
+		return
+	}
+
+	if err := s.answer(m, name, q.Qtype); err != nil {
+		klog.Errorf("failed to answer DNS query for %q: %v", name, err)
+		m.SetRcode(r, dns.RcodeServerFailure)
+	}
+	s.write(w, m)
+}
+
+func (s *Server) answer(m *dns.Msg, name string, qtype uint16) error {
+	if name == s.domain && (qtype == dns.TypeSOA || qtype == dns.TypeANY) {
+		m.Answer = append(m.Answer, s.soa())
+		return nil
+	}
+
+	records, err := s.answeredRecords()
+	if err != nil {
+		return err
+	}
+
+	// As per RFC 1034, the response code applies to the last name of the CNAME chain.
+	var exists bool
+	for i := 0; i < maxCNAMEChain; i++ {
+		var rrs []dns.RR
+		var cname string
+		rrs, cname, exists = s.lookup(records, name, qtype)
+		m.Answer = append(m.Answer, rrs...)
+
+		// Chase the CNAME while its target is within the zone.
+		if cname == "" || qtype == dns.TypeCNAME || !dns.IsSubDomain(s.domain, cname) {
+			break
+		}
+		name = cname
+	}
+
+	if !exists && name != s.domain {
+		m.Rcode = dns.RcodeNameError
+	}
+	if len(m.Answer) == 0 {
+		// Negative answer, let the resolvers cache it.
+		m.Ns = append(m.Ns, s.soa())
+	}
+
+	return nil
+}
+
+// lookup returns the records of the DNSRecords for name that answer the query
+// type, the CNAME target if name is an alias, and whether name exists at all.
+func (s *Server) lookup(records []*v1.DNSRecord, name string, qtype uint16) ([]dns.RR, string, bool) {
+	var addresses []*v1.DNSRecord
+	var txts []dns.RR
+	var ttl uint32
+	exists := false
+
+	for _, record := range records {
+		if dns.CanonicalName(record.Spec.DNSName) != name {
+			continue
+		}
+
+		hdr := func(rrtype uint16) dns.RR_Header {
+			return dns.RR_Header{Name: name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: dnsprovider.TTL(record.Spec)}
+		}
+
+		switch record.Spec.RecordType {
+		case v1.CNAMERecordType:
+			if len(record.Spec.Targets) == 0 {
+				continue
+			}
+			// A CNAME answers any query type, and there can only be one.
+			cname := dns.CanonicalName(record.Spec.Targets[0])
+			rrs := []dns.RR{&dns.CNAME{Hdr: hdr(dns.TypeCNAME), Target: cname}}
+			return rrs, cname, true
+		case v1.ARecordType, v1.AAAARecordType:
+			addresses = append(addresses, record)
+			ttl = dnsprovider.TTL(record.Spec)
+		case v1.TXTRecordType:
+			// The TXT records, e.g., the ACME challenges, are all answered
+			exists = true
+			if qtype == dns.TypeTXT || qtype == dns.TypeANY {
+				for _, target := range record.Spec.Targets {
+					txts = append(txts, &dns.TXT{Hdr: hdr(dns.TypeTXT), Txt: []string{target}})
+				}
+			}
+		}
+	}
+
+	if len(addresses) == 0 {
+		return txts, "", exists
+	}
+
+	hdr := func(rrtype uint16) dns.RR_Header {
+		return dns.RR_Header{Name: name, Rrtype: rrtype, Class: dns.ClassINET, Ttl: ttl}
+	}
+
+	// The drained and unhealthy targets are not answered
+	var candidates []weightedRR
+	for _, target := range dnsprovider.AnsweredTargets(addresses...) {
+		ip := net.ParseIP(target.Value)
+		switch {
+		case ip == nil:
+			continue
+		case ip.To4() != nil && (qtype == dns.TypeA || qtype == dns.TypeANY):
+			candidates = append(candidates, weightedRR{&dns.A{Hdr: hdr(dns.TypeA), A: ip.To4()}, target.Weight})
+		case ip.To4() == nil && (qtype == dns.TypeAAAA || qtype == dns.TypeANY):
+			candidates = append(candidates, weightedRR{&dns.AAAA{Hdr: hdr(dns.TypeAAAA), AAAA: ip}, target.Weight})
+		}
+	}
+
+	return append(order(dedup(candidates)), txts...), "", true
+}
+
+// answeredRecords returns the DNSRecords the server answers, i.e., the ones
+// that are not being deleted, and that are published to the ManagedZone of
+// their DNS name, if any.
+func (s *Server) answeredRecords() ([]*v1.DNSRecord, error) {
+	records, err := s.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	zones, err := s.zoneLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var answered []*v1.DNSRecord
+	for _, record := range records {
+		if record.DeletionTimestamp != nil {
+			continue
+		}
+		if zone := zoneFor(zones, record.Spec.DNSName); zone != nil && !isPublished(record, zone.ZoneID()) {
+			continue
+		}
+		answered = append(answered, record)
+	}
+	return answered, nil
+}
+
+// zoneFor returns the ManagedZone with the longest domain name the DNS name
+// is a subdomain of, or nil if there is none.
+func zoneFor(zones []*v1.ManagedZone, dnsName string) *v1.ManagedZone {
+	name := dns.CanonicalName(dnsName)
+
+	var match *v1.ManagedZone
+	for _, zone := range zones {
+		domain := dns.CanonicalName(zone.Spec.DomainName)
+		if !dns.IsSubDomain(domain, name) {
+			continue
+		}
+		if match == nil || len(domain) > len(dns.CanonicalName(match.Spec.DomainName)) {
+			match = zone
+		}
+	}
+	return match
+}
+
+// isPublished returns whether the record is published to the zone.
+func isPublished(record *v1.DNSRecord, zoneID string) bool {
+	for _, zone := range record.Status.Zones {
+		if zone.DNSZone.ID != zoneID {
+			continue
+		}
+		for _, condition := range zone.Conditions {
+			if condition.Type == v1.DNSRecordPublishedConditionType && condition.Status == string(metav1.ConditionTrue) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Server) soa() dns.RR {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: s.domain, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: soaTTL},
+		Ns:      "ns." + s.domain,
+		Mbox:    "hostmaster." + s.domain,
+		Serial:  1,
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  86400,
+		Minttl:  soaTTL,
+	}
+}
+
+func (s *Server) write(w dns.ResponseWriter, m *dns.Msg) {
+	if err := w.WriteMsg(m); err != nil {
+		klog.Errorf("failed to write DNS response: %v", err)
+	}
+}
+
+// weightedRR is a record answering a query, along with the weight of the
+// target it is built from.
+type weightedRR struct {
+	rr     dns.RR
+	weight int64
+}
+
+// dedup removes the duplicated records, e.g., the same load-balancer address
+// reported by several DNSRecords for the same name.
+func dedup(candidates []weightedRR) []weightedRR {
+	seen := map[string]struct{}{}
+	result := candidates[:0]
+	for _, candidate := range candidates {
+		key := strings.TrimPrefix(candidate.rr.String(), candidate.rr.Header().String())
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, candidate)
+	}
+	return result
+}
+
+// order returns all the records, in a random order weighted by their targets
+// weights, i.e., each record comes first with a probability in proportion to
+// its weight, so that the traffic of the clients using the first address is
+// shared across the targets accordingly, while the others can still fall back
+// to the rest of the RRset.
+func order(candidates []weightedRR) []dns.RR {
+	var total int64
+	for _, candidate := range candidates {
+		total += candidate.weight
+	}
+
+	remaining := append([]weightedRR(nil), candidates...)
+	rrs := make([]dns.RR, 0, len(candidates))
+	for len(remaining) > 0 {
+		next := 0
+		if total > 0 {
+			n := rand.Int63n(total)
+			for i, candidate := range remaining {
+				if n < candidate.weight {
+					next = i
+					break
+				}
+				n -= candidate.weight
+			}
+		}
+		rrs = append(rrs, remaining[next].rr)
+		total -= remaining[next].weight
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+	return rrs
+}
@@ -0,0 +1,271 @@
+package server
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/miekg/dns"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	kuadrantv1lister "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/listers/kuadrant/v1"
+)
+
+const domain = "kcp-apps.example.com"
+
+func newRecord(name, dnsName string, recordType v1.DNSRecordType, targets ...string) *v1.DNSRecord {
+	return &v1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec:       v1.DNSRecordSpec{DNSName: dnsName, RecordType: recordType, Targets: targets, RecordTTL: 60},
+	}
+}
+
+// published returns the record, reported as published to the zone.
+func published(record *v1.DNSRecord, zoneID string) *v1.DNSRecord {
+	record.Status.Zones = []v1.DNSZoneStatus{{
+		DNSZone: v1.DNSZone{ID: zoneID},
+		Conditions: []v1.DNSZoneCondition{{
+			Type:   v1.DNSRecordPublishedConditionType,
+			Status: string(metav1.ConditionTrue),
+		}},
+	}}
+	return record
+}
+
+func newServer(t *testing.T, records []*v1.DNSRecord, zones []*v1.ManagedZone) *Server {
+	t.Helper()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, record := range records {
+		if err := indexer.Add(record); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	zoneIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, zone := range zones {
+		if err := zoneIndexer.Add(zone); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return NewServer(0, domain, kuadrantv1lister.NewDNSRecordLister(indexer), kuadrantv1lister.NewManagedZoneLister(zoneIndexer))
+}
+
+func TestAnswer(t *testing.T) {
+	now := metav1.Now()
+	zone := &v1.ManagedZone{
+		ObjectMeta: metav1.ObjectMeta{Name: "apps"},
+		Spec:       v1.ManagedZoneSpec{DomainName: "zone." + domain},
+	}
+
+	tests := []struct {
+		name    string
+		records []*v1.DNSRecord
+		zones   []*v1.ManagedZone
+		qname   string
+		qtype   uint16
+		// wantAnswer is the data of the answered records, in any order
+		wantAnswer []string
+		wantRcode  int
+	}{
+		{
+			name: "addresses",
+			records: []*v1.DNSRecord{
+				newRecord("app-a", "app."+domain, v1.ARecordType, "10.0.0.1", "10.0.0.2"),
+				newRecord("app-aaaa", "app."+domain, v1.AAAARecordType, "::1"),
+			},
+			qname:      "app." + domain,
+			qtype:      dns.TypeA,
+			wantAnswer: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name: "addresses, deduplicated across records",
+			records: []*v1.DNSRecord{
+				newRecord("app-a", "app."+domain, v1.ARecordType, "10.0.0.1"),
+				newRecord("other-a", "App."+domain+".", v1.ARecordType, "10.0.0.1", "10.0.0.2"),
+			},
+			qname:      "app." + domain,
+			qtype:      dns.TypeA,
+			wantAnswer: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name: "drained target",
+			records: []*v1.DNSRecord{
+				func() *v1.DNSRecord {
+					record := newRecord("app-a", "app."+domain, v1.ARecordType, "10.0.0.1", "10.0.0.2")
+					record.Spec.TargetAttributes = []v1.DNSTargetAttributes{
+						{Target: "10.0.0.1", Weight: pointer.Int64(0)},
+						{Target: "10.0.0.2", Weight: pointer.Int64(100)},
+					}
+					return record
+				}(),
+			},
+			qname:      "app." + domain,
+			qtype:      dns.TypeA,
+			wantAnswer: []string{"10.0.0.2"},
+		},
+		{
+			name: "no address of the query type",
+			records: []*v1.DNSRecord{
+				newRecord("app-a", "app."+domain, v1.ARecordType, "10.0.0.1"),
+			},
+			qname:      "app." + domain,
+			qtype:      dns.TypeAAAA,
+			wantAnswer: nil,
+		},
+		{
+			name:      "unknown name",
+			qname:     "missing." + domain,
+			qtype:     dns.TypeA,
+			wantRcode: dns.RcodeNameError,
+		},
+		{
+			name: "record being deleted",
+			records: []*v1.DNSRecord{
+				func() *v1.DNSRecord {
+					record := newRecord("app-a", "app."+domain, v1.ARecordType, "10.0.0.1")
+					record.DeletionTimestamp = &now
+					return record
+				}(),
+			},
+			qname:     "app." + domain,
+			qtype:     dns.TypeA,
+			wantRcode: dns.RcodeNameError,
+		},
+		{
+			name: "CNAME chased within the zone",
+			records: []*v1.DNSRecord{
+				newRecord("app-cname", "app."+domain, v1.CNAMERecordType, "lb."+domain),
+				newRecord("lb-a", "lb."+domain, v1.ARecordType, "10.0.0.1"),
+			},
+			qname:      "app." + domain,
+			qtype:      dns.TypeA,
+			wantAnswer: []string{"lb." + domain + ".", "10.0.0.1"},
+		},
+		{
+			name: "CNAME to a missing name within the zone",
+			records: []*v1.DNSRecord{
+				newRecord("app-cname", "app."+domain, v1.CNAMERecordType, "lb."+domain),
+			},
+			qname:      "app." + domain,
+			qtype:      dns.TypeA,
+			wantAnswer: []string{"lb." + domain + "."},
+			wantRcode:  dns.RcodeNameError,
+		},
+		{
+			name: "CNAME outside of the zone",
+			records: []*v1.DNSRecord{
+				newRecord("app-cname", "app."+domain, v1.CNAMERecordType, "lb.example.org"),
+				newRecord("lb-a", "lb.example.org", v1.ARecordType, "10.0.0.1"),
+			},
+			qname:      "app." + domain,
+			qtype:      dns.TypeA,
+			wantAnswer: []string{"lb.example.org."},
+		},
+		{
+			name: "CNAME loop",
+			records: []*v1.DNSRecord{
+				newRecord("a-cname", "a."+domain, v1.CNAMERecordType, "b."+domain),
+				newRecord("b-cname", "b."+domain, v1.CNAMERecordType, "a."+domain),
+			},
+			qname: "a." + domain,
+			qtype: dns.TypeA,
+			wantAnswer: []string{
+				"b." + domain + ".", "a." + domain + ".", "b." + domain + ".", "a." + domain + ".",
+				"b." + domain + ".", "a." + domain + ".", "b." + domain + ".", "a." + domain + ".",
+			},
+		},
+		{
+			name: "CNAME query",
+			records: []*v1.DNSRecord{
+				newRecord("app-cname", "app."+domain, v1.CNAMERecordType, "lb."+domain),
+				newRecord("lb-a", "lb."+domain, v1.ARecordType, "10.0.0.1"),
+			},
+			qname:      "app." + domain,
+			qtype:      dns.TypeCNAME,
+			wantAnswer: []string{"lb." + domain + "."},
+		},
+		{
+			name: "TXT record",
+			records: []*v1.DNSRecord{
+				newRecord("app-acme-challenge", "_acme-challenge.app."+domain, v1.TXTRecordType, "response"),
+			},
+			qname:      "_acme-challenge.app." + domain,
+			qtype:      dns.TypeTXT,
+			wantAnswer: []string{`"response"`},
+		},
+		{
+			name: "record under a managed zone, not published",
+			records: []*v1.DNSRecord{
+				newRecord("app-a", "app.zone."+domain, v1.ARecordType, "10.0.0.1"),
+			},
+			zones:     []*v1.ManagedZone{zone},
+			qname:     "app.zone." + domain,
+			qtype:     dns.TypeA,
+			wantRcode: dns.RcodeNameError,
+		},
+		{
+			name: "record under a managed zone, published to another zone",
+			records: []*v1.DNSRecord{
+				published(newRecord("app-a", "app.zone."+domain, v1.ARecordType, "10.0.0.1"), domain),
+			},
+			zones:     []*v1.ManagedZone{zone},
+			qname:     "app.zone." + domain,
+			qtype:     dns.TypeA,
+			wantRcode: dns.RcodeNameError,
+		},
+		{
+			name: "record under a managed zone, published",
+			records: []*v1.DNSRecord{
+				published(newRecord("app-a", "app.zone."+domain, v1.ARecordType, "10.0.0.1"), "zone."+domain),
+			},
+			zones:      []*v1.ManagedZone{zone},
+			qname:      "app.zone." + domain,
+			qtype:      dns.TypeA,
+			wantAnswer: []string{"10.0.0.1"},
+		},
+		{
+			name: "record outside of the managed zones",
+			records: []*v1.DNSRecord{
+				newRecord("app-a", "app."+domain, v1.ARecordType, "10.0.0.1"),
+			},
+			zones:      []*v1.ManagedZone{zone},
+			qname:      "app." + domain,
+			qtype:      dns.TypeA,
+			wantAnswer: []string{"10.0.0.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t, tt.records, tt.zones)
+			m := new(dns.Msg)
+
+			if err := s.answer(m, dns.CanonicalName(tt.qname), tt.qtype); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m.Rcode != tt.wantRcode {
+				t.Errorf("rcode = %s, want %s", dns.RcodeToString[m.Rcode], dns.RcodeToString[tt.wantRcode])
+			}
+
+			var answer []string
+			for _, rr := range m.Answer {
+				answer = append(answer, rr.String()[len(rr.Header().String()):])
+			}
+			// The addresses come in a random order
+			sort.Strings(answer)
+			sort.Strings(tt.wantAnswer)
+			if len(answer) != len(tt.wantAnswer) {
+				t.Fatalf("answer = %v, want %v", answer, tt.wantAnswer)
+			}
+			for i := range answer {
+				if answer[i] != tt.wantAnswer[i] {
+					t.Errorf("answer = %v, want %v", answer, tt.wantAnswer)
+				}
+			}
+			if len(m.Answer) == 0 && len(m.Ns) != 1 {
+				t.Errorf("authority = %v, want the SOA record for negative caching", m.Ns)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package dns
+
+import (
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+// DefaultWeight is the weight of the targets which attributes do not set one.
+const DefaultWeight = 1
+
+// Target is a record target, along with its routing attributes and health.
+type Target struct {
+	Value   string
+	Cluster string
+	Weight  int64
+	Geo     string
+	Healthy bool
+}
+
+// Targets returns the targets of the record, along with their attributes and
+// their health, as reported in the record status. The targets that are not
+// health checked, or have not been probed yet, are healthy.
+func Targets(record *v1.DNSRecord) []Target {
+	attributes := make(map[string]v1.DNSTargetAttributes, len(record.Spec.TargetAttributes))
+	for _, a := range record.Spec.TargetAttributes {
+		attributes[a.Target] = a
+	}
+
+	unhealthy := map[string]bool{}
+	if record.Spec.HealthCheck != nil {
+		for _, status := range record.Status.Targets {
+			unhealthy[status.Target] = !status.Healthy
+		}
+	}
+
+	targets := make([]Target, 0, len(record.Spec.Targets))
+	for _, value := range record.Spec.Targets {
+		target := Target{Value: value, Weight: DefaultWeight, Healthy: !unhealthy[value]}
+		if a, ok := attributes[value]; ok {
+			target.Cluster = a.Cluster
+			target.Geo = a.Geo
+			if a.Weight != nil {
+				target.Weight = *a.Weight
+			}
+		}
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// AnsweredTargets returns the targets of the records, for the same DNS name,
+// that should be answered, i.e., the ones that are not drained, and that are
+// healthy, unless none of them is, so that the name keeps resolving.
+func AnsweredTargets(records ...*v1.DNSRecord) []Target {
+	var targets []Target
+	healthy := false
+	for _, record := range records {
+		for _, target := range Targets(record) {
+			if target.Weight == 0 {
+				continue
+			}
+			targets = append(targets, target)
+			healthy = healthy || target.Healthy
+		}
+	}
+
+	if !healthy {
+		return targets
+	}
+
+	answered := targets[:0]
+	for _, target := range targets {
+		if target.Healthy {
+			answered = append(answered, target)
+		}
+	}
+	return answered
+}
@@ -24,6 +24,9 @@ type Cache struct {
 	mu         sync.Mutex
 	ingresses  *gocache.Cache
 	translator *translator
+	// challenges holds the responses to the ACME HTTP-01 challenges, by host
+	// and path.
+	challenges map[string]map[string]string
 }
 
 func NewCache(translator *translator) *Cache {
@@ -31,6 +34,7 @@ func NewCache(translator *translator) *Cache {
 		mu:         sync.Mutex{},
 		ingresses:  gocache.New(gocache.NoExpiration, defaultCleanupInterval),
 		translator: translator,
+		challenges: make(map[string]map[string]string),
 	}
 }
 
@@ -47,6 +51,27 @@ func (c *Cache) DeleteIngress(key string) {
 	c.ingresses.Delete(key)
 }
 
+// SetChallenge serves the response to an ACME HTTP-01 challenge for the host,
+// at the given path.
+func (c *Cache) SetChallenge(host, path, response string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.challenges[host] == nil {
+		c.challenges[host] = make(map[string]string)
+	}
+	c.challenges[host][path] = response
+}
+
+// DeleteChallenge stops serving the response to an ACME HTTP-01 challenge.
+func (c *Cache) DeleteChallenge(host, path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.challenges[host], path)
+	if len(c.challenges[host]) == 0 {
+		delete(c.challenges, host)
+	}
+}
+
 func (c *Cache) ToEnvoySnapshot() cache.Snapshot {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -60,6 +85,8 @@ func (c *Cache) ToEnvoySnapshot() cache.Snapshot {
 		virtualhosts = append(virtualhosts, ingvhosts...)
 	}
 
+	virtualhosts = c.translator.addChallengeRoutes(virtualhosts, c.challenges)
+
 	routeConfig := c.translator.newRouteConfig("defaultroute", virtualhosts)
 	hcm := c.translator.newHTTPConnectionManager(routeConfig.Name)
 	listener, _ := c.translator.newHTTPListener(hcm)
@@ -0,0 +1,119 @@
+package envoy
+
+import (
+	"testing"
+
+	envoyclusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	envoyroutev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const challengePath = "/.well-known/acme-challenge/token"
+
+func TestCacheChallenges(t *testing.T) {
+	port := uint(8080)
+
+	tests := []struct {
+		name string
+		// update sets the challenges and ingresses of the cache
+		update func(c *Cache)
+		// wantRoutes are the routes, per virtual host domain
+		wantRoutes map[string][]string
+	}{
+		{
+			name: "host without virtual host",
+			update: func(c *Cache) {
+				c.SetChallenge("app.kcp-apps.example.com", challengePath, "response")
+			},
+			wantRoutes: map[string][]string{
+				"app.kcp-apps.example.com": {"acme-challenge" + challengePath},
+			},
+		},
+		{
+			name: "host with a virtual host",
+			update: func(c *Cache) {
+				c.UpdateIngress(newIngress("app", "app.kcp-apps.example.com"))
+				c.SetChallenge("app.kcp-apps.example.com", challengePath, "response")
+			},
+			wantRoutes: map[string][]string{
+				// The challenge route comes ahead of the Ingress ones
+				"app.kcp-apps.example.com": {"acme-challenge" + challengePath, "appdefault0"},
+			},
+		},
+		{
+			name: "deleted challenge",
+			update: func(c *Cache) {
+				c.UpdateIngress(newIngress("app", "app.kcp-apps.example.com"))
+				c.SetChallenge("app.kcp-apps.example.com", challengePath, "response")
+				c.DeleteChallenge("app.kcp-apps.example.com", challengePath)
+				c.SetChallenge("other.kcp-apps.example.com", challengePath, "response")
+				c.DeleteChallenge("other.kcp-apps.example.com", challengePath)
+			},
+			wantRoutes: map[string][]string{
+				"app.kcp-apps.example.com": {"appdefault0"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCache(NewTranslator(&port, envoyclusterv3.Cluster_V4_ONLY))
+			tt.update(c)
+
+			snapshot := c.ToEnvoySnapshot()
+			routes := snapshot.GetResources(resource.RouteType)
+			config, ok := routes["defaultroute"].(*envoyroutev3.RouteConfiguration)
+			if !ok {
+				t.Fatalf("no default route configuration in %v", routes)
+			}
+
+			got := map[string][]string{}
+			for _, vh := range config.VirtualHosts {
+				for _, route := range vh.Routes {
+					got[vh.Domains[0]] = append(got[vh.Domains[0]], route.Name)
+					if route.GetDirectResponse() != nil && route.GetDirectResponse().GetBody().GetInlineString() != "response" {
+						t.Errorf("route %q answers %v, want the challenge response", route.Name, route.GetDirectResponse().GetBody())
+					}
+				}
+			}
+			if len(got) != len(tt.wantRoutes) {
+				t.Fatalf("routes = %v, want %v", got, tt.wantRoutes)
+			}
+			for domain, want := range tt.wantRoutes {
+				if len(got[domain]) != len(want) {
+					t.Fatalf("routes = %v, want %v", got, tt.wantRoutes)
+				}
+				for i := range want {
+					if got[domain][i] != want[i] {
+						t.Errorf("routes = %v, want %v", got, tt.wantRoutes)
+					}
+				}
+			}
+		})
+	}
+}
+
+func newIngress(name, host string) networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+	return networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{Name: "httpecho", Port: networkingv1.ServiceBackendPort{Number: 80}},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+}
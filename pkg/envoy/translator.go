@@ -2,7 +2,9 @@ package envoy
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	envoyclusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
@@ -22,14 +24,26 @@ import (
 
 type translator struct {
 	envoyListenPort *uint
+	dnsLookupFamily envoyclusterv3.Cluster_DnsLookupFamily
 }
 
-func NewTranslator(envoyListenPort *uint) *translator {
+func NewTranslator(envoyListenPort *uint, dnsLookupFamily envoyclusterv3.Cluster_DnsLookupFamily) *translator {
 	return &translator{
 		envoyListenPort: envoyListenPort,
+		dnsLookupFamily: dnsLookupFamily,
 	}
 }
 
+// ParseDNSLookupFamily parses the DNS lookup family of the upstream clusters,
+// e.g., "V4_ONLY" or "ALL".
+func ParseDNSLookupFamily(family string) (envoyclusterv3.Cluster_DnsLookupFamily, error) {
+	value, ok := envoyclusterv3.Cluster_DnsLookupFamily_value[strings.ToUpper(family)]
+	if !ok {
+		return 0, fmt.Errorf("unsupported DNS lookup family %q", family)
+	}
+	return envoyclusterv3.Cluster_DnsLookupFamily(value), nil
+}
+
 func (t *translator) translateIngress(ingress networkingv1.Ingress) ([]cachetypes.Resource, []*envoyroutev3.VirtualHost) {
 
 	// TODO(jmprusi): Hardcoded port, also, not TLS support. Review
@@ -46,7 +60,7 @@ func (t *translator) translateIngress(ingress networkingv1.Ingress) ([]cachetype
 
 	//TODO(jmprusi): HTTP2 is set to false always, also allow for configuration of the timeout
 	cluster := t.newCluster(ingressToKey(ingress), 2*time.Second, endpoints, envoyclusterv3.Cluster_STRICT_DNS)
-	cluster.DnsLookupFamily = envoyclusterv3.Cluster_V4_ONLY
+	cluster.DnsLookupFamily = t.dnsLookupFamily
 
 	virtualHosts := make([]*envoyroutev3.VirtualHost, 0)
 	routes := make([]*envoyroutev3.Route, 0)
@@ -97,6 +111,65 @@ func (t *translator) translateIngress(ingress networkingv1.Ingress) ([]cachetype
 	return []cachetypes.Resource{cluster}, virtualHosts
 }
 
+// addChallengeRoutes adds the routes answering the ACME HTTP-01 challenges
+// directly, ahead of the routes of the virtual hosts of their hosts, or to
+// new virtual hosts for the hosts that have none yet.
+func (t *translator) addChallengeRoutes(virtualHosts []*envoyroutev3.VirtualHost, challenges map[string]map[string]string) []*envoyroutev3.VirtualHost {
+	hosts := make([]string, 0, len(challenges))
+	for host := range challenges {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		paths := make([]string, 0, len(challenges[host]))
+		for path := range challenges[host] {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		routes := make([]*envoyroutev3.Route, 0, len(paths))
+		for _, path := range paths {
+			routes = append(routes, &envoyroutev3.Route{
+				Name: "acme-challenge" + path,
+				Match: &envoyroutev3.RouteMatch{
+					PathSpecifier: &envoyroutev3.RouteMatch_Path{
+						Path: path,
+					},
+				},
+				Action: &envoyroutev3.Route_DirectResponse{
+					DirectResponse: &envoyroutev3.DirectResponseAction{
+						Status: 200,
+						Body: &envoycorev3.DataSource{
+							Specifier: &envoycorev3.DataSource_InlineString{InlineString: challenges[host][path]},
+						},
+					},
+				},
+			})
+		}
+
+		found := false
+		for _, vh := range virtualHosts {
+			for _, domain := range vh.Domains {
+				if domain == host {
+					vh.Routes = append(routes, vh.Routes...)
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			virtualHosts = append(virtualHosts, &envoyroutev3.VirtualHost{
+				Name:    "acme-challenge/" + host,
+				Domains: []string{host, host + ":*"},
+				Routes:  routes,
+			})
+		}
+	}
+
+	return virtualHosts
+}
+
 func (t *translator) newLBEndpoint(ip string, port uint32) *envoyendpointv3.LbEndpoint {
 	return &envoyendpointv3.LbEndpoint{
 		HostIdentifier: &envoyendpointv3.LbEndpoint_Endpoint{
@@ -109,7 +182,7 @@ func (t *translator) newLBEndpoint(ip string, port uint32) *envoyendpointv3.LbEn
 							PortSpecifier: &envoycorev3.SocketAddress_PortValue{
 								PortValue: port,
 							},
-							Ipv4Compat: true,
+							Ipv4Compat: t.dnsLookupFamily == envoyclusterv3.Cluster_V4_ONLY,
 						},
 					},
 				},
@@ -0,0 +1,101 @@
+package certificate
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog"
+
+	"github.com/kuadrant/kcp-ingress/pkg/acme"
+)
+
+const (
+	clusterLabel            = "kcp.dev/cluster"
+	hostGeneratedAnnotation = "kuadrant.dev/host.generated"
+
+	// hostAnnotation records the host the certificate has been obtained for.
+	hostAnnotation = "kuadrant.dev/acme.host"
+
+	// issuanceTimeout bounds the time a certificate takes to be obtained,
+	// including the validation of the challenges.
+	issuanceTimeout = 5 * time.Minute
+)
+
+// reconcile ensures the certificate of the host generated for the root Ingress
+// is valid, and returns the time it should be renewed at.
+func (c *Controller) reconcile(ctx context.Context, ingress *networkingv1.Ingress) (time.Time, error) {
+	host := ingress.Annotations[hostGeneratedAnnotation]
+	if ingress.Labels[clusterLabel] != "" || host == "" {
+		// Only the generated hosts of the root Ingresses get certificates
+		return time.Time{}, nil
+	}
+	if ingress.DeletionTimestamp != nil || (c.isHandled != nil && !c.isHandled(ingress)) {
+		// The Ingresses being deleted, or of other classes, have their certificate deleted
+		// by the Ingress controller
+		return time.Time{}, nil
+	}
+
+	name := acme.CertificateSecretName(ingress.Name)
+	secret, err := c.secretLister.Secrets(ingress.Namespace).Get(clusters.ToClusterAwareKey(ingress.ClusterName, name))
+	if err != nil && !errors.IsNotFound(err) {
+		return time.Time{}, err
+	}
+	if secret != nil {
+		renewal := acme.RenewalTime(secret.Data[corev1.TLSCertKey], host, c.renewBefore)
+		if time.Now().Before(renewal) {
+			return renewal, nil
+		}
+	}
+
+	klog.Infof("obtaining certificate for host %q of Ingress %q", host, ingress.Name)
+	solver := c.solver
+	if solver == nil {
+		solver = &dns01Solver{client: c.dnsRecordClient, ingress: ingress, servedDomain: c.servedDomain}
+	}
+	ctx, cancel := context.WithTimeout(ctx, issuanceTimeout)
+	defer cancel()
+	certPEM, keyPEM, err := c.issuer.Obtain(ctx, host, solver)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	desired := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   ingress.Namespace,
+			ClusterName: ingress.ClusterName,
+			Annotations: map[string]string{hostAnnotation: host},
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: networkingv1.SchemeGroupVersion.String(),
+				Kind:       "Ingress",
+				Name:       ingress.Name,
+				UID:        ingress.UID,
+			}},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}
+	if secret == nil {
+		_, err = c.client.CoreV1().Secrets(ingress.Namespace).Create(ctx, desired, metav1.CreateOptions{})
+	} else {
+		updated := secret.DeepCopy()
+		updated.Annotations = desired.Annotations
+		updated.OwnerReferences = desired.OwnerReferences
+		updated.Type = desired.Type
+		updated.Data = desired.Data
+		_, err = c.client.CoreV1().Secrets(ingress.Namespace).Update(ctx, updated, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return acme.RenewalTime(certPEM, host, c.renewBefore), nil
+}
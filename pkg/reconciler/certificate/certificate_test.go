@@ -0,0 +1,73 @@
+package certificate
+
+import (
+	"context"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcileIgnored(t *testing.T) {
+	now := metav1.Now()
+	root := func() *networkingv1.Ingress {
+		return &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Name:        "app",
+				Annotations: map[string]string{hostGeneratedAnnotation: testHost},
+			},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		ingress   *networkingv1.Ingress
+		isHandled func(*networkingv1.Ingress) bool
+	}{
+		{
+			name: "leaf",
+			ingress: func() *networkingv1.Ingress {
+				leaf := root()
+				leaf.Labels = map[string]string{clusterLabel: "cluster"}
+				return leaf
+			}(),
+		},
+		{
+			name: "root without generated host",
+			ingress: func() *networkingv1.Ingress {
+				ingress := root()
+				ingress.Annotations = nil
+				return ingress
+			}(),
+		},
+		{
+			name: "root being deleted",
+			ingress: func() *networkingv1.Ingress {
+				ingress := root()
+				ingress.DeletionTimestamp = &now
+				return ingress
+			}(),
+		},
+		{
+			name:      "root of another class",
+			ingress:   root(),
+			isHandled: func(*networkingv1.Ingress) bool { return false },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No certificate is obtained, as there is neither an issuer nor a Secret lister
+			c := &Controller{isHandled: tt.isHandled}
+
+			renewal, err := c.reconcile(context.Background(), tt.ingress)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !renewal.IsZero() {
+				t.Errorf("renewal = %v, want none", renewal)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package certificate
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	"github.com/kuadrant/kcp-ingress/pkg/acme"
+	kuadrantv1 "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned"
+)
+
+const (
+	resyncPeriod = 10 * time.Hour
+
+	defaultRenewBefore = 30 * 24 * time.Hour
+)
+
+// NewController returns a new Controller which obtains the certificates of
+// the hosts generated for the root Ingresses from an ACME server, and renews
+// them before they expire.
+func NewController(config *ControllerConfig) *Controller {
+	client := kubernetes.NewForConfigOrDie(config.Cfg)
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	stopCh := make(chan struct{}) // TODO: hook this up to SIGTERM/SIGINT
+
+	c := &Controller{
+		queue:           queue,
+		client:          client,
+		dnsRecordClient: kuadrantv1.NewForConfigOrDie(config.Cfg),
+		stopCh:          stopCh,
+		issuer:          config.Issuer,
+		solver:          config.Solver,
+		isHandled:       config.IsHandled,
+		renewBefore:     defaultRenewBefore,
+	}
+
+	if config.RenewBefore != nil {
+		c.renewBefore = *config.RenewBefore
+	}
+
+	if config.ServedDomain != nil {
+		c.servedDomain = *config.ServedDomain
+	}
+
+	sif := informers.NewSharedInformerFactoryWithOptions(c.client, resyncPeriod)
+
+	// Watch for events related to Ingresses
+	sif.Networking().V1().Ingresses().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+	})
+
+	// Watch for the deletion of the certificates Secrets, that are obtained again
+	sif.Core().V1().Secrets().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) { c.ingressFromSecret(obj) },
+	})
+
+	sif.Start(stopCh)
+	for inf, sync := range sif.WaitForCacheSync(stopCh) {
+		if !sync {
+			klog.Fatalf("Failed to sync %s", inf)
+		}
+	}
+
+	c.indexer = sif.Networking().V1().Ingresses().Informer().GetIndexer()
+	c.secretLister = sif.Core().V1().Secrets().Lister()
+
+	return c
+}
+
+type ControllerConfig struct {
+	Cfg    *rest.Config
+	Issuer *acme.Issuer
+	// Solver presents the responses to the ACME challenges. The DNS-01
+	// challenges are presented with DNSRecords if nil.
+	Solver      acme.Solver
+	RenewBefore *time.Duration
+	// IsHandled returns whether the Ingress is meant for the Ingress controller,
+	// which is the case of all the Ingresses if nil.
+	IsHandled func(*networkingv1.Ingress) bool
+	// ServedDomain is the domain of the embedded DNS server, which answers the
+	// DNS-01 challenge records that are not published to any managed zone.
+	ServedDomain *string
+}
+
+type Controller struct {
+	queue           workqueue.RateLimitingInterface
+	client          kubernetes.Interface
+	dnsRecordClient kuadrantv1.Interface
+	stopCh          chan struct{}
+	indexer         cache.Indexer
+	secretLister    corev1lister.SecretLister
+	issuer          *acme.Issuer
+	solver          acme.Solver
+	renewBefore     time.Duration
+	servedDomain    string
+	isHandled       func(*networkingv1.Ingress) bool
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.AddRateLimited(key)
+}
+
+func (c *Controller) Start(numThreads int) {
+	defer c.queue.ShutDown()
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(c.startWorker, time.Second, c.stopCh)
+	}
+	klog.Infof("Starting workers")
+	<-c.stopCh
+	klog.Infof("Stopping workers")
+}
+
+func (c *Controller) startWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *Controller) processNextWorkItem() bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	err := c.process(key)
+	c.handleErr(err, key)
+	return true
+}
+
+func (c *Controller) handleErr(err error, key string) {
+	// Reconcile worked, nothing else to do for this workqueue item.
+	if err == nil {
+		c.queue.Forget(key)
+		return
+	}
+
+	// Re-enqueue up to 5 times.
+	num := c.queue.NumRequeues(key)
+	if num < 5 {
+		klog.Errorf("Error reconciling key %q, retrying... (#%d): %v", key, num, err)
+		c.queue.AddRateLimited(key)
+		return
+	}
+
+	// Give up and report error elsewhere.
+	c.queue.Forget(key)
+	runtime.HandleError(err)
+	klog.Infof("Dropping key %q after failed retries: %v", key, err)
+}
+
+func (c *Controller) process(key string) error {
+	obj, exists, err := c.indexer.GetByKey(key)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		// The certificates are deleted by the Ingress controller, before the Ingress is released
+		klog.Infof("Object with key %q was deleted", key)
+		return nil
+	}
+
+	ingress := obj.(*networkingv1.Ingress)
+
+	renewal, err := c.reconcile(context.TODO(), ingress)
+	if err != nil {
+		return err
+	}
+
+	if !renewal.IsZero() {
+		// Renew the certificate before it expires
+		c.queue.AddAfter(key, time.Until(renewal))
+	}
+
+	return nil
+}
+
+// ingressFromSecret enqueues the Ingress which certificate the Secret holds.
+func (c *Controller) ingressFromSecret(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok || secret.Labels[clusterLabel] != "" {
+		return
+	}
+	for _, owner := range secret.OwnerReferences {
+		if owner.Kind == "Ingress" && acme.CertificateSecretName(owner.Name) == secret.Name {
+			c.enqueue(&networkingv1.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   secret.Namespace,
+					Name:        owner.Name,
+					ClusterName: secret.ClusterName,
+				},
+			})
+		}
+	}
+}
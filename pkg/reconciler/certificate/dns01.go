@@ -0,0 +1,117 @@
+package certificate
+
+import (
+	"context"
+	"time"
+
+	"github.com/miekg/dns"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/kuadrant/kcp-ingress/pkg/acme"
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	kuadrantv1 "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned"
+)
+
+const (
+	challengeTTL          = 60
+	challengePollInterval = 2 * time.Second
+)
+
+// dns01Solver presents the responses to the ACME DNS-01 challenges of the
+// Ingress generated host with a TXT DNSRecord, which is published to the
+// managed zone by the DNS controller, or answered by the embedded DNS server
+// of the served domain.
+type dns01Solver struct {
+	client       kuadrantv1.Interface
+	ingress      *networkingv1.Ingress
+	servedDomain string
+}
+
+var _ acme.Solver = &dns01Solver{}
+
+func (s *dns01Solver) Type() string {
+	return acme.DNS01
+}
+
+// Present creates the challenge DNSRecord, and waits for it to be published.
+func (s *dns01Solver) Present(ctx context.Context, host, _, response string) error {
+	desired := &v1.DNSRecord{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        s.recordName(),
+			Namespace:   s.ingress.Namespace,
+			ClusterName: s.ingress.ClusterName,
+			// The record is not controlled by the Ingress, which DNSRecords are all reconciled
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: networkingv1.SchemeGroupVersion.String(),
+				Kind:       "Ingress",
+				Name:       s.ingress.Name,
+				UID:        s.ingress.UID,
+			}},
+		},
+		Spec: v1.DNSRecordSpec{
+			DNSName:    acme.DNS01ChallengeName(host),
+			RecordType: v1.TXTRecordType,
+			RecordTTL:  challengeTTL,
+			Targets:    []string{response},
+		},
+	}
+
+	records := s.client.KuadrantV1().DNSRecords(s.ingress.Namespace)
+	if _, err := records.Create(ctx, desired, metav1.CreateOptions{}); errors.IsAlreadyExists(err) {
+		existing, err := records.Get(ctx, desired.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		existing.Spec = desired.Spec
+		if _, err := records.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	return wait.PollImmediateUntil(challengePollInterval, func() (bool, error) {
+		record, err := records.Get(ctx, desired.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		return isPublished(record, s.servedDomain), nil
+	}, ctx.Done())
+}
+
+// CleanUp deletes the challenge DNSRecord, which is withdrawn from the zone
+// before being released.
+func (s *dns01Solver) CleanUp(ctx context.Context, _, _ string) error {
+	err := s.client.KuadrantV1().DNSRecords(s.ingress.Namespace).Delete(ctx, s.recordName(), metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *dns01Solver) recordName() string {
+	return s.ingress.Name + "-acme-challenge"
+}
+
+// isPublished returns whether the current generation of the record has been
+// published to a zone. The records of the served domain that are not published
+// to any zone are answered by the embedded DNS server once reconciled.
+func isPublished(record *v1.DNSRecord, servedDomain string) bool {
+	if record.Status.ObservedGeneration != record.Generation {
+		return false
+	}
+	if len(record.Status.Zones) == 0 {
+		return servedDomain != "" && dns.IsSubDomain(dns.CanonicalName(servedDomain), dns.CanonicalName(record.Spec.DNSName))
+	}
+	for _, zone := range record.Status.Zones {
+		for _, condition := range zone.Conditions {
+			if condition.Type == v1.DNSRecordPublishedConditionType && condition.Status != string(metav1.ConditionTrue) {
+				return false
+			}
+		}
+	}
+	return true
+}
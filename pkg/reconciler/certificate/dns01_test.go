@@ -0,0 +1,187 @@
+package certificate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned/fake"
+)
+
+const testHost = "app.kcp-apps.example.com"
+
+// publish has the DNSRecords created or updated with the client reported as
+// published, as the DNS controller would.
+func publish(client *fake.Clientset) {
+	reactor := func(action clienttesting.Action) (bool, runtime.Object, error) {
+		record := action.(interface{ GetObject() runtime.Object }).GetObject().(*v1.DNSRecord)
+		record.Status.ObservedGeneration = record.Generation
+		record.Status.Zones = []v1.DNSZoneStatus{{
+			DNSZone: v1.DNSZone{ID: "kcp-apps.example.com"},
+			Conditions: []v1.DNSZoneCondition{{
+				Type:   v1.DNSRecordPublishedConditionType,
+				Status: string(metav1.ConditionTrue),
+			}},
+		}}
+		// Let the tracker store the published record
+		return false, nil, nil
+	}
+	client.PrependReactor("create", "dnsrecords", reactor)
+	client.PrependReactor("update", "dnsrecords", reactor)
+}
+
+func TestDNS01Solver(t *testing.T) {
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", UID: "uid"},
+	}
+
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		publish bool
+		wantErr bool
+	}{
+		{
+			name:    "new challenge record",
+			publish: true,
+		},
+		{
+			name: "stale challenge record",
+			objects: []runtime.Object{&v1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app-acme-challenge"},
+				Spec: v1.DNSRecordSpec{
+					DNSName:    "_acme-challenge." + testHost,
+					RecordType: v1.TXTRecordType,
+					Targets:    []string{"stale"},
+				},
+			}},
+			publish: true,
+		},
+		{
+			name:    "challenge record not published",
+			publish: false,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(tt.objects...)
+			if tt.publish {
+				publish(client)
+			}
+			solver := &dns01Solver{client: client, ingress: ingress}
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+
+			err := solver.Present(ctx, testHost, "token", "response")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+
+			record, err := client.KuadrantV1().DNSRecords("default").Get(context.Background(), "app-acme-challenge", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if record.Spec.DNSName != "_acme-challenge."+testHost || record.Spec.RecordType != v1.TXTRecordType ||
+				len(record.Spec.Targets) != 1 || record.Spec.Targets[0] != "response" {
+				t.Errorf("challenge record = %v, want a TXT record of _acme-challenge.%s with the response", record.Spec, testHost)
+			}
+
+			if err := solver.CleanUp(context.Background(), testHost, "token"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if records, _ := client.KuadrantV1().DNSRecords("default").List(context.Background(), metav1.ListOptions{}); len(records.Items) != 0 {
+				t.Errorf("challenge records = %v after clean up, want none", records.Items)
+			}
+			// Cleaning up again is a no-op
+			if err := solver.CleanUp(context.Background(), testHost, "token"); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsPublished(t *testing.T) {
+	published := v1.DNSZoneCondition{Type: v1.DNSRecordPublishedConditionType, Status: string(metav1.ConditionTrue)}
+	failed := v1.DNSZoneCondition{Type: v1.DNSRecordPublishedConditionType, Status: string(metav1.ConditionFalse)}
+
+	tests := []struct {
+		name         string
+		record       *v1.DNSRecord
+		servedDomain string
+		want         bool
+	}{
+		{
+			name: "published",
+			record: &v1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status:     v1.DNSRecordStatus{ObservedGeneration: 2, Zones: []v1.DNSZoneStatus{{Conditions: []v1.DNSZoneCondition{published}}}},
+			},
+			want: true,
+		},
+		{
+			name: "previous generation published",
+			record: &v1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status:     v1.DNSRecordStatus{ObservedGeneration: 1, Zones: []v1.DNSZoneStatus{{Conditions: []v1.DNSZoneCondition{published}}}},
+			},
+		},
+		{
+			name: "no zone",
+			record: &v1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status:     v1.DNSRecordStatus{ObservedGeneration: 2},
+			},
+		},
+		{
+			name: "no zone, served by the embedded DNS server",
+			record: &v1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       v1.DNSRecordSpec{DNSName: "_acme-challenge." + testHost},
+				Status:     v1.DNSRecordStatus{ObservedGeneration: 2},
+			},
+			servedDomain: "kcp-apps.example.com",
+			want:         true,
+		},
+		{
+			name: "no zone, served by the embedded DNS server, previous generation",
+			record: &v1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       v1.DNSRecordSpec{DNSName: "_acme-challenge." + testHost},
+				Status:     v1.DNSRecordStatus{ObservedGeneration: 1},
+			},
+			servedDomain: "kcp-apps.example.com",
+		},
+		{
+			name: "no zone, outside of the domain of the embedded DNS server",
+			record: &v1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Spec:       v1.DNSRecordSpec{DNSName: "_acme-challenge.app.example.org"},
+				Status:     v1.DNSRecordStatus{ObservedGeneration: 2},
+			},
+			servedDomain: "kcp-apps.example.com",
+		},
+		{
+			name: "failed to be published",
+			record: &v1.DNSRecord{
+				ObjectMeta: metav1.ObjectMeta{Generation: 2},
+				Status:     v1.DNSRecordStatus{ObservedGeneration: 2, Zones: []v1.DNSZoneStatus{{Conditions: []v1.DNSZoneCondition{failed}}}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPublished(tt.record, tt.servedDomain); got != tt.want {
+				t.Errorf("isPublished() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1lister "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
@@ -17,9 +21,20 @@ import (
 	kuadrantv1 "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned"
 	"github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/informers/externalversions"
 	kuadrantv1lister "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/listers/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns"
+	"github.com/kuadrant/kcp-ingress/pkg/dns/health"
+	dnsserver "github.com/kuadrant/kcp-ingress/pkg/dns/server"
 )
 
-const resyncPeriod = 10 * time.Hour
+const (
+	resyncPeriod = 10 * time.Hour
+
+	// conflictRetryBaseDelay and conflictRetryMaxDelay bound the backoff of the
+	// records that conflict with records owned by someone else, which may be
+	// released eventually.
+	conflictRetryBaseDelay = 5 * time.Second
+	conflictRetryMaxDelay  = 10 * time.Minute
+)
 
 // NewController returns a new Controller which reconciles DNSRecord.
 func NewController(config *ControllerConfig) *Controller {
@@ -28,9 +43,13 @@ func NewController(config *ControllerConfig) *Controller {
 	stopCh := make(chan struct{}) // TODO: hook this up to SIGTERM/SIGINT
 
 	c := &Controller{
-		queue:  queue,
-		client: client,
-		stopCh: stopCh,
+		queue:       queue,
+		client:      client,
+		stopCh:      stopCh,
+		newProvider: config.NewProvider,
+		ownerID:     *config.OwnerID,
+
+		conflictBackoff: workqueue.NewItemExponentialFailureRateLimiter(conflictRetryBaseDelay, conflictRetryMaxDelay),
 	}
 
 	sif := externalversions.NewSharedInformerFactoryWithOptions(c.client, resyncPeriod)
@@ -42,33 +61,102 @@ func NewController(config *ControllerConfig) *Controller {
 		DeleteFunc: func(obj interface{}) { c.enqueue(obj) },
 	})
 
+	// Watch for events related to ManagedZones, that may change the zone
+	// the DNSRecords are published to
+	dnsRecords := sif.Kuadrant().V1().DNSRecords().Informer().GetStore()
+	sif.Kuadrant().V1().ManagedZones().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(_ interface{}) { c.enqueueAll(dnsRecords) },
+		UpdateFunc: func(_, _ interface{}) { c.enqueueAll(dnsRecords) },
+		DeleteFunc: func(obj interface{}) {
+			if key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj); err == nil {
+				c.providers.forget(key)
+			}
+			c.enqueueAll(dnsRecords)
+		},
+	})
+
+	// Watch for events related to Secrets, that may be the credentials of the
+	// ManagedZones, so that the DNSRecords are published with the new ones
+	ksif := informers.NewSharedInformerFactoryWithOptions(kubernetes.NewForConfigOrDie(config.Cfg), resyncPeriod)
+	zones := sif.Kuadrant().V1().ManagedZones().Informer().GetStore()
+	ksif.Core().V1().Secrets().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.recordsFromSecret(zones, dnsRecords, obj) },
+		UpdateFunc: func(_, obj interface{}) { c.recordsFromSecret(zones, dnsRecords, obj) },
+		DeleteFunc: func(obj interface{}) { c.recordsFromSecret(zones, dnsRecords, obj) },
+	})
+
 	sif.Start(stopCh)
 	for inf, sync := range sif.WaitForCacheSync(stopCh) {
 		if !sync {
 			klog.Fatalf("Failed to sync %s", inf)
 		}
 	}
+	ksif.Start(stopCh)
+	for inf, sync := range ksif.WaitForCacheSync(stopCh) {
+		if !sync {
+			klog.Fatalf("Failed to sync %s", inf)
+		}
+	}
 
 	c.indexer = sif.Kuadrant().V1().DNSRecords().Informer().GetIndexer()
 	c.lister = sif.Kuadrant().V1().DNSRecords().Lister()
+	c.zoneLister = sif.Kuadrant().V1().ManagedZones().Lister()
+	c.secretLister = ksif.Core().V1().Secrets().Lister()
+
+	if config.HealthCheckInterval != nil {
+		c.healthChecker = health.NewChecker(*config.HealthCheckInterval, c.healthChanged)
+
+		go c.healthChecker.Start(stopCh)
+	}
+
+	if config.DriftDetectionInterval != nil && *config.DriftDetectionInterval > 0 {
+		go wait.Until(c.detectDrift, *config.DriftDetectionInterval, stopCh)
+	}
+
+	if config.DNSServerPort != nil {
+		c.dnsServer = dnsserver.NewServer(*config.DNSServerPort, *config.Domain, c.lister, c.zoneLister)
+
+		go func() {
+			if err := c.dnsServer.ListenAndServe(); err != nil {
+				klog.Fatalf("Failed to serve DNS on port %d: %v", *config.DNSServerPort, err)
+			}
+		}()
+	}
 
 	return c
 }
 
 type ControllerConfig struct {
-	Cfg *rest.Config
+	Cfg                    *rest.Config
+	NewProvider            dns.ProviderFactory
+	OwnerID                *string
+	Domain                 *string
+	DNSServerPort          *uint
+	HealthCheckInterval    *time.Duration
+	DriftDetectionInterval *time.Duration
 }
 
 type Controller struct {
-	queue   workqueue.RateLimitingInterface
-	client  kuadrantv1.Interface
-	stopCh  chan struct{}
-	indexer cache.Indexer
-	lister  kuadrantv1lister.DNSRecordLister
+	queue         workqueue.RateLimitingInterface
+	client        kuadrantv1.Interface
+	stopCh        chan struct{}
+	indexer       cache.Indexer
+	lister        kuadrantv1lister.DNSRecordLister
+	zoneLister    kuadrantv1lister.ManagedZoneLister
+	secretLister  corev1lister.SecretLister
+	newProvider   dns.ProviderFactory
+	ownerID       string
+	dnsServer     *dnsserver.Server
+	healthChecker *health.Checker
+	drifts        drifts
+	providers     providers
+
+	// conflictBackoff delays the retries of the records in conflict.
+	conflictBackoff workqueue.RateLimiter
 }
 
 func (c *Controller) enqueue(obj interface{}) {
-	key, err := cache.MetaNamespaceKeyFunc(obj)
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -76,6 +164,38 @@ func (c *Controller) enqueue(obj interface{}) {
 	c.queue.AddRateLimited(key)
 }
 
+// recordsFromSecret enqueues all the DNSRecords in store, if the Secret holds
+// the credentials of one of the ManagedZones.
+func (c *Controller) recordsFromSecret(zones, store cache.Store, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+
+	for _, o := range zones.List() {
+		zone, ok := o.(*v1.ManagedZone)
+		if !ok {
+			continue
+		}
+		ref := zone.Spec.CredentialsSecretRef
+		if ref != nil && zone.ClusterName == secret.ClusterName && ref.Namespace == secret.Namespace && ref.Name == secret.Name {
+			klog.Infof("credentials Secret %q of ManagedZone %q triggered DNSRecords reconciliation", secret.Name, zone.Name)
+			c.enqueueAll(store)
+			return
+		}
+	}
+}
+
+// enqueueAll enqueues all the DNSRecords in store.
+func (c *Controller) enqueueAll(store cache.Store) {
+	for _, obj := range store.List() {
+		c.enqueue(obj)
+	}
+}
+
 func (c *Controller) Start(numThreads int) {
 	defer c.queue.ShutDown()
 	for i := 0; i < numThreads; i++ {
@@ -137,22 +257,42 @@ func (c *Controller) process(key string) error {
 
 	if !exists {
 		klog.Infof("Object with key %q was deleted", key)
+		// The record has already been withdrawn from the zones before the finalizer
+		// got released, or it's left to the drift detection to delete it otherwise.
+		if c.healthChecker != nil {
+			c.healthChecker.Remove(key)
+		}
+		c.drifts.forget(key)
+		c.conflictBackoff.Forget(key)
+		return nil
 	}
 
-	current := obj.(*v1.DNSRecord)
-
-	previous := current.DeepCopy()
+	previous := obj.(*v1.DNSRecord)
+	current := previous.DeepCopy()
 
 	ctx := context.TODO()
-	if err := c.reconcile(ctx, current); err != nil {
-		return err
+	reconcileErr := c.reconcile(ctx, current)
+
+	// Once the finalizer is released, the object is gone, and there is no status to update.
+	if current.DeletionTimestamp != nil && !hasFinalizer(current) {
+		return reconcileErr
+	}
+
+	// Retry the records in conflict, as the record may be released by its owner,
+	// backing off so that the zone isn't looked up continuously.
+	if hasConflict(current) {
+		c.queue.AddAfter(key, c.conflictBackoff.When(key))
+	} else {
+		c.conflictBackoff.Forget(key)
 	}
 
-	// If the object being reconciled changed as a result, update it.
-	if !equality.Semantic.DeepEqual(previous, current) {
-		_, uerr := c.client.KuadrantV1().DNSRecords(current.Namespace).Update(ctx, current, metav1.UpdateOptions{})
-		return uerr
+	// If the status of the object being reconciled changed as a result, update it,
+	// so that the per-zone results get recorded, even if the reconciliation failed.
+	if !equality.Semantic.DeepEqual(previous.Status, current.Status) {
+		if _, err := c.client.KuadrantV1().DNSRecords(current.Namespace).UpdateStatus(ctx, current, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
 	}
 
-	return err
+	return reconcileErr
 }
@@ -0,0 +1,204 @@
+package dns
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned/fake"
+	kuadrantv1lister "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/listers/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns"
+	"github.com/kuadrant/kcp-ingress/pkg/dns/inmemory"
+)
+
+// newTestController returns a Controller publishing the records to an
+// in-memory provider, for the given managed zones, which informer caches are
+// fed by the test.
+func newTestController(t *testing.T, provider dns.Provider, zones ...*v1.ManagedZone) (*Controller, *fake.Clientset) {
+	t.Helper()
+	zoneIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, zone := range zones {
+		if err := zoneIndexer.Add(zone); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	client := fake.NewSimpleClientset()
+
+	c := &Controller{
+		queue:       workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		client:      client,
+		indexer:     indexer,
+		lister:      kuadrantv1lister.NewDNSRecordLister(indexer),
+		zoneLister:  kuadrantv1lister.NewManagedZoneLister(zoneIndexer),
+		newProvider: func(string, map[string][]byte) (dns.Provider, error) { return provider, nil },
+		ownerID:     "kcp-ingress",
+
+		conflictBackoff: workqueue.NewItemExponentialFailureRateLimiter(conflictRetryBaseDelay, conflictRetryMaxDelay),
+	}
+	t.Cleanup(c.queue.ShutDown)
+	return c, client
+}
+
+// syncRecord feeds the informer cache with the record, as stored by the client, and
+// processes it.
+func syncRecord(t *testing.T, c *Controller, client *fake.Clientset, record *v1.DNSRecord) *v1.DNSRecord {
+	t.Helper()
+	current, err := client.KuadrantV1().DNSRecords(record.Namespace).Get(context.Background(), record.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.indexer.Update(current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err := cache.MetaNamespaceKeyFunc(current)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.process(key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	processed, err := client.KuadrantV1().DNSRecords(record.Namespace).Get(context.Background(), record.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return processed
+}
+
+func TestReconcile(t *testing.T) {
+	const dnsName = "app.kcp-apps.example.com"
+	zone := v1.DNSZone{ID: "kcp-apps.example.com"}
+	newRecord := func(targets ...string) *v1.DNSRecord {
+		return &v1.DNSRecord{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "app", Generation: 1},
+			Spec:       v1.DNSRecordSpec{DNSName: dnsName, RecordType: v1.ARecordType, Targets: targets, RecordTTL: 60},
+		}
+	}
+
+	tests := []struct {
+		name string
+		// update changes the record, once it's been published with the 10.0.0.1 target
+		update      func(record *v1.DNSRecord)
+		zones       []*v1.ManagedZone
+		wantTargets []string
+		// wantRecords is the number of records in the zone, including the ownership ones
+		wantRecords   int
+		wantZones     []string
+		wantFinalizer bool
+	}{
+		{
+			name:          "published record",
+			update:        func(record *v1.DNSRecord) {},
+			zones:         []*v1.ManagedZone{newManagedZone("apps", "kcp-apps.example.com")},
+			wantTargets:   []string{"10.0.0.1"},
+			wantRecords:   2,
+			wantZones:     []string{"kcp-apps.example.com"},
+			wantFinalizer: true,
+		},
+		{
+			name: "updated record",
+			update: func(record *v1.DNSRecord) {
+				record.Spec.Targets = []string{"10.0.0.2", "10.0.0.3"}
+				record.Generation++
+			},
+			zones:         []*v1.ManagedZone{newManagedZone("apps", "kcp-apps.example.com")},
+			wantTargets:   []string{"10.0.0.2", "10.0.0.3"},
+			wantRecords:   2,
+			wantZones:     []string{"kcp-apps.example.com"},
+			wantFinalizer: true,
+		},
+		{
+			name: "withdrawn record",
+			update: func(record *v1.DNSRecord) {
+				now := metav1.Now()
+				record.DeletionTimestamp = &now
+			},
+			zones: []*v1.ManagedZone{newManagedZone("apps", "kcp-apps.example.com")},
+		},
+		{
+			name:          "record outside of the managed zones",
+			update:        func(record *v1.DNSRecord) {},
+			zones:         []*v1.ManagedZone{newManagedZone("other", "example.org")},
+			wantFinalizer: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := inmemory.NewProvider()
+			c, client := newTestController(t, provider, tt.zones...)
+			record := newRecord("10.0.0.1")
+			if _, err := client.KuadrantV1().DNSRecords("default").Create(context.Background(), record, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := c.indexer.Add(record); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			record = syncRecord(t, c, client, record)
+
+			tt.update(record)
+			if _, err := client.KuadrantV1().DNSRecords("default").Update(context.Background(), record, metav1.UpdateOptions{}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			record = syncRecord(t, c, client, record)
+
+			published, err := provider.Get(dnsName, v1.ARecordType, zone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var targets []string
+			if published != nil {
+				targets = published.Targets
+			}
+			if !equalStrings(targets, tt.wantTargets) {
+				t.Errorf("published targets = %v, want %v", targets, tt.wantTargets)
+			}
+			records, err := provider.List(zone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(records) != tt.wantRecords {
+				t.Errorf("records = %v, want %d records", records, tt.wantRecords)
+			}
+
+			if got := hasFinalizer(record); got != tt.wantFinalizer {
+				t.Errorf("finalizer = %v, want %v", got, tt.wantFinalizer)
+			}
+			if !tt.wantFinalizer {
+				// The record is released, and its status isn't updated anymore
+				return
+			}
+			var zones []string
+			for _, z := range record.Status.Zones {
+				zones = append(zones, z.DNSZone.ID)
+				for _, condition := range z.Conditions {
+					if condition.Type == v1.DNSRecordPublishedConditionType && condition.Status != string(metav1.ConditionTrue) {
+						t.Errorf("record not published to zone %q: %s", z.DNSZone.ID, condition.Message)
+					}
+				}
+			}
+			if !equalStrings(zones, tt.wantZones) {
+				t.Errorf("zones = %v, want %v", zones, tt.wantZones)
+			}
+			if record.Status.ObservedGeneration != record.Generation {
+				t.Errorf("observed generation = %d, want %d", record.Status.ObservedGeneration, record.Generation)
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
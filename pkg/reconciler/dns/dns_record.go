@@ -3,14 +3,267 @@ package dns
 import (
 	"context"
 
-	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/klog"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns"
+	"github.com/kuadrant/kcp-ingress/pkg/dns/registry"
+)
+
+const (
+	providerSuccessReason   = "ProviderSuccess"
+	providerErrorReason     = "ProviderError"
+	ownershipConflictReason = "OwnershipConflict"
 )
 
+// finalizer is set on the DNSRecords, so that they are withdrawn from the zones
+// before being deleted.
+const finalizer = "kuadrant.dev/dns-record"
+
 func (c *Controller) reconcile(ctx context.Context, dnsRecord *v1.DNSRecord) error {
 	klog.Infof("reconciling DNSRecord %q", dnsRecord.Name)
 
-	// TODO: Reconcile record to DNS server
+	managedZones, err := c.zoneLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	if err := c.checkHealth(dnsRecord); err != nil {
+		return err
+	}
+
+	if dnsRecord.DeletionTimestamp != nil && !dnsRecord.DeletionTimestamp.IsZero() {
+		if !hasFinalizer(dnsRecord) {
+			return nil
+		}
+		if err := c.deleteRecord(ctx, dnsRecord, managedZones); err != nil {
+			return err
+		}
+		return c.removeFinalizer(ctx, dnsRecord)
+	}
+
+	if !hasFinalizer(dnsRecord) {
+		if err := c.addFinalizer(ctx, dnsRecord); err != nil {
+			return err
+		}
+	}
+
+	published, err := c.publishedRecord(dnsRecord)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	var zones []v1.DNSZoneStatus
+
+	target := zoneFor(managedZones, dnsRecord.Spec.DNSName)
+	if target == nil {
+		klog.Infof("no managed zone for DNSRecord %q with DNS name %q", dnsRecord.Name, dnsRecord.Spec.DNSName)
+	} else {
+		zone := v1.DNSZone{ID: target.ZoneID()}
+		invalid := dns.Validate(dnsRecord.Spec)
+		err := invalid
+		if err == nil {
+			var provider *registry.TXTRegistry
+			provider, err = c.providerFor(target)
+			if err == nil {
+				err = provider.Ensure(published, zone)
+			}
+		}
+		if err != nil {
+			klog.Errorf("failed to publish DNSRecord %q to zone %q: %v", dnsRecord.Name, zone.ID, err)
+			// Invalid records and conflicts are reported in the status, retrying right away
+			// won't solve them. The conflicts are retried with a backoff instead.
+			if invalid == nil && !registry.IsConflict(err) {
+				errs = append(errs, err)
+			}
+		}
+		zones = append(zones, zoneStatus(dnsRecord.Status, zone, err))
+	}
+
+	// Withdraw the record from the zones it was previously published to, e.g., when
+	// a more specific zone has been added. The zones that are no longer managed are
+	// forgotten, and the ones the record fails to be withdrawn from are kept, so
+	// that it gets retried.
+	for _, status := range dnsRecord.Status.Zones {
+		if target != nil && status.DNSZone.ID == target.ZoneID() {
+			continue
+		}
+		managedZone := zoneByID(managedZones, status.DNSZone.ID)
+		if managedZone == nil {
+			continue
+		}
+		if err := c.deleteFromZone(ctx, dnsRecord, managedZone); err != nil {
+			errs = append(errs, err)
+			zones = append(zones, status)
+		}
+	}
+
+	dnsRecord.Status.Zones = zones
+	dnsRecord.Status.ObservedGeneration = dnsRecord.Generation
+	dnsRecord.Status.DriftCount += c.drifts.take(dnsRecord)
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// deleteRecord removes the record from the zone it is published to, as well as
+// from the zones it has been published to previously.
+func (c *Controller) deleteRecord(ctx context.Context, dnsRecord *v1.DNSRecord, managedZones []*v1.ManagedZone) error {
+	targets := map[string]*v1.ManagedZone{}
+	if zone := zoneFor(managedZones, dnsRecord.Spec.DNSName); zone != nil {
+		targets[zone.ZoneID()] = zone
+	}
+	for _, status := range dnsRecord.Status.Zones {
+		if zone := zoneByID(managedZones, status.DNSZone.ID); zone != nil {
+			targets[zone.ZoneID()] = zone
+		}
+	}
 
+	var errs []error
+	for _, zone := range targets {
+		if err := c.deleteFromZone(ctx, dnsRecord, zone); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+func (c *Controller) deleteFromZone(ctx context.Context, dnsRecord *v1.DNSRecord, managedZone *v1.ManagedZone) error {
+	zone := v1.DNSZone{ID: managedZone.ZoneID()}
+	provider, err := c.providerFor(managedZone)
+	if err == nil {
+		err = provider.Delete(dnsRecord, zone)
+	}
+	if registry.IsConflict(err) {
+		// The record is owned by someone else, there is nothing to withdraw
+		klog.Infof("not deleting DNSRecord %q from zone %q: %v", dnsRecord.Name, zone.ID, err)
+		return nil
+	}
+	if err != nil {
+		klog.Errorf("failed to delete DNSRecord %q from zone %q: %v", dnsRecord.Name, zone.ID, err)
+	}
+	return err
+}
+
+func hasFinalizer(dnsRecord *v1.DNSRecord) bool {
+	for _, f := range dnsRecord.Finalizers {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// addFinalizer sets the finalizer on the record, before it gets published to
+// any zone. The record status is preserved, to be updated afterwards.
+func (c *Controller) addFinalizer(ctx context.Context, dnsRecord *v1.DNSRecord) error {
+	dnsRecord.Finalizers = append(dnsRecord.Finalizers, finalizer)
+	updated, err := c.client.KuadrantV1().DNSRecords(dnsRecord.Namespace).Update(ctx, dnsRecord, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	dnsRecord.ResourceVersion = updated.ResourceVersion
 	return nil
 }
+
+// removeFinalizer releases the record, once it's been withdrawn from the zones.
+func (c *Controller) removeFinalizer(ctx context.Context, dnsRecord *v1.DNSRecord) error {
+	finalizers := make([]string, 0, len(dnsRecord.Finalizers))
+	for _, f := range dnsRecord.Finalizers {
+		if f != finalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	dnsRecord.Finalizers = finalizers
+	_, err := c.client.KuadrantV1().DNSRecords(dnsRecord.Namespace).Update(ctx, dnsRecord, metav1.UpdateOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// hasConflict returns whether the record conflicts, in any of its zones, with a
+// record owned by someone else.
+func hasConflict(dnsRecord *v1.DNSRecord) bool {
+	for _, zone := range dnsRecord.Status.Zones {
+		for _, condition := range zone.Conditions {
+			if condition.Type == v1.DNSRecordConflictConditionType && condition.Status == string(metav1.ConditionTrue) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// zoneStatus returns the status of the record in the zone, given the result of
+// publishing the record to that zone.
+func zoneStatus(status v1.DNSRecordStatus, zone v1.DNSZone, err error) v1.DNSZoneStatus {
+	published := v1.DNSZoneCondition{
+		Type:    v1.DNSRecordPublishedConditionType,
+		Status:  string(metav1.ConditionTrue),
+		Reason:  providerSuccessReason,
+		Message: "The record was published to the zone",
+	}
+	failed := v1.DNSZoneCondition{
+		Type:    v1.DNSRecordFailedConditionType,
+		Status:  string(metav1.ConditionFalse),
+		Reason:  providerSuccessReason,
+		Message: "The record was published to the zone",
+	}
+	conflict := v1.DNSZoneCondition{
+		Type:    v1.DNSRecordConflictConditionType,
+		Status:  string(metav1.ConditionFalse),
+		Reason:  providerSuccessReason,
+		Message: "The record is owned by this controller",
+	}
+	if err != nil {
+		reason := providerErrorReason
+		if registry.IsConflict(err) {
+			reason = ownershipConflictReason
+			conflict.Status = string(metav1.ConditionTrue)
+			conflict.Reason = reason
+			conflict.Message = err.Error()
+		}
+		published.Status = string(metav1.ConditionFalse)
+		published.Reason = reason
+		published.Message = err.Error()
+		failed.Status = string(metav1.ConditionTrue)
+		failed.Reason = reason
+		failed.Message = err.Error()
+	}
+
+	var current []v1.DNSZoneCondition
+	for _, z := range status.Zones {
+		if equality.Semantic.DeepEqual(z.DNSZone, zone) {
+			current = z.Conditions
+			break
+		}
+	}
+
+	return v1.DNSZoneStatus{
+		DNSZone: zone,
+		Conditions: []v1.DNSZoneCondition{
+			setLastTransitionTime(current, published),
+			setLastTransitionTime(current, failed),
+			setLastTransitionTime(current, conflict),
+		},
+	}
+}
+
+// setLastTransitionTime sets the condition transition time, preserving the
+// current one if the condition status has not changed.
+func setLastTransitionTime(conditions []v1.DNSZoneCondition, condition v1.DNSZoneCondition) v1.DNSZoneCondition {
+	condition.LastTransitionTime = metav1.Now()
+	for _, c := range conditions {
+		if c.Type == condition.Type && c.Status == condition.Status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+	}
+	return condition
+}
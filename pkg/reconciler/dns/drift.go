@@ -0,0 +1,194 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns"
+	"github.com/kuadrant/kcp-ingress/pkg/dns/registry"
+)
+
+// drifts counts the drifts detected for each record, until they are reported
+// in the record status.
+type drifts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (d *drifts) add(record *v1.DNSRecord) {
+	key, err := cache.MetaNamespaceKeyFunc(record)
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.counts == nil {
+		d.counts = map[string]int64{}
+	}
+	d.counts[key]++
+}
+
+// forget discards the drifts detected for the record with the given key.
+func (d *drifts) forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.counts, key)
+}
+
+// take returns the number of drifts detected for the record since the last
+// call, and resets it.
+func (d *drifts) take(record *v1.DNSRecord) int64 {
+	key, err := cache.MetaNamespaceKeyFunc(record)
+	if err != nil {
+		return 0
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	count := d.counts[key]
+	delete(d.counts, key)
+	return count
+}
+
+// detectDrift compares the records published to the managed zones with the
+// DNSRecords, re-enqueues the DNSRecords which published records have been
+// changed out-of-band, or are missing, so that they are fixed, and removes the
+// orphan records the controller owns.
+func (c *Controller) detectDrift() {
+	ctx := context.TODO()
+
+	managedZones, err := c.zoneLister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list managed zones: %v", err)
+		return
+	}
+	records, err := c.lister.List(labels.Everything())
+	if err != nil {
+		klog.Errorf("failed to list DNSRecords: %v", err)
+		return
+	}
+
+	for _, managedZone := range managedZones {
+		if err := c.detectZoneDrift(ctx, managedZone, managedZones, records); err != nil {
+			klog.Errorf("failed to detect drift in zone %q: %v", managedZone.ZoneID(), err)
+		}
+	}
+}
+
+func (c *Controller) detectZoneDrift(ctx context.Context, managedZone *v1.ManagedZone, managedZones []*v1.ManagedZone, records []*v1.DNSRecord) error {
+	zone := v1.DNSZone{ID: managedZone.ZoneID()}
+	provider, err := c.providerFor(managedZone)
+	if err != nil {
+		return err
+	}
+
+	owned, err := provider.ListOwned(zone)
+	if err != nil {
+		return err
+	}
+	published := make(map[string]registry.Owned, len(owned))
+	for _, o := range owned {
+		published[o.Resource+"/"+string(o.Spec.RecordType)] = o
+	}
+
+	expected := map[string]struct{}{}
+	for _, record := range records {
+		if record.DeletionTimestamp != nil || zoneFor(managedZones, record.Spec.DNSName) != managedZone {
+			continue
+		}
+		key := registry.Resource(record) + "/" + string(record.Spec.RecordType)
+		expected[key] = struct{}{}
+
+		// The records that failed to be published are retried anyway
+		if !isPublished(record, zone) {
+			continue
+		}
+
+		desired, err := c.publishedRecord(record)
+		if err != nil {
+			return err
+		}
+		current, ok := published[key]
+		kind := ""
+		switch {
+		case !ok && len(desired.Spec.Targets) > 0:
+			kind = driftMissing
+		case ok && !sameRecord(current.Spec, desired.Spec):
+			kind = driftChanged
+		default:
+			continue
+		}
+
+		klog.Infof("DNSRecord %q is %s in zone %q", record.Name, kind, zone.ID)
+		driftsTotal.WithLabelValues(zone.ID, kind).Inc()
+		c.drifts.add(record)
+		c.enqueue(record)
+	}
+
+	for key, o := range published {
+		if _, ok := expected[key]; ok {
+			continue
+		}
+
+		klog.Infof("deleting orphan %s record %q from zone %q", o.Spec.RecordType, o.Spec.DNSName, zone.ID)
+		driftsTotal.WithLabelValues(zone.ID, driftOrphan).Inc()
+		if err := provider.DeleteOwned(o, zone); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isPublished returns whether the record status reports it is published to
+// the zone.
+func isPublished(record *v1.DNSRecord, zone v1.DNSZone) bool {
+	for _, status := range record.Status.Zones {
+		if status.DNSZone.ID != zone.ID {
+			continue
+		}
+		for _, condition := range status.Conditions {
+			if condition.Type == v1.DNSRecordPublishedConditionType {
+				return condition.Status == string(metav1.ConditionTrue)
+			}
+		}
+	}
+	return false
+}
+
+// sameRecord returns whether the published record matches the desired one.
+func sameRecord(published, desired v1.DNSRecordSpec) bool {
+	if dns.TTL(published) != dns.TTL(desired) || len(published.Targets) != len(desired.Targets) {
+		return false
+	}
+
+	p, d := normalizeTargets(published.Targets), normalizeTargets(desired.Targets)
+	for i := range p {
+		if p[i] != d[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeTargets(targets []string) []string {
+	normalized := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if ip := net.ParseIP(target); ip != nil {
+			normalized = append(normalized, ip.String())
+		} else {
+			normalized = append(normalized, normalize(target))
+		}
+	}
+	sort.Strings(normalized)
+	return normalized
+}
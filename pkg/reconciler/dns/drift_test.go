@@ -0,0 +1,220 @@
+package dns
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns/inmemory"
+	"github.com/kuadrant/kcp-ingress/pkg/dns/registry"
+)
+
+func TestDetectZoneDrift(t *testing.T) {
+	const dnsName = "app.kcp-apps.example.com"
+	zone := v1.DNSZone{ID: "kcp-apps.example.com"}
+	managedZone := newManagedZone("apps", "kcp-apps.example.com")
+	newRecord := func(name string, targets ...string) *v1.DNSRecord {
+		return &v1.DNSRecord{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       v1.DNSRecordSpec{DNSName: dnsName, RecordType: v1.ARecordType, Targets: targets, RecordTTL: 60},
+		}
+	}
+	// published returns the record, reported as published to the zone
+	published := func(record *v1.DNSRecord) *v1.DNSRecord {
+		record.Status.Zones = []v1.DNSZoneStatus{{
+			DNSZone: zone,
+			Conditions: []v1.DNSZoneCondition{{
+				Type:   v1.DNSRecordPublishedConditionType,
+				Status: string(metav1.ConditionTrue),
+			}},
+		}}
+		return record
+	}
+
+	tests := []struct {
+		name string
+		// zone are the records published to the zone
+		zone []*v1.DNSRecord
+		// owner is the owner of the records published to the zone
+		owner   string
+		records []*v1.DNSRecord
+		// wantDrifts is the number of drifts detected for the app record
+		wantDrifts  int64
+		wantTargets []string
+	}{
+		{
+			name:        "record in sync",
+			zone:        []*v1.DNSRecord{newRecord("app", "10.0.0.1", "10.0.0.2")},
+			records:     []*v1.DNSRecord{published(newRecord("app", "10.0.0.1", "10.0.0.2"))},
+			wantTargets: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name:        "record in sync, with the targets in another order and format",
+			zone:        []*v1.DNSRecord{newRecord("app", "::ffff:10.0.0.2", "10.0.0.1")},
+			records:     []*v1.DNSRecord{published(newRecord("app", "10.0.0.1", "10.0.0.2"))},
+			wantTargets: []string{"::ffff:10.0.0.2", "10.0.0.1"},
+		},
+		{
+			name:       "missing record",
+			records:    []*v1.DNSRecord{published(newRecord("app", "10.0.0.1"))},
+			wantDrifts: 1,
+		},
+		{
+			name: "missing record, with all its targets drained",
+			records: []*v1.DNSRecord{func() *v1.DNSRecord {
+				record := published(newRecord("app", "10.0.0.1"))
+				record.Spec.TargetAttributes = []v1.DNSTargetAttributes{{Target: "10.0.0.1", Weight: new(int64)}}
+				return record
+			}()},
+			// Nothing is published for the records which targets are all drained
+			wantDrifts: 0,
+		},
+		{
+			name:        "changed targets",
+			zone:        []*v1.DNSRecord{newRecord("app", "10.0.0.3")},
+			records:     []*v1.DNSRecord{published(newRecord("app", "10.0.0.1"))},
+			wantDrifts:  1,
+			wantTargets: []string{"10.0.0.3"},
+		},
+		{
+			name: "changed TTL",
+			zone: []*v1.DNSRecord{func() *v1.DNSRecord {
+				record := newRecord("app", "10.0.0.1")
+				record.Spec.RecordTTL = 300
+				return record
+			}()},
+			records:     []*v1.DNSRecord{published(newRecord("app", "10.0.0.1"))},
+			wantDrifts:  1,
+			wantTargets: []string{"10.0.0.1"},
+		},
+		{
+			name:    "record not reported as published",
+			records: []*v1.DNSRecord{newRecord("app", "10.0.0.1")},
+		},
+		{
+			name: "orphan record",
+			zone: []*v1.DNSRecord{newRecord("app", "10.0.0.1")},
+		},
+		{
+			name: "record being deleted",
+			zone: []*v1.DNSRecord{newRecord("app", "10.0.0.1")},
+			records: []*v1.DNSRecord{func() *v1.DNSRecord {
+				record := published(newRecord("app", "10.0.0.1"))
+				now := metav1.Now()
+				record.DeletionTimestamp = &now
+				return record
+			}()},
+		},
+		{
+			name:        "record owned by another controller",
+			zone:        []*v1.DNSRecord{newRecord("app", "10.0.0.1")},
+			owner:       "other",
+			wantTargets: []string{"10.0.0.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := inmemory.NewProvider()
+			c, _ := newTestController(t, provider, managedZone)
+			owner := c.ownerID
+			if tt.owner != "" {
+				owner = tt.owner
+			}
+			for _, record := range tt.zone {
+				if err := registry.NewTXTRegistry(provider, owner).Ensure(record, zone); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			for _, record := range tt.records {
+				if err := c.indexer.Add(record); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if err := c.detectZoneDrift(context.Background(), managedZone, []*v1.ManagedZone{managedZone}, tt.records); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := c.drifts.take(newRecord("app")); got != tt.wantDrifts {
+				t.Errorf("drifts = %d, want %d", got, tt.wantDrifts)
+			}
+			current, err := provider.Get(dnsName, v1.ARecordType, zone)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var targets []string
+			if current != nil {
+				targets = current.Targets
+			}
+			if !equalStrings(targets, tt.wantTargets) {
+				t.Errorf("published targets = %v, want %v", targets, tt.wantTargets)
+			}
+		})
+	}
+}
+
+func TestSameRecord(t *testing.T) {
+	tests := []struct {
+		name      string
+		published v1.DNSRecordSpec
+		desired   v1.DNSRecordSpec
+		want      bool
+	}{
+		{
+			name:      "same targets",
+			published: v1.DNSRecordSpec{Targets: []string{"10.0.0.1", "10.0.0.2"}, RecordTTL: 60},
+			desired:   v1.DNSRecordSpec{Targets: []string{"10.0.0.1", "10.0.0.2"}, RecordTTL: 60},
+			want:      true,
+		},
+		{
+			name:      "same targets, in another order",
+			published: v1.DNSRecordSpec{Targets: []string{"10.0.0.2", "10.0.0.1"}, RecordTTL: 60},
+			desired:   v1.DNSRecordSpec{Targets: []string{"10.0.0.1", "10.0.0.2"}, RecordTTL: 60},
+			want:      true,
+		},
+		{
+			name:      "same IPv6 targets, in another format",
+			published: v1.DNSRecordSpec{Targets: []string{"2001:db8:0:0:0:0:0:1"}, RecordTTL: 60},
+			desired:   v1.DNSRecordSpec{Targets: []string{"2001:DB8::1"}, RecordTTL: 60},
+			want:      true,
+		},
+		{
+			name:      "same CNAME target, fully qualified and in another case",
+			published: v1.DNSRecordSpec{Targets: []string{"lb.example.org."}, RecordTTL: 60},
+			desired:   v1.DNSRecordSpec{Targets: []string{"LB.example.org"}, RecordTTL: 60},
+			want:      true,
+		},
+		{
+			name:      "default TTL",
+			published: v1.DNSRecordSpec{Targets: []string{"10.0.0.1"}, RecordTTL: 0},
+			desired:   v1.DNSRecordSpec{Targets: []string{"10.0.0.1"}, RecordTTL: 0},
+			want:      true,
+		},
+		{
+			name:      "different targets",
+			published: v1.DNSRecordSpec{Targets: []string{"10.0.0.1", "10.0.0.3"}, RecordTTL: 60},
+			desired:   v1.DNSRecordSpec{Targets: []string{"10.0.0.1", "10.0.0.2"}, RecordTTL: 60},
+		},
+		{
+			name:      "missing target",
+			published: v1.DNSRecordSpec{Targets: []string{"10.0.0.1"}, RecordTTL: 60},
+			desired:   v1.DNSRecordSpec{Targets: []string{"10.0.0.1", "10.0.0.2"}, RecordTTL: 60},
+		},
+		{
+			name:      "different TTL",
+			published: v1.DNSRecordSpec{Targets: []string{"10.0.0.1"}, RecordTTL: 300},
+			desired:   v1.DNSRecordSpec{Targets: []string{"10.0.0.1"}, RecordTTL: 60},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameRecord(tt.published, tt.desired); got != tt.want {
+				t.Errorf("sameRecord() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package dns
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns"
+)
+
+// checkHealth starts or stops probing the targets of the record, depending on
+// whether it is health checked, and reports their health in the record status.
+func (c *Controller) checkHealth(dnsRecord *v1.DNSRecord) error {
+	key, err := cache.MetaNamespaceKeyFunc(dnsRecord)
+	if err != nil {
+		return err
+	}
+
+	if c.healthChecker == nil || dnsRecord.Spec.HealthCheck == nil || dnsRecord.DeletionTimestamp != nil {
+		if c.healthChecker != nil {
+			c.healthChecker.Remove(key)
+		}
+		dnsRecord.Status.Targets = nil
+		return nil
+	}
+
+	c.healthChecker.Ensure(key, dnsRecord)
+	dnsRecord.Status.Targets = c.healthChecker.Status(key)
+
+	return nil
+}
+
+// publishedRecord returns a copy of the record, with the targets to publish,
+// i.e., without the drained and the unhealthy ones, unless none of the targets
+// of the records with the same DNS name is healthy.
+func (c *Controller) publishedRecord(dnsRecord *v1.DNSRecord) (*v1.DNSRecord, error) {
+	siblings, err := c.siblings(dnsRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	answered := map[string]struct{}{}
+	for _, target := range dns.AnsweredTargets(append(siblings, dnsRecord)...) {
+		answered[target.Value] = struct{}{}
+	}
+
+	published := dnsRecord.DeepCopy()
+	published.Spec.Targets = nil
+	for _, target := range dnsRecord.Spec.Targets {
+		if _, ok := answered[target]; ok {
+			published.Spec.Targets = append(published.Spec.Targets, target)
+		}
+	}
+
+	return published, nil
+}
+
+// siblings returns the other records with the same DNS name and type.
+func (c *Controller) siblings(dnsRecord *v1.DNSRecord) ([]*v1.DNSRecord, error) {
+	records, err := c.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var siblings []*v1.DNSRecord
+	for _, record := range records {
+		if record.Spec.DNSName != dnsRecord.Spec.DNSName || record.Spec.RecordType != dnsRecord.Spec.RecordType {
+			continue
+		}
+		if record.Namespace == dnsRecord.Namespace && record.Name == dnsRecord.Name && record.ClusterName == dnsRecord.ClusterName {
+			continue
+		}
+		siblings = append(siblings, record)
+	}
+
+	return siblings, nil
+}
+
+// healthChanged enqueues the record which targets health has changed, as well
+// as its siblings, which published targets may depend on it.
+func (c *Controller) healthChanged(key string) {
+	c.queue.Add(key)
+
+	obj, exists, err := c.indexer.GetByKey(key)
+	if err != nil || !exists {
+		return
+	}
+	siblings, err := c.siblings(obj.(*v1.DNSRecord))
+	if err != nil {
+		klog.Errorf("failed to list the siblings of DNSRecord %q: %v", key, err)
+		return
+	}
+	for _, sibling := range siblings {
+		c.enqueue(sibling)
+	}
+}
@@ -0,0 +1,23 @@
+package dns
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	driftMissing = "missing"
+	driftChanged = "changed"
+	driftOrphan  = "orphan"
+)
+
+var driftsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "kcp_ingress_dns_drifts_total",
+		Help: "Number of records found drifted from the DNSRecords in the managed zones, by kind: missing, changed or orphan.",
+	},
+	[]string{"zone", "kind"},
+)
+
+func init() {
+	prometheus.MustRegister(driftsTotal)
+}
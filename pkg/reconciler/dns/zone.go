@@ -0,0 +1,124 @@
+package dns
+
+import (
+	"strings"
+	"sync"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns/registry"
+)
+
+// zoneFor returns the managed zone a DNS name is published to, i.e., the one
+// with the longest domain name the DNS name is a subdomain of, or nil if the
+// DNS name does not belong to any of the managed zones.
+func zoneFor(zones []*v1.ManagedZone, dnsName string) *v1.ManagedZone {
+	name := normalize(dnsName)
+
+	var match *v1.ManagedZone
+	for _, zone := range zones {
+		domain := normalize(zone.Spec.DomainName)
+		if name != domain && !strings.HasSuffix(name, "."+domain) {
+			continue
+		}
+		if match == nil || len(domain) > len(normalize(match.Spec.DomainName)) {
+			match = zone
+		}
+	}
+
+	return match
+}
+
+// zoneByID returns the managed zone with the given provider identifier, or nil
+// if there is none.
+func zoneByID(zones []*v1.ManagedZone, id string) *v1.ManagedZone {
+	for _, zone := range zones {
+		if zone.ZoneID() == id {
+			return zone
+		}
+	}
+	return nil
+}
+
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// providers caches the providers of the managed zones, so that they are not
+// configured again on every reconciliation, e.g., authenticating to the DNS
+// service API.
+type providers struct {
+	mu      sync.Mutex
+	entries map[string]*providerEntry
+}
+
+// providerEntry is the provider of a managed zone, along with the provider
+// name and the resourceVersion of the credentials Secret it was configured
+// with, which invalidate it when they change.
+type providerEntry struct {
+	name          string
+	secretVersion string
+	provider      *registry.TXTRegistry
+}
+
+func (p *providers) get(key, name, secretVersion string) *registry.TXTRegistry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.entries[key]
+	if !ok || entry.name != name || entry.secretVersion != secretVersion {
+		return nil
+	}
+	return entry.provider
+}
+
+func (p *providers) add(key, name, secretVersion string, provider *registry.TXTRegistry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.entries == nil {
+		p.entries = map[string]*providerEntry{}
+	}
+	p.entries[key] = &providerEntry{name: name, secretVersion: secretVersion, provider: provider}
+}
+
+// forget discards the provider of the managed zone with the given key.
+func (p *providers) forget(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.entries, key)
+}
+
+// providerFor returns the provider of the managed zone, configured with the
+// zone credentials if any, that only modifies the records owned by the controller.
+// The provider is cached until the zone provider or credentials Secret change.
+func (c *Controller) providerFor(zone *v1.ManagedZone) (*registry.TXTRegistry, error) {
+	key, err := cache.MetaNamespaceKeyFunc(zone)
+	if err != nil {
+		return nil, err
+	}
+
+	var credentials map[string][]byte
+	secretVersion := ""
+	if ref := zone.Spec.CredentialsSecretRef; ref != nil {
+		secret, err := c.secretLister.Secrets(ref.Namespace).Get(clusters.ToClusterAwareKey(zone.ClusterName, ref.Name))
+		if err != nil {
+			return nil, err
+		}
+		credentials = secret.Data
+		secretVersion = secret.ResourceVersion
+	}
+
+	if provider := c.providers.get(key, zone.Spec.Provider, secretVersion); provider != nil {
+		return provider, nil
+	}
+
+	provider, err := c.newProvider(zone.Spec.Provider, credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	txtRegistry := registry.NewTXTRegistry(provider, c.ownerID)
+	c.providers.add(key, zone.Spec.Provider, secretVersion, txtRegistry)
+	return txtRegistry, nil
+}
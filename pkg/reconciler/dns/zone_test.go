@@ -0,0 +1,209 @@
+package dns
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns"
+	"github.com/kuadrant/kcp-ingress/pkg/dns/inmemory"
+)
+
+func newManagedZone(name, domainName string) *v1.ManagedZone {
+	return &v1.ManagedZone{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       v1.ManagedZoneSpec{DomainName: domainName},
+	}
+}
+
+func TestZoneFor(t *testing.T) {
+	zones := []*v1.ManagedZone{
+		newManagedZone("apps", "kcp-apps.example.com"),
+		newManagedZone("root", "example.com"),
+		newManagedZone("eu", "EU.kcp-apps.example.com."),
+	}
+
+	tests := []struct {
+		name     string
+		dnsName  string
+		wantZone string
+	}{
+		{
+			name:     "subdomain of a single zone",
+			dnsName:  "www.example.com",
+			wantZone: "root",
+		},
+		{
+			name:     "longest matching zone",
+			dnsName:  "app.kcp-apps.example.com",
+			wantZone: "apps",
+		},
+		{
+			name:     "most specific zone, case insensitive and fully qualified",
+			dnsName:  "App.eu.kcp-apps.example.com.",
+			wantZone: "eu",
+		},
+		{
+			name:     "zone apex",
+			dnsName:  "kcp-apps.example.com",
+			wantZone: "apps",
+		},
+		{
+			name:     "suffix which is not a parent domain",
+			dnsName:  "app.notexample.com",
+			wantZone: "",
+		},
+		{
+			name:     "no matching zone",
+			dnsName:  "app.example.org",
+			wantZone: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := zoneFor(zones, tt.dnsName)
+			got := ""
+			if zone != nil {
+				got = zone.Name
+			}
+			if got != tt.wantZone {
+				t.Errorf("zoneFor(%q) = %q, want %q", tt.dnsName, got, tt.wantZone)
+			}
+		})
+	}
+}
+
+func TestZoneByID(t *testing.T) {
+	withID := newManagedZone("hosted", "example.com")
+	withID.Spec.ID = "Z0123456789"
+	zones := []*v1.ManagedZone{withID, newManagedZone("apps", "kcp-apps.example.com")}
+
+	tests := []struct {
+		name     string
+		id       string
+		wantZone string
+	}{
+		{
+			name:     "provider identifier",
+			id:       "Z0123456789",
+			wantZone: "hosted",
+		},
+		{
+			name:     "domain name without identifier",
+			id:       "kcp-apps.example.com",
+			wantZone: "apps",
+		},
+		{
+			name:     "domain name of a zone with an identifier",
+			id:       "example.com",
+			wantZone: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := zoneByID(zones, tt.id)
+			got := ""
+			if zone != nil {
+				got = zone.Name
+			}
+			if got != tt.wantZone {
+				t.Errorf("zoneByID(%q) = %q, want %q", tt.id, got, tt.wantZone)
+			}
+		})
+	}
+}
+
+func TestProviderFor(t *testing.T) {
+	newZone := func(provider string) *v1.ManagedZone {
+		zone := newManagedZone("eu", "eu.kcp-apps.example.com")
+		zone.Spec.Provider = provider
+		zone.Spec.CredentialsSecretRef = &corev1.SecretReference{Namespace: "kcp-ingress", Name: "eu-zone-credentials"}
+		return zone
+	}
+	newSecret := func(resourceVersion string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "kcp-ingress", Name: "eu-zone-credentials", ResourceVersion: resourceVersion},
+			Data:       map[string][]byte{"tsig-secret": []byte(resourceVersion)},
+		}
+	}
+
+	tests := []struct {
+		name string
+		// update changes the zone or its credentials, once its provider has been configured
+		update        func(zone *v1.ManagedZone, secret *corev1.Secret)
+		wantProviders int
+		wantErr       bool
+	}{
+		{
+			name:          "unchanged zone",
+			update:        func(zone *v1.ManagedZone, secret *corev1.Secret) {},
+			wantProviders: 1,
+		},
+		{
+			name: "updated credentials",
+			update: func(zone *v1.ManagedZone, secret *corev1.Secret) {
+				*secret = *newSecret("2")
+			},
+			wantProviders: 2,
+		},
+		{
+			name: "changed provider",
+			update: func(zone *v1.ManagedZone, secret *corev1.Secret) {
+				zone.Spec.Provider = "inmemory"
+			},
+			wantProviders: 2,
+		},
+		{
+			name: "missing credentials",
+			update: func(zone *v1.ManagedZone, secret *corev1.Secret) {
+				zone.Spec.CredentialsSecretRef.Name = "missing"
+			},
+			wantProviders: 1,
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			var credentials []string
+			c := &Controller{
+				secretLister: corev1lister.NewSecretLister(indexer),
+				newProvider: func(_ string, c map[string][]byte) (dns.Provider, error) {
+					credentials = append(credentials, string(c["tsig-secret"]))
+					return inmemory.NewProvider(), nil
+				},
+				ownerID: "kcp-ingress",
+			}
+			zone, secret := newZone("rfc2136"), newSecret("1")
+			if err := indexer.Add(secret); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := c.providerFor(zone); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tt.update(zone, secret)
+			if err := indexer.Update(secret); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, err := c.providerFor(zone)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(credentials) != tt.wantProviders {
+				t.Errorf("providers configured = %d, want %d", len(credentials), tt.wantProviders)
+			}
+			// The providers are configured with the current credentials
+			if last := credentials[len(credentials)-1]; last != string(secret.Data["tsig-secret"]) {
+				t.Errorf("credentials = %q, want %q", last, secret.Data["tsig-secret"])
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package domainclaim
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+// challengeKeyKey is the key of the challenge key in the Secret data.
+const challengeKeyKey = "key"
+
+// Challenge returns the challenge value of the claim, i.e., an HMAC of its
+// workspace, namespace and domain, so that the value a claimant publishes
+// cannot be used to verify the claims of the same domain in other namespaces
+// or workspaces. It's derived by the controller, rather than read from the
+// claim status, which the claim owner can write.
+func Challenge(key []byte, claim *v1.DomainClaim) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.Join([]string{claim.ClusterName, claim.Namespace, strings.TrimSuffix(strings.ToLower(claim.Spec.Domain), ".")}, "/")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewChallengeKey returns a random key to derive the challenge values with.
+func NewChallengeKey() ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// LoadChallengeKey returns the key the challenge values are derived with, held
+// by the Secret, which is created with a new key if it doesn't exist, so that
+// the challenge values don't change across restarts.
+func LoadChallengeKey(ctx context.Context, client kubernetes.Interface, namespace, name string) ([]byte, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		if len(secret.Data[challengeKeyKey]) == 0 {
+			return nil, fmt.Errorf("no challenge key found in Secret %q", name)
+		}
+		return secret.Data[challengeKeyKey], nil
+	}
+	if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	key, err := NewChallengeKey()
+	if err != nil {
+		return nil, err
+	}
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{challengeKeyKey: key},
+	}
+	klog.Infof("creating DomainClaim challenge key Secret %q", name)
+	_, err = client.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		// Another instance created it in the meantime
+		return LoadChallengeKey(ctx, client, namespace, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,198 @@
+package domainclaim
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	kuadrantv1 "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned"
+	"github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/informers/externalversions"
+	"github.com/kuadrant/kcp-ingress/pkg/resolver"
+)
+
+const (
+	resyncPeriod = 10 * time.Hour
+
+	defaultVerificationInterval = time.Minute
+	defaultRevalidationInterval = 10 * time.Minute
+)
+
+// NewController returns a new Controller which verifies the DomainClaims.
+func NewController(config *ControllerConfig) *Controller {
+	client := kuadrantv1.NewForConfigOrDie(config.Cfg)
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	stopCh := make(chan struct{}) // TODO: hook this up to SIGTERM/SIGINT
+
+	c := &Controller{
+		queue:                queue,
+		client:               client,
+		stopCh:               stopCh,
+		resolver:             config.Resolver,
+		challengeKey:         config.ChallengeKey,
+		verificationInterval: defaultVerificationInterval,
+		revalidationInterval: defaultRevalidationInterval,
+	}
+
+	if c.resolver == nil {
+		c.resolver = resolver.NewTXTResolver()
+	}
+	if c.challengeKey == nil {
+		// The challenge values change across restarts
+		key, err := NewChallengeKey()
+		if err != nil {
+			klog.Fatalf("Failed to generate the challenge key: %v", err)
+		}
+		c.challengeKey = key
+	}
+	if config.VerificationInterval != nil {
+		c.verificationInterval = *config.VerificationInterval
+	}
+	if config.RevalidationInterval != nil {
+		c.revalidationInterval = *config.RevalidationInterval
+	}
+
+	sif := externalversions.NewSharedInformerFactoryWithOptions(c.client, resyncPeriod)
+
+	// Watch for events related to DomainClaims
+	sif.Kuadrant().V1().DomainClaims().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: func(obj interface{}) { c.enqueue(obj) },
+	})
+
+	sif.Start(stopCh)
+	for inf, sync := range sif.WaitForCacheSync(stopCh) {
+		if !sync {
+			klog.Fatalf("Failed to sync %s", inf)
+		}
+	}
+
+	c.indexer = sif.Kuadrant().V1().DomainClaims().Informer().GetIndexer()
+
+	return c
+}
+
+type ControllerConfig struct {
+	Cfg                  *rest.Config
+	Resolver             resolver.TXTResolver
+	ChallengeKey         []byte
+	VerificationInterval *time.Duration
+	RevalidationInterval *time.Duration
+}
+
+type Controller struct {
+	queue                workqueue.RateLimitingInterface
+	client               kuadrantv1.Interface
+	stopCh               chan struct{}
+	indexer              cache.Indexer
+	resolver             resolver.TXTResolver
+	challengeKey         []byte
+	verificationInterval time.Duration
+	revalidationInterval time.Duration
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	c.queue.AddRateLimited(key)
+}
+
+func (c *Controller) Start(numThreads int) {
+	defer c.queue.ShutDown()
+	for i := 0; i < numThreads; i++ {
+		go wait.Until(c.startWorker, time.Second, c.stopCh)
+	}
+	klog.Infof("Starting workers")
+	<-c.stopCh
+	klog.Infof("Stopping workers")
+}
+
+func (c *Controller) startWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+func (c *Controller) processNextWorkItem() bool {
+	// Wait until there is a new item in the working queue
+	k, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	key := k.(string)
+
+	// No matter what, tell the queue we're done with this key, to unblock
+	// other workers.
+	defer c.queue.Done(key)
+
+	err := c.process(key)
+	c.handleErr(err, key)
+	return true
+}
+
+func (c *Controller) handleErr(err error, key string) {
+	// Reconcile worked, nothing else to do for this workqueue item.
+	if err == nil {
+		c.queue.Forget(key)
+		return
+	}
+
+	// Re-enqueue up to 5 times.
+	num := c.queue.NumRequeues(key)
+	if num < 5 {
+		klog.Errorf("Error reconciling key %q, retrying... (#%d): %v", key, num, err)
+		c.queue.AddRateLimited(key)
+		return
+	}
+
+	// Give up and report error elsewhere.
+	c.queue.Forget(key)
+	runtime.HandleError(err)
+	klog.Infof("Dropping key %q after failed retries: %v", key, err)
+}
+
+func (c *Controller) process(key string) error {
+	obj, exists, err := c.indexer.GetByKey(key)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		// The Ingresses watch the DomainClaims, and withdraw the hosts that are not claimed anymore
+		klog.Infof("Object with key %q was deleted", key)
+		return nil
+	}
+
+	previous := obj.(*v1.DomainClaim)
+	current := previous.DeepCopy()
+
+	ctx := context.TODO()
+	reconcileErr := c.reconcile(ctx, current)
+
+	if current.IsVerified() {
+		// Check the challenge record is still published
+		c.queue.AddAfter(key, c.revalidationInterval)
+	} else {
+		// Retry the verification until the challenge record is published
+		c.queue.AddAfter(key, c.verificationInterval)
+	}
+
+	if !equality.Semantic.DeepEqual(previous.Status, current.Status) {
+		if _, err := c.client.KuadrantV1().DomainClaims(current.Namespace).UpdateStatus(ctx, current, metav1.UpdateOptions{}); err != nil {
+			return err
+		}
+	}
+
+	return reconcileErr
+}
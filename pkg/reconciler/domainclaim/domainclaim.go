@@ -0,0 +1,91 @@
+package domainclaim
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/resolver"
+)
+
+const (
+	// challengePrefix is the label prepended to the claimed domain, to form
+	// the name of the challenge TXT record.
+	challengePrefix = "_kcp-ingress-challenge"
+
+	challengeSucceededReason = "ChallengeSucceeded"
+	challengeFailedReason    = "ChallengeFailed"
+)
+
+func (c *Controller) reconcile(ctx context.Context, claim *v1.DomainClaim) error {
+	klog.Infof("reconciling DomainClaim %q", claim.Name)
+
+	// The verified claims are verified again, so that they are withdrawn once the
+	// challenge record is removed. The challenge value is reset, in case it's been
+	// changed.
+	claim.Status.Challenge = Challenge(c.challengeKey, claim)
+	claim.Status.ChallengeRecord = ChallengeRecord(claim.Spec.Domain)
+
+	verified := v1.DomainClaimCondition{
+		Type:    v1.DomainClaimVerifiedConditionType,
+		Status:  string(metav1.ConditionTrue),
+		Reason:  challengeSucceededReason,
+		Message: fmt.Sprintf("The challenge TXT record %q has been found", claim.Status.ChallengeRecord),
+	}
+	if err := c.verify(ctx, claim); err != nil {
+		klog.Infof("failed to verify DomainClaim %q: %v", claim.Name, err)
+		verified.Status = string(metav1.ConditionFalse)
+		verified.Reason = challengeFailedReason
+		verified.Message = err.Error()
+	}
+
+	claim.Status.Conditions = []v1.DomainClaimCondition{setLastTransitionTime(claim.Status.Conditions, verified)}
+	claim.Status.ObservedGeneration = claim.Generation
+
+	return nil
+}
+
+// verify looks up the challenge TXT record of the claim, and checks it holds
+// the challenge value of the claim.
+func (c *Controller) verify(ctx context.Context, claim *v1.DomainClaim) error {
+	return Verify(ctx, c.resolver, claim.Spec.Domain, Challenge(c.challengeKey, claim))
+}
+
+// ChallengeRecord returns the name of the challenge TXT record of the domain.
+func ChallengeRecord(domain string) string {
+	return challengePrefix + "." + strings.TrimSuffix(strings.ToLower(domain), ".")
+}
+
+// Verify looks up the challenge TXT record of the domain, and checks it holds
+// the challenge value. The record name is derived from the domain, rather than
+// read from the claim status, which the claim owner can write.
+func Verify(ctx context.Context, r resolver.TXTResolver, domain, challenge string) error {
+	record := ChallengeRecord(domain)
+	values, err := r.LookupTXT(ctx, record)
+	if err != nil {
+		return fmt.Errorf("failed to look up the challenge TXT record %q: %w", record, err)
+	}
+
+	for _, value := range values {
+		if challenge != "" && value == challenge {
+			return nil
+		}
+	}
+	return fmt.Errorf("the challenge TXT record %q doesn't hold the challenge value %q", record, challenge)
+}
+
+// setLastTransitionTime sets the condition transition time, preserving the
+// current one if the condition status has not changed.
+func setLastTransitionTime(conditions []v1.DomainClaimCondition, condition v1.DomainClaimCondition) v1.DomainClaimCondition {
+	condition.LastTransitionTime = metav1.Now()
+	for _, c := range conditions {
+		if c.Type == condition.Type && c.Status == condition.Status {
+			condition.LastTransitionTime = c.LastTransitionTime
+		}
+	}
+	return condition
+}
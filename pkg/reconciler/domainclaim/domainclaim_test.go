@@ -0,0 +1,189 @@
+package domainclaim
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/resolver"
+)
+
+func newClaim(cluster, namespace, domain string) *v1.DomainClaim {
+	return &v1.DomainClaim{
+		ObjectMeta: metav1.ObjectMeta{ClusterName: cluster, Namespace: namespace, Name: "claim"},
+		Spec:       v1.DomainClaimSpec{Domain: domain},
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	key := []byte("key")
+	// The claim of the domain owner, which challenge value is published
+	owner := newClaim("workspace-a", "default", "whatever.com")
+
+	tests := []struct {
+		name         string
+		claim        *v1.DomainClaim
+		wantVerified bool
+	}{
+		{
+			name:         "claim of the domain owner",
+			claim:        newClaim("workspace-a", "default", "whatever.com"),
+			wantVerified: true,
+		},
+		{
+			name:         "claim of the domain owner, fully qualified",
+			claim:        newClaim("workspace-a", "default", "Whatever.com."),
+			wantVerified: true,
+		},
+		{
+			name:  "claim of the same domain in another workspace",
+			claim: newClaim("workspace-b", "default", "whatever.com"),
+		},
+		{
+			name: "claim of the same domain in another workspace, with the copied challenge value",
+			claim: func() *v1.DomainClaim {
+				claim := newClaim("workspace-b", "default", "whatever.com")
+				claim.Status.Challenge = Challenge(key, owner)
+				return claim
+			}(),
+		},
+		{
+			name:  "claim of the same domain in another namespace",
+			claim: newClaim("workspace-a", "other", "whatever.com"),
+		},
+		{
+			name:  "claim of another domain",
+			claim: newClaim("workspace-a", "default", "other.com"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := resolver.NewFakeResolver(time.Minute)
+			r.SetTXT("_kcp-ingress-challenge.whatever.com", Challenge(key, owner))
+			c := &Controller{resolver: r, challengeKey: key}
+
+			if err := c.reconcile(context.Background(), tt.claim); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := tt.claim.IsVerified(); got != tt.wantVerified {
+				t.Errorf("verified = %v, want %v: %v", got, tt.wantVerified, tt.claim.Status.Conditions)
+			}
+			if tt.claim.Status.Challenge != Challenge(key, tt.claim) {
+				t.Errorf("challenge = %q, want the value derived for the claim", tt.claim.Status.Challenge)
+			}
+		})
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	claim := newClaim("workspace-a", "default", "whatever.com")
+
+	tests := []struct {
+		name      string
+		key       []byte
+		claim     *v1.DomainClaim
+		wantEqual bool
+	}{
+		{
+			name:      "same claim",
+			key:       []byte("key"),
+			claim:     newClaim("workspace-a", "default", "whatever.com"),
+			wantEqual: true,
+		},
+		{
+			name:      "same domain, in another case",
+			key:       []byte("key"),
+			claim:     newClaim("workspace-a", "default", "WHATEVER.com"),
+			wantEqual: true,
+		},
+		{
+			name:  "another key",
+			key:   []byte("other"),
+			claim: newClaim("workspace-a", "default", "whatever.com"),
+		},
+		{
+			name:  "another workspace",
+			key:   []byte("key"),
+			claim: newClaim("workspace-b", "default", "whatever.com"),
+		},
+		{
+			name:  "another namespace",
+			key:   []byte("key"),
+			claim: newClaim("workspace-a", "other", "whatever.com"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Challenge(tt.key, tt.claim) == Challenge([]byte("key"), claim); got != tt.wantEqual {
+				t.Errorf("equal challenges = %v, want %v", got, tt.wantEqual)
+			}
+		})
+	}
+}
+
+func TestLoadChallengeKey(t *testing.T) {
+	secret := func(key string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "kcp-ingress-domain-claim"},
+			Data:       map[string][]byte{challengeKeyKey: []byte(key)},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		objects []runtime.Object
+		wantKey string
+		wantErr bool
+	}{
+		{
+			name: "new key",
+		},
+		{
+			name:    "existing key",
+			objects: []runtime.Object{secret("key")},
+			wantKey: "key",
+		},
+		{
+			name:    "empty key",
+			objects: []runtime.Object{secret("")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := kubefake.NewSimpleClientset(tt.objects...)
+
+			key, err := LoadChallengeKey(context.Background(), client, "default", "kcp-ingress-domain-claim")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.wantKey != "" && string(key) != tt.wantKey {
+				t.Errorf("key = %q, want %q", key, tt.wantKey)
+			}
+			if len(key) == 0 {
+				t.Fatalf("empty key")
+			}
+
+			// The key is the same once loaded again
+			again, err := LoadChallengeKey(context.Background(), client, "default", "kcp-ingress-domain-claim")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(again) != string(key) {
+				t.Errorf("key = %q once loaded again, want %q", again, key)
+			}
+		})
+	}
+}
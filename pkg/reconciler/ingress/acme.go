@@ -0,0 +1,38 @@
+package ingress
+
+import (
+	"context"
+
+	"github.com/kuadrant/kcp-ingress/pkg/acme"
+	"github.com/kuadrant/kcp-ingress/pkg/envoy"
+)
+
+// HTTP01Solver returns the solver of the ACME HTTP-01 challenges, that serves
+// their responses from the Envoy listener, or nil if the Envoy control plane
+// is not enabled.
+func (c *Controller) HTTP01Solver() acme.Solver {
+	if c.envoyXDS == nil {
+		return nil
+	}
+	return &http01Solver{c: c}
+}
+
+type http01Solver struct {
+	c *Controller
+}
+
+var _ acme.Solver = &http01Solver{}
+
+func (s *http01Solver) Type() string {
+	return acme.HTTP01
+}
+
+func (s *http01Solver) Present(_ context.Context, host, token, response string) error {
+	s.c.cache.SetChallenge(host, acme.HTTP01ChallengePath(token), response)
+	return s.c.envoyXDS.SetSnapshot(envoy.NodeID, s.c.cache.ToEnvoySnapshot())
+}
+
+func (s *http01Solver) CleanUp(_ context.Context, host, token string) error {
+	s.c.cache.DeleteChallenge(host, acme.HTTP01ChallengePath(token))
+	return s.c.envoyXDS.SetSnapshot(envoy.NodeID, s.c.cache.ToEnvoySnapshot())
+}
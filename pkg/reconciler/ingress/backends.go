@@ -0,0 +1,144 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog"
+)
+
+// backendsAnnotation reports the conditions of the backend Services of the
+// root Ingress, as a JSON list, so that the missing backends are visible while
+// the leaves are still created for the other ones.
+const backendsAnnotation = "kuadrant.dev/backends"
+
+const (
+	serviceResolvedReason    = "ServiceResolved"
+	serviceNotFoundReason    = "ServiceNotFound"
+	serviceNotAssignedReason = "ServiceNotAssigned"
+)
+
+// backendCondition is the condition of a backend Service of a root Ingress.
+type backendCondition struct {
+	Service string                 `json:"service"`
+	Status  metav1.ConditionStatus `json:"status"`
+	Reason  string                 `json:"reason"`
+	Message string                 `json:"message,omitempty"`
+	Cluster string                 `json:"cluster,omitempty"`
+}
+
+// getServices will parse the ingress object and return the list of the backend
+// services that exist, as well as the conditions of all the backends. The
+// services are read from the informer cache, and the missing ones don't
+// prevent the other ones from being returned.
+func (c *Controller) getServices(ingress *networkingv1.Ingress) ([]*corev1.Service, []backendCondition, error) {
+	names := map[string]struct{}{}
+	if backend := ingress.Spec.DefaultBackend; backend != nil && backend.Service != nil {
+		names[backend.Service.Name] = struct{}{}
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service != nil {
+				names[path.Backend.Service.Name] = struct{}{}
+			}
+		}
+	}
+
+	var services []*corev1.Service
+	conditions := make([]backendCondition, 0, len(names))
+	for name := range names {
+		svc, err := c.serviceLister.Services(ingress.Namespace).Get(clusters.ToClusterAwareKey(ingress.ClusterName, name))
+		if errors.IsNotFound(err) {
+			klog.Infof("Service %q of Ingress %q not found", name, ingress.Name)
+			conditions = append(conditions, backendCondition{
+				Service: name,
+				Status:  metav1.ConditionFalse,
+				Reason:  serviceNotFoundReason,
+				Message: "The Service does not exist",
+			})
+			// Trigger reconciliation of the ingress once the service gets created.
+			c.tracker.add(ingress, &corev1.Service{ObjectMeta: metav1.ObjectMeta{Namespace: ingress.Namespace, Name: name, ClusterName: ingress.ClusterName}})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if cluster := svc.Labels[clusterLabel]; cluster != "" {
+			conditions = append(conditions, backendCondition{
+				Service: name,
+				Status:  metav1.ConditionTrue,
+				Reason:  serviceResolvedReason,
+				Cluster: cluster,
+			})
+		} else {
+			conditions = append(conditions, backendCondition{
+				Service: name,
+				Status:  metav1.ConditionFalse,
+				Reason:  serviceNotAssignedReason,
+				Message: "The Service is not assigned to any cluster",
+			})
+		}
+		services = append(services, svc)
+	}
+
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	sort.Slice(conditions, func(i, j int) bool { return conditions[i].Service < conditions[j].Service })
+
+	return services, conditions, nil
+}
+
+// recordBackendConditions records the conditions of the backends in the root
+// Ingress annotations, if they have changed, and reports the backends that go
+// missing or get resolved as events. It returns the root Ingress, patched if
+// the conditions have changed.
+func (c *Controller) recordBackendConditions(ctx context.Context, root *networkingv1.Ingress, conditions []backendCondition) (*networkingv1.Ingress, error) {
+	var current []backendCondition
+	if value, ok := root.Annotations[backendsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &current); err != nil {
+			current = nil
+		}
+	}
+	if equality.Semantic.DeepEqual(current, conditions) {
+		return root, nil
+	}
+
+	for _, condition := range conditions {
+		var previous *backendCondition
+		for i := range current {
+			if current[i].Service == condition.Service {
+				previous = &current[i]
+			}
+		}
+		switch {
+		case condition.Status != metav1.ConditionTrue && (previous == nil || previous.Reason != condition.Reason):
+			c.recorder.Eventf(root, corev1.EventTypeWarning, "BackendMissing", "Service %q: %s", condition.Service, condition.Message)
+		case condition.Status == metav1.ConditionTrue && previous != nil && previous.Status != metav1.ConditionTrue:
+			c.recorder.Eventf(root, corev1.EventTypeNormal, "BackendResolved", "Service %q has been resolved", condition.Service)
+		}
+	}
+
+	value, err := json.Marshal(conditions)
+	if err != nil {
+		return nil, err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{backendsAnnotation: string(value)},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.patchIngress(ctx, root, patch)
+}
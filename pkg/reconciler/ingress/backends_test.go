@@ -0,0 +1,207 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func newBackendIngress(annotations map[string]string, services ...string) *networkingv1.Ingress {
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{ClusterName: "workspace", Namespace: "default", Name: "app", Annotations: annotations},
+	}
+	for _, service := range services {
+		ingress.Spec.Rules = append(ingress.Spec.Rules, networkingv1.IngressRule{
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{Name: service}},
+					}},
+				},
+			},
+		})
+	}
+	return ingress
+}
+
+func TestGetServices(t *testing.T) {
+	service := func(name, cluster string) *corev1.Service {
+		s := &corev1.Service{ObjectMeta: metav1.ObjectMeta{ClusterName: "workspace", Namespace: "default", Name: name}}
+		if cluster != "" {
+			s.Labels = map[string]string{clusterLabel: cluster}
+		}
+		return s
+	}
+
+	tests := []struct {
+		name           string
+		ingress        *networkingv1.Ingress
+		wantServices   []string
+		wantConditions []backendCondition
+	}{
+		{
+			name:         "resolved service",
+			ingress:      newBackendIngress(nil, "assigned"),
+			wantServices: []string{"assigned"},
+			wantConditions: []backendCondition{
+				{Service: "assigned", Status: metav1.ConditionTrue, Reason: serviceResolvedReason, Cluster: "cluster-1"},
+			},
+		},
+		{
+			name:    "missing service",
+			ingress: newBackendIngress(nil, "missing", "assigned"),
+			// The existing services are still returned
+			wantServices: []string{"assigned"},
+			wantConditions: []backendCondition{
+				{Service: "assigned", Status: metav1.ConditionTrue, Reason: serviceResolvedReason, Cluster: "cluster-1"},
+				{Service: "missing", Status: metav1.ConditionFalse, Reason: serviceNotFoundReason, Message: "The Service does not exist"},
+			},
+		},
+		{
+			name:         "service not assigned to any cluster",
+			ingress:      newBackendIngress(nil, "unassigned"),
+			wantServices: []string{"unassigned"},
+			wantConditions: []backendCondition{
+				{Service: "unassigned", Status: metav1.ConditionFalse, Reason: serviceNotAssignedReason, Message: "The Service is not assigned to any cluster"},
+			},
+		},
+		{
+			name: "service of another workspace",
+			ingress: func() *networkingv1.Ingress {
+				ingress := newBackendIngress(nil, "assigned")
+				ingress.ClusterName = "other"
+				return ingress
+			}(),
+			wantConditions: []backendCondition{
+				{Service: "assigned", Status: metav1.ConditionFalse, Reason: serviceNotFoundReason, Message: "The Service does not exist"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			for _, s := range []*corev1.Service{service("assigned", "cluster-1"), service("unassigned", "")} {
+				if err := indexer.Add(s); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			c := &Controller{serviceLister: corev1lister.NewServiceLister(indexer), tracker: *NewTracker()}
+
+			services, conditions, err := c.getServices(tt.ingress)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, s := range services {
+				names = append(names, s.Name)
+			}
+			if !equalStrings(names, tt.wantServices) {
+				t.Errorf("services = %v, want %v", names, tt.wantServices)
+			}
+			if len(conditions) != len(tt.wantConditions) {
+				t.Fatalf("conditions = %v, want %v", conditions, tt.wantConditions)
+			}
+			for i := range conditions {
+				if conditions[i] != tt.wantConditions[i] {
+					t.Errorf("conditions = %v, want %v", conditions, tt.wantConditions)
+				}
+			}
+		})
+	}
+}
+
+func TestRecordBackendConditions(t *testing.T) {
+	resolved := backendCondition{Service: "app", Status: metav1.ConditionTrue, Reason: serviceResolvedReason, Cluster: "cluster-1"}
+	missing := backendCondition{Service: "app", Status: metav1.ConditionFalse, Reason: serviceNotFoundReason, Message: "The Service does not exist"}
+	unassigned := backendCondition{Service: "app", Status: metav1.ConditionFalse, Reason: serviceNotAssignedReason, Message: "The Service is not assigned to any cluster"}
+	annotations := func(conditions ...backendCondition) map[string]string {
+		value, err := json.Marshal(conditions)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return map[string]string{backendsAnnotation: string(value)}
+	}
+
+	tests := []struct {
+		name        string
+		current     map[string]string
+		conditions  []backendCondition
+		wantPatched bool
+		wantEvents  []string
+	}{
+		{
+			name:        "missing backend",
+			conditions:  []backendCondition{missing},
+			wantPatched: true,
+			wantEvents:  []string{"Warning BackendMissing Service \"app\": The Service does not exist"},
+		},
+		{
+			name:        "backend still missing",
+			current:     annotations(missing),
+			conditions:  []backendCondition{missing},
+			wantPatched: false,
+		},
+		{
+			name:        "missing backend for another reason",
+			current:     annotations(missing),
+			conditions:  []backendCondition{unassigned},
+			wantPatched: true,
+			wantEvents:  []string{"Warning BackendMissing Service \"app\": The Service is not assigned to any cluster"},
+		},
+		{
+			name:        "resolved backend",
+			current:     annotations(missing),
+			conditions:  []backendCondition{resolved},
+			wantPatched: true,
+			wantEvents:  []string{"Normal BackendResolved Service \"app\" has been resolved"},
+		},
+		{
+			name:        "new resolved backend",
+			conditions:  []backendCondition{resolved},
+			wantPatched: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := newBackendIngress(tt.current, "app")
+			client := kubefake.NewSimpleClientset(root)
+			recorder := record.NewFakeRecorder(10)
+			c := &Controller{client: client, recorder: recorder}
+
+			patched, err := c.recordBackendConditions(context.Background(), root, tt.conditions)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := patched != root; got != tt.wantPatched {
+				t.Errorf("patched = %v, want %v", got, tt.wantPatched)
+			}
+			// The returned root Ingress holds the conditions
+			var got []backendCondition
+			if err := json.Unmarshal([]byte(patched.Annotations[backendsAnnotation]), &got); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(got) != len(tt.conditions) || got[0] != tt.conditions[0] {
+				t.Errorf("conditions = %v, want %v", got, tt.conditions)
+			}
+
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			if !equalStrings(events, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", events, tt.wantEvents)
+			}
+		})
+	}
+}
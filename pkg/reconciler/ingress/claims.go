@@ -0,0 +1,75 @@
+package ingress
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/reconciler/domainclaim"
+	"github.com/kuadrant/kcp-ingress/pkg/resolver"
+)
+
+const (
+	// claimVerificationTTL bounds the duration the successful verifications of
+	// the DomainClaims are cached for.
+	claimVerificationTTL = time.Minute
+	// claimVerificationTimeout bounds the duration of the challenge TXT record
+	// lookups.
+	claimVerificationTimeout = 10 * time.Second
+)
+
+// claimVerifier checks the challenge TXT records of the DomainClaims reported
+// as verified, as their status can be written by the owner of the workspace,
+// against the challenge values derived with the key shared with the DomainClaim
+// controller.
+type claimVerifier struct {
+	resolver resolver.TXTResolver
+	key      []byte
+
+	mu       sync.Mutex
+	verified map[string]time.Time
+}
+
+func newClaimVerifier(resolver resolver.TXTResolver, key []byte) *claimVerifier {
+	return &claimVerifier{
+		resolver: resolver,
+		key:      key,
+		verified: map[string]time.Time{},
+	}
+}
+
+// isVerified returns whether the claim is reported as verified, and its
+// challenge TXT record holds the challenge value of the claim, whatever the
+// one reported in its status.
+func (v *claimVerifier) isVerified(claim *v1.DomainClaim) bool {
+	if !claim.IsVerified() || len(v.key) == 0 {
+		return false
+	}
+
+	challenge := domainclaim.Challenge(v.key, claim)
+	key := domainclaim.ChallengeRecord(claim.Spec.Domain) + "/" + challenge
+	v.mu.Lock()
+	expires, ok := v.verified[key]
+	v.mu.Unlock()
+	if ok && time.Now().Before(expires) {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), claimVerificationTimeout)
+	defer cancel()
+	if err := domainclaim.Verify(ctx, v.resolver, claim.Spec.Domain, challenge); err != nil {
+		klog.Infof("DomainClaim %q is reported as verified, but: %v", claim.Name, err)
+		v.mu.Lock()
+		delete(v.verified, key)
+		v.mu.Unlock()
+		return false
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.verified[key] = time.Now().Add(claimVerificationTTL)
+	return true
+}
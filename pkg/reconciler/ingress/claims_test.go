@@ -0,0 +1,79 @@
+package ingress
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/reconciler/domainclaim"
+	"github.com/kuadrant/kcp-ingress/pkg/resolver"
+)
+
+func TestClaimVerifier(t *testing.T) {
+	key := []byte("key")
+	claim := func(cluster string) *v1.DomainClaim {
+		return &v1.DomainClaim{
+			ObjectMeta: metav1.ObjectMeta{ClusterName: cluster, Namespace: "default", Name: "claim", Generation: 1},
+			Spec:       v1.DomainClaimSpec{Domain: "whatever.com"},
+			Status: v1.DomainClaimStatus{
+				ObservedGeneration: 1,
+				Conditions: []v1.DomainClaimCondition{{
+					Type:   v1.DomainClaimVerifiedConditionType,
+					Status: string(metav1.ConditionTrue),
+				}},
+			},
+		}
+	}
+	// The domain is owned by workspace-a, which publishes its challenge value
+	owner := claim("workspace-a")
+
+	tests := []struct {
+		name  string
+		key   []byte
+		claim *v1.DomainClaim
+		want  bool
+	}{
+		{
+			name:  "claim of the domain owner",
+			key:   key,
+			claim: claim("workspace-a"),
+			want:  true,
+		},
+		{
+			name: "claim of the domain owner, not verified",
+			key:  key,
+			claim: func() *v1.DomainClaim {
+				c := claim("workspace-a")
+				c.Status.Conditions[0].Status = string(metav1.ConditionFalse)
+				return c
+			}(),
+		},
+		{
+			name: "claim in another workspace, reported as verified with the copied challenge value",
+			key:  key,
+			claim: func() *v1.DomainClaim {
+				c := claim("workspace-b")
+				c.Status.Challenge = domainclaim.Challenge(key, owner)
+				return c
+			}(),
+		},
+		{
+			name:  "no challenge key",
+			claim: claim("workspace-a"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := resolver.NewFakeResolver(time.Minute)
+			r.SetTXT(domainclaim.ChallengeRecord("whatever.com"), domainclaim.Challenge(key, owner))
+			v := newClaimVerifier(r, tt.key)
+
+			if got := v.isVerified(tt.claim); got != tt.want {
+				t.Errorf("isVerified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,26 +2,42 @@ package ingress
 
 import (
 	"context"
+	"text/template"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/dynamic/dynamiclister"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1lister "k8s.io/client-go/listers/core/v1"
 	networkingv1lister "k8s.io/client-go/listers/networking/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
 
+	envoyclusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoyserver "knative.dev/net-kourier/pkg/envoy/server"
 
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	kuadrantclientset "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned"
 	kuadrantv1 "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned/typed/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/informers/externalversions"
+	kuadrantv1lister "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/listers/kuadrant/v1"
 	"github.com/kuadrant/kcp-ingress/pkg/envoy"
+	"github.com/kuadrant/kcp-ingress/pkg/resolver"
 )
 
 const resyncPeriod = 10 * time.Hour
@@ -35,8 +51,12 @@ func NewController(config *ControllerConfig) *Controller {
 	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
 	stopCh := make(chan struct{}) // TODO: hook this up to SIGTERM/SIGINT
 
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+
 	c := &Controller{
 		queue:           queue,
+		recorder:        broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: manager}),
 		client:          client,
 		dnsRecordClient: dnsRecordClient,
 		stopCh:          stopCh,
@@ -44,9 +64,71 @@ func NewController(config *ControllerConfig) *Controller {
 		tracker:         *NewTracker(),
 	}
 
+	r := config.Resolver
+	if r == nil {
+		r = resolver.NewResolver()
+	}
+	c.resolver = resolver.NewCachingResolver(r, c.ingressesFromHostname)
+	go c.resolver.Start(stopCh)
+
+	txtResolver := config.TXTResolver
+	if txtResolver == nil {
+		txtResolver = resolver.NewTXTResolver()
+	}
+	// Without the key of the DomainClaim controller, no claim can be verified
+	c.claimVerifier = newClaimVerifier(txtResolver, config.ClaimChallengeKey)
+
+	c.ingressClass = DefaultIngressClass
+	if config.IngressClass != nil {
+		c.ingressClass = *config.IngressClass
+	}
+	// The Ingresses without a class are handled by default, as they were before the classes were honoured
+	c.defaultIngressClass = true
+	if config.DefaultIngressClass != nil {
+		c.defaultIngressClass = *config.DefaultIngressClass
+	}
+	if config.LeafIngressClass != nil {
+		c.leafIngressClass = *config.LeafIngressClass
+	}
+	if config.ACMECertificates != nil {
+		c.acmeCertificates = *config.ACMECertificates
+	}
+	if err := c.registerIngressClass(context.TODO()); err != nil {
+		klog.Errorf("Failed to register IngressClass %q: %v", c.ingressClass, err)
+	}
+
+	hostnameTemplate := DefaultHostnameTemplate
+	if config.HostnameTemplate != nil {
+		hostnameTemplate = *config.HostnameTemplate
+	}
+	t, err := template.New("hostname").Option("missingkey=error").Parse(hostnameTemplate)
+	if err != nil {
+		klog.Fatalf("Invalid hostname template: %v", err)
+	}
+	c.hostnameTemplate = t
+
+	if config.ClusterGeoLabel != nil {
+		c.clusterGeoLabel = *config.ClusterGeoLabel
+	}
+	if config.ClusterGeo != nil {
+		clusterGeo, err := parseClusterValues(*config.ClusterGeo)
+		if err != nil {
+			klog.Fatalf("Invalid clusters geographical locations: %v", err)
+		}
+		c.clusterGeo = clusterGeo
+	}
+
 	if config.EnvoyXDS != nil {
 		c.envoyXDS = config.EnvoyXDS
-		c.cache = envoy.NewCache(envoy.NewTranslator(config.EnvoyListenPort))
+		dnsLookupFamily := envoyclusterv3.Cluster_V4_ONLY
+		if config.EnvoyDNSLookupFamily != nil {
+			family, err := envoy.ParseDNSLookupFamily(*config.EnvoyDNSLookupFamily)
+			if err != nil {
+				klog.Fatalf("Invalid Envoy DNS lookup family: %v", err)
+			}
+			dnsLookupFamily = family
+		}
+		c.cache = envoy.NewCache(envoy.NewTranslator(config.EnvoyListenPort, dnsLookupFamily))
 
 		go func() {
 			err := c.envoyXDS.RunManagementServer()
@@ -62,51 +144,155 @@ func NewController(config *ControllerConfig) *Controller {
 	sif.Networking().V1().Ingresses().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc:    func(obj interface{}) { c.enqueue(obj) },
 		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
-		DeleteFunc: func(obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: func(obj interface{}) { c.ingressDeleted(obj) },
 	})
 
 	// Watch for events related to Services
 	sif.Core().V1().Services().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.ingressesFromService(obj) },
 		UpdateFunc: func(_, obj interface{}) { c.ingressesFromService(obj) },
 		DeleteFunc: func(obj interface{}) { c.ingressesFromService(obj) },
 	})
 
+	// Watch for events related to Secrets, that may be the TLS Secrets of the Ingresses or their copies
+	sif.Core().V1().Secrets().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.ingressesFromSecret(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.ingressesFromSecret(obj) },
+		DeleteFunc: func(obj interface{}) { c.ingressesFromSecret(obj) },
+	})
+
+	ksif := externalversions.NewSharedInformerFactoryWithOptions(kuadrantclientset.NewForConfigOrDie(config.Cfg), resyncPeriod)
+
+	// Watch for events related to DomainClaims, that may verify or withdraw the Ingresses hosts
+	ksif.Kuadrant().V1().DomainClaims().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.ingressesInNamespace(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.ingressesInNamespace(obj) },
+		DeleteFunc: func(obj interface{}) { c.ingressesInNamespace(obj) },
+	})
+	// Watch for events related to IngressPlacements, that may change the clusters the Ingresses are placed on
+	ksif.Kuadrant().V1().IngressPlacements().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { c.ingressesInNamespace(obj) },
+		UpdateFunc: func(_, obj interface{}) { c.ingressesInNamespace(obj) },
+		DeleteFunc: func(obj interface{}) { c.ingressesInNamespace(obj) },
+	})
+	// Watch for the changes of the DNSRecords targets health, that may switch the active cluster of the
+	// Ingresses in failover mode, and of their publication, that is reported in the Ingresses status
+	ksif.Kuadrant().V1().DNSRecords().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, obj interface{}) {
+			if isHealthChanged(old.(*v1.DNSRecord), obj.(*v1.DNSRecord)) || isPublicationChanged(old.(*v1.DNSRecord), obj.(*v1.DNSRecord)) {
+				c.ingressesFromDNSRecord(obj.(*v1.DNSRecord))
+			}
+		},
+	})
+
 	sif.Start(stopCh)
+	ksif.Start(stopCh)
 	for inf, sync := range sif.WaitForCacheSync(stopCh) {
 		if !sync {
 			klog.Fatalf("Failed to sync %s", inf)
 		}
 	}
+	for inf, sync := range ksif.WaitForCacheSync(stopCh) {
+		if !sync {
+			klog.Fatalf("Failed to sync %s", inf)
+		}
+	}
 
 	c.indexer = sif.Networking().V1().Ingresses().Informer().GetIndexer()
 	c.lister = sif.Networking().V1().Ingresses().Lister()
+	c.serviceLister = sif.Core().V1().Services().Lister()
+	c.secretLister = sif.Core().V1().Secrets().Lister()
+	c.domainClaimLister = ksif.Kuadrant().V1().DomainClaims().Lister()
+	c.dnsRecordLister = ksif.Kuadrant().V1().DNSRecords().Lister()
+	c.placementLister = ksif.Kuadrant().V1().IngressPlacements().Lister()
+
+	// The Clusters may not be available, e.g., the CRD is not installed, in which case the Ingresses
+	// are placed on the clusters their backends are assigned to, and the informer would never sync.
+	if !isDiscoverable(client.Discovery(), clusterResource) {
+		klog.Infof("%s are not available, the IngressPlacements are ignored", clusterResource)
+		return c
+	}
+
+	dsif := dynamicinformer.NewDynamicSharedInformerFactory(dynamic.NewForConfigOrDie(config.Cfg), resyncPeriod)
+
+	// Watch for events related to Clusters, that may change the clusters the Ingresses are placed on
+	dsif.ForResource(clusterResource).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(_ interface{}) { c.placedIngresses() },
+		UpdateFunc: func(old, obj interface{}) {
+			oldLabels, newLabels := old.(metav1.Object).GetLabels(), obj.(metav1.Object).GetLabels()
+			if !equality.Semantic.DeepEqual(oldLabels, newLabels) {
+				c.placedIngresses()
+			}
+			// The DNS targets exposed by the cluster are tagged with its location
+			if oldLabels[c.clusterGeoLabel] != newLabels[c.clusterGeoLabel] {
+				c.ingressesFromCluster(obj.(metav1.Object).GetName())
+			}
+		},
+		DeleteFunc: func(_ interface{}) { c.placedIngresses() },
+	})
+
+	dsif.Start(stopCh)
+	for inf, sync := range dsif.WaitForCacheSync(stopCh) {
+		if !sync {
+			klog.Fatalf("Failed to sync %s", inf)
+		}
+	}
+
+	c.clusterLister = dynamiclister.New(dsif.ForResource(clusterResource).Informer().GetIndexer(), clusterResource)
 
 	return c
 }
 
 type ControllerConfig struct {
-	Cfg             *rest.Config
-	EnvoyXDS        *envoyserver.XdsServer
-	Domain          *string
-	EnvoyListenPort *uint
+	Cfg                  *rest.Config
+	EnvoyXDS             *envoyserver.XdsServer
+	Domain               *string
+	EnvoyListenPort      *uint
+	ClusterGeoLabel      *string
+	ClusterGeo           *string
+	HostnameTemplate     *string
+	IngressClass         *string
+	DefaultIngressClass  *bool
+	LeafIngressClass     *string
+	ACMECertificates     *bool
+	EnvoyDNSLookupFamily *string
+	Resolver             resolver.Resolver
+	TXTResolver          resolver.TXTResolver
+	ClaimChallengeKey    []byte
 }
 
 type Controller struct {
-	queue           workqueue.RateLimitingInterface
-	client          kubernetes.Interface
-	dnsRecordClient kuadrantv1.KuadrantV1Interface
-	stopCh          chan struct{}
-	indexer         cache.Indexer
-	lister          networkingv1lister.IngressLister
-	envoyXDS        *envoyserver.XdsServer
-	envoyListenPort *uint
-	cache           *envoy.Cache
-	domain          *string
-	tracker         Tracker
+	queue               workqueue.RateLimitingInterface
+	client              kubernetes.Interface
+	recorder            record.EventRecorder
+	dnsRecordClient     kuadrantv1.KuadrantV1Interface
+	dnsRecordLister     kuadrantv1lister.DNSRecordLister
+	domainClaimLister   kuadrantv1lister.DomainClaimLister
+	placementLister     kuadrantv1lister.IngressPlacementLister
+	clusterLister       dynamiclister.Lister
+	stopCh              chan struct{}
+	indexer             cache.Indexer
+	lister              networkingv1lister.IngressLister
+	serviceLister       corev1lister.ServiceLister
+	secretLister        corev1lister.SecretLister
+	envoyXDS            *envoyserver.XdsServer
+	envoyListenPort     *uint
+	cache               *envoy.Cache
+	domain              *string
+	hostnameTemplate    *template.Template
+	ingressClass        string
+	defaultIngressClass bool
+	leafIngressClass    string
+	acmeCertificates    bool
+	clusterGeoLabel     string
+	clusterGeo          map[string]string
+	resolver            *resolver.CachingResolver
+	claimVerifier       *claimVerifier
+	tracker             Tracker
 }
 
 func (c *Controller) enqueue(obj interface{}) {
-	key, err := cache.MetaNamespaceKeyFunc(obj)
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 	if err != nil {
 		runtime.HandleError(err)
 		return
@@ -173,23 +359,55 @@ func (c *Controller) process(key string) error {
 		return err
 	}
 
+	ctx := context.TODO()
+
 	if !exists {
 		klog.Infof("Object with key %q was deleted", key)
-		// If Envoy is enabled, delete the Ingress from the config cache.
-		if c.envoyXDS != nil {
-			// if EnvoyXDS is enabled, delete the Ingress from the cache and set the new snaphost.
-			c.cache.DeleteIngress(key)
-			c.envoyXDS.SetSnapshot(envoy.NodeID, c.cache.ToEnvoySnapshot())
+		// The root Ingresses are cleaned up before they are released, so that's a leaf, which
+		// DNSRecords of previous versions get deleted, or a root Ingress deleted before the
+		// finalizer was set.
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			return err
+		}
+		clusterName, name := clusters.SplitClusterAwareKey(name)
+		deleted := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, ClusterName: clusterName}}
+		return c.cleanup(ctx, key, deleted)
+	}
+	current := obj.(*networkingv1.Ingress).DeepCopy()
+
+	if current.Labels[clusterLabel] == "" && current.DeletionTimestamp != nil {
+		if !hasFinalizer(current) {
+			return nil
+		}
+		if err := c.cleanup(ctx, key, current); err != nil {
+			return err
+		}
+		return c.removeFinalizer(ctx, current)
+	}
+
+	// The Ingresses of other classes are left to their controllers, once the resources
+	// of the ones that have been switched to another class are deleted.
+	if !c.IsHandled(current) {
+		if !hasFinalizer(current) {
+			klog.Infof("Ignoring Ingress %q of another class", current.Name)
+			return nil
+		}
+		klog.Infof("Ingress %q has been switched to another class", current.Name)
+		if err := c.cleanup(ctx, key, current); err != nil {
+			return err
+		}
+		return c.removeFinalizer(ctx, current)
+	}
+
+	if current.Labels[clusterLabel] == "" && !hasFinalizer(current) {
+		if err := c.addFinalizer(ctx, current); err != nil {
+			return err
 		}
-		// The ingress has been deleted, so we remove any ingress to service tracking.
-		c.tracker.deleteIngress(key)
-		return nil
 	}
-	current := obj.(*networkingv1.Ingress)
 
 	previous := current.DeepCopy()
 
-	ctx := context.TODO()
 	if err := c.reconcile(ctx, current); err != nil {
 		return err
 	}
@@ -203,17 +421,142 @@ func (c *Controller) process(key string) error {
 	return err
 }
 
+// ingressDeleted enqueues the deleted Ingress, as well as its root Ingress if
+// it's a leaf, so that its load-balancers get withdrawn from the DNSRecords.
+func (c *Controller) ingressDeleted(obj interface{}) {
+	c.enqueue(obj)
+
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	leaf, ok := obj.(*networkingv1.Ingress)
+	if !ok || leaf.Labels[ownedByLabel] == "" {
+		return
+	}
+	c.enqueue(&networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   leaf.Namespace,
+			Name:        leaf.Labels[ownedByLabel],
+			ClusterName: leaf.GetClusterName(),
+		},
+	})
+}
+
+// ingressesInNamespace enqueues the Ingresses in the namespace of the object,
+// e.g., a DomainClaim, which hosts may be under the claimed domain, or an
+// IngressPlacement, which may select them.
+func (c *Controller) ingressesInNamespace(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	object, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+
+	ingresses, err := c.lister.Ingresses(object.GetNamespace()).List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, ingress := range ingresses {
+		if ingress.ClusterName == object.GetClusterName() {
+			c.enqueue(ingress)
+		}
+	}
+}
+
+// ingressesFromDNSRecord enqueues the root Ingress controlling the DNSRecord,
+// as well as its leaves.
+func (c *Controller) ingressesFromDNSRecord(record *v1.DNSRecord) {
+	ref := metav1.GetControllerOf(record)
+	if ref == nil || ref.Kind != "Ingress" {
+		return
+	}
+
+	c.enqueue(&networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   record.Namespace,
+			Name:        ref.Name,
+			ClusterName: record.ClusterName,
+		},
+	})
+	leaves, err := c.lister.Ingresses(record.Namespace).List(labels.SelectorFromSet(labels.Set{ownedByLabel: ref.Name}))
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, leaf := range leaves {
+		if leaf.ClusterName == record.ClusterName {
+			c.enqueue(leaf)
+		}
+	}
+}
+
+// ingressesFromCluster enqueues the root Ingresses with a leaf on the cluster.
+func (c *Controller) ingressesFromCluster(cluster string) {
+	leaves, err := c.lister.List(labels.SelectorFromSet(labels.Set{clusterLabel: cluster}))
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, leaf := range leaves {
+		c.enqueue(&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   leaf.Namespace,
+				Name:        leaf.Labels[ownedByLabel],
+				ClusterName: leaf.ClusterName,
+			},
+		})
+	}
+}
+
 // ingressesFromService enqueues all the related ingresses for a given service.
 func (c *Controller) ingressesFromService(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	service, ok := obj.(*corev1.Service)
+	if !ok {
+		return
+	}
+
 	// Does that Service has any Ingress associated to?
-	ingresses, ok := c.tracker.getIngress(obj.(*corev1.Service))
+	ingresses, ok := c.tracker.getIngress(service)
 	if ok {
 		// One Service can be referenced by 0..n Ingresses, so we need to enqueue all the related ingreses.
 		for _, ingress := range ingresses {
-			klog.Infof("tracked service %q triggered Ingress %q reconciliation", obj.(*corev1.Service).Name, ingress.Name)
+			klog.Infof("tracked service %q triggered Ingress %q reconciliation", service.Name, ingress.Name)
 			c.enqueue(ingress.DeepCopy())
 		}
 	} else {
-		klog.Info("Ignoring non-tracked service: ", obj.(*corev1.Service).Name)
+		klog.Info("Ignoring non-tracked service: ", service.Name)
+	}
+}
+
+// ingressesFromHostname enqueues the leaf ingresses which load-balancer reports
+// the given hostname, which addresses have changed.
+func (c *Controller) ingressesFromHostname(hostname string) {
+	ingresses, err := c.lister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+
+	found := false
+	for _, ingress := range ingresses {
+		for _, lbs := range ingress.Status.LoadBalancer.Ingress {
+			if lbs.Hostname == hostname {
+				klog.Infof("addresses of %q triggered Ingress %q reconciliation", hostname, ingress.Name)
+				c.enqueue(ingress)
+				found = true
+				break
+			}
+		}
+	}
+
+	// Stop refreshing the hostname once no leaf reports it anymore
+	if !found {
+		c.resolver.Forget(hostname)
 	}
 }
@@ -0,0 +1,135 @@
+package ingress
+
+import (
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns"
+)
+
+// failoverAnnotation enables the active/passive failover mode of the root
+// Ingress, and holds the clusters in order of priority, e.g.,
+// "kcp-cluster-a,kcp-cluster-b". Only the active cluster, i.e., the first one
+// which leaf is admitted and healthy, takes traffic, while the other ones stand
+// by.
+const failoverAnnotation = "kuadrant.dev/failover"
+
+// activeCluster returns the active cluster of the root Ingress in failover
+// mode, i.e., the first cluster, in order of priority, which leaf has been
+// admitted, and which targets are not all unhealthy, as reported in the status
+// of the current DNSRecords. The clusters that are not listed in the failover
+// annotation come last, by name. If no cluster is healthy, the first admitted
+// one is active, so that the host keeps resolving. It returns false if the
+// root Ingress is not in failover mode.
+func (c *Controller) activeCluster(root *networkingv1.Ingress, leaves []*networkingv1.Ingress) (string, bool, error) {
+	value, ok := root.Annotations[failoverAnnotation]
+	if !ok {
+		return "", false, nil
+	}
+
+	priority := map[string]int{}
+	for i, cluster := range strings.Split(value, ",") {
+		if cluster = strings.TrimSpace(cluster); cluster != "" {
+			if _, ok := priority[cluster]; !ok {
+				priority[cluster] = i
+			}
+		}
+	}
+
+	var admitted []string
+	for _, leaf := range leaves {
+		if leaf.DeletionTimestamp == nil && len(leaf.Status.LoadBalancer.Ingress) > 0 {
+			admitted = append(admitted, leaf.Labels[clusterLabel])
+		}
+	}
+	if len(admitted) == 0 {
+		return "", true, nil
+	}
+	sort.Slice(admitted, func(i, j int) bool {
+		pi, oki := priority[admitted[i]]
+		pj, okj := priority[admitted[j]]
+		if oki != okj {
+			return oki
+		}
+		if oki && pi != pj {
+			return pi < pj
+		}
+		return admitted[i] < admitted[j]
+	})
+
+	unhealthy, err := c.unhealthyClusters(root)
+	if err != nil {
+		return "", true, err
+	}
+	for _, cluster := range admitted {
+		if !unhealthy[cluster] {
+			return cluster, true, nil
+		}
+	}
+	return admitted[0], true, nil
+}
+
+// unhealthyClusters returns the clusters which targets, in the DNSRecords of
+// the root Ingress, are all unhealthy.
+func (c *Controller) unhealthyClusters(root *networkingv1.Ingress) (map[string]bool, error) {
+	records, err := c.dnsRecordLister.DNSRecords(root.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	unhealthy := map[string]bool{}
+	healthy := map[string]bool{}
+	for _, record := range records {
+		if !isControlledBy(record, root) {
+			continue
+		}
+		for _, target := range dns.Targets(record) {
+			if target.Cluster == "" {
+				continue
+			}
+			if target.Healthy {
+				healthy[target.Cluster] = true
+			} else {
+				unhealthy[target.Cluster] = true
+			}
+		}
+	}
+	for cluster := range healthy {
+		delete(unhealthy, cluster)
+	}
+	return unhealthy, nil
+}
+
+// failoverWeights returns the weights of the clusters, given the active one,
+// i.e., the other clusters are drained, so that their targets are still health
+// checked, while not being published.
+func failoverWeights(weights map[string]string, active string, leaves []*networkingv1.Ingress) map[string]string {
+	failover := make(map[string]string, len(weights))
+	for cluster, weight := range weights {
+		failover[cluster] = weight
+	}
+	for _, leaf := range leaves {
+		if cluster := leaf.Labels[clusterLabel]; cluster != active {
+			failover[cluster] = "0"
+		}
+	}
+	return failover
+}
+
+// isHealthChanged returns whether the health of the DNSRecord targets has
+// changed, which may switch the active cluster of its root Ingress.
+func isHealthChanged(old, new *v1.DNSRecord) bool {
+	if len(old.Status.Targets) != len(new.Status.Targets) {
+		return true
+	}
+	for i := range old.Status.Targets {
+		if old.Status.Targets[i].Target != new.Status.Targets[i].Target || old.Status.Targets[i].Healthy != new.Status.Targets[i].Healthy {
+			return true
+		}
+	}
+	return false
+}
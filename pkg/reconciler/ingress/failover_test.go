@@ -0,0 +1,177 @@
+package ingress
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+func TestActiveCluster(t *testing.T) {
+	leaf := func(cluster string, admitted bool) *networkingv1.Ingress {
+		l := newRootIngress("workspace", "default", "app--"+cluster, nil)
+		l.Labels = map[string]string{clusterLabel: cluster, ownedByLabel: "app"}
+		if admitted {
+			l.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+		}
+		return l
+	}
+	// dnsRecord returns the record of the root Ingress, with a target per
+	// cluster, which health is given.
+	dnsRecord := func(health map[string]bool) *v1.DNSRecord {
+		record := &v1.DNSRecord{
+			ObjectMeta: metav1.ObjectMeta{
+				ClusterName:     "workspace",
+				Namespace:       "default",
+				Name:            "app",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Ingress", Name: "app", Controller: pointer.Bool(true)}},
+			},
+			Spec: v1.DNSRecordSpec{HealthCheck: &v1.DNSHealthCheck{Protocol: v1.HealthCheckProtocolTCP}},
+		}
+		for cluster, healthy := range health {
+			target := "target-" + cluster
+			record.Spec.Targets = append(record.Spec.Targets, target)
+			record.Spec.TargetAttributes = append(record.Spec.TargetAttributes, v1.DNSTargetAttributes{Target: target, Cluster: cluster})
+			record.Status.Targets = append(record.Status.Targets, v1.DNSTargetStatus{Target: target, Healthy: healthy})
+		}
+		return record
+	}
+
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		leaves       []*networkingv1.Ingress
+		records      []*v1.DNSRecord
+		wantActive   string
+		wantFailover bool
+	}{
+		{
+			name:   "not in failover mode",
+			leaves: []*networkingv1.Ingress{leaf("cluster-a", true)},
+		},
+		{
+			name:         "first cluster in order of priority",
+			annotations:  map[string]string{failoverAnnotation: "cluster-b, cluster-a"},
+			leaves:       []*networkingv1.Ingress{leaf("cluster-a", true), leaf("cluster-b", true)},
+			wantActive:   "cluster-b",
+			wantFailover: true,
+		},
+		{
+			name:         "first cluster not admitted",
+			annotations:  map[string]string{failoverAnnotation: "cluster-b,cluster-a"},
+			leaves:       []*networkingv1.Ingress{leaf("cluster-a", true), leaf("cluster-b", false)},
+			wantActive:   "cluster-a",
+			wantFailover: true,
+		},
+		{
+			name:        "first cluster being deleted",
+			annotations: map[string]string{failoverAnnotation: "cluster-b,cluster-a"},
+			leaves: []*networkingv1.Ingress{leaf("cluster-a", true), func() *networkingv1.Ingress {
+				l := leaf("cluster-b", true)
+				now := metav1.Now()
+				l.DeletionTimestamp = &now
+				return l
+			}()},
+			wantActive:   "cluster-a",
+			wantFailover: true,
+		},
+		{
+			name:         "first cluster unhealthy",
+			annotations:  map[string]string{failoverAnnotation: "cluster-b,cluster-a"},
+			leaves:       []*networkingv1.Ingress{leaf("cluster-a", true), leaf("cluster-b", true)},
+			records:      []*v1.DNSRecord{dnsRecord(map[string]bool{"cluster-a": true, "cluster-b": false})},
+			wantActive:   "cluster-a",
+			wantFailover: true,
+		},
+		{
+			name:        "first cluster healthy in one of the records",
+			annotations: map[string]string{failoverAnnotation: "cluster-b,cluster-a"},
+			leaves:      []*networkingv1.Ingress{leaf("cluster-a", true), leaf("cluster-b", true)},
+			records: []*v1.DNSRecord{
+				dnsRecord(map[string]bool{"cluster-b": false}),
+				func() *v1.DNSRecord {
+					record := dnsRecord(map[string]bool{"cluster-b": true})
+					record.Name = "app-aaaa"
+					return record
+				}(),
+			},
+			wantActive:   "cluster-b",
+			wantFailover: true,
+		},
+		{
+			name:        "first cluster unhealthy in the records of another Ingress",
+			annotations: map[string]string{failoverAnnotation: "cluster-b,cluster-a"},
+			leaves:      []*networkingv1.Ingress{leaf("cluster-a", true), leaf("cluster-b", true)},
+			records: []*v1.DNSRecord{func() *v1.DNSRecord {
+				record := dnsRecord(map[string]bool{"cluster-b": false})
+				record.OwnerReferences[0].Name = "other"
+				return record
+			}()},
+			wantActive:   "cluster-b",
+			wantFailover: true,
+		},
+		{
+			name:         "all clusters unhealthy",
+			annotations:  map[string]string{failoverAnnotation: "cluster-b,cluster-a"},
+			leaves:       []*networkingv1.Ingress{leaf("cluster-a", true), leaf("cluster-b", true)},
+			records:      []*v1.DNSRecord{dnsRecord(map[string]bool{"cluster-a": false, "cluster-b": false})},
+			wantActive:   "cluster-b",
+			wantFailover: true,
+		},
+		{
+			name:         "clusters not listed, by name",
+			annotations:  map[string]string{failoverAnnotation: "cluster-c"},
+			leaves:       []*networkingv1.Ingress{leaf("cluster-b", true), leaf("cluster-a", true), leaf("cluster-c", false)},
+			wantActive:   "cluster-a",
+			wantFailover: true,
+		},
+		{
+			name:         "no admitted cluster",
+			annotations:  map[string]string{failoverAnnotation: "cluster-a"},
+			leaves:       []*networkingv1.Ingress{leaf("cluster-a", false)},
+			wantFailover: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, _ := newTestController(t, nil, nil, tt.records)
+			root := newRootIngress("workspace", "default", "app", tt.annotations)
+
+			active, failover, err := c.activeCluster(root, tt.leaves)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if active != tt.wantActive || failover != tt.wantFailover {
+				t.Errorf("activeCluster() = %q, %v, want %q, %v", active, failover, tt.wantActive, tt.wantFailover)
+			}
+		})
+	}
+}
+
+func TestFailoverWeights(t *testing.T) {
+	leaves := []*networkingv1.Ingress{
+		{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{clusterLabel: "cluster-a"}}},
+		{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{clusterLabel: "cluster-b"}}},
+	}
+	weights := map[string]string{"cluster-a": "90", "cluster-b": "10", "cluster-c": "50"}
+
+	got := failoverWeights(weights, "cluster-a", leaves)
+	want := map[string]string{"cluster-a": "90", "cluster-b": "0", "cluster-c": "50"}
+	if len(got) != len(want) {
+		t.Fatalf("failoverWeights() = %v, want %v", got, want)
+	}
+	for cluster, weight := range want {
+		if got[cluster] != weight {
+			t.Errorf("failoverWeights() = %v, want %v", got, want)
+		}
+	}
+	// The weights of the root Ingress are left untouched
+	if weights["cluster-b"] != "10" {
+		t.Errorf("weights = %v, want them unchanged", weights)
+	}
+}
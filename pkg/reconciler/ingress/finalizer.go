@@ -0,0 +1,94 @@
+package ingress
+
+import (
+	"context"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+
+	"github.com/kuadrant/kcp-ingress/pkg/envoy"
+)
+
+// finalizer is set on the root Ingresses, so that their leaves, the copies of
+// their TLS Secrets and their DNSRecords are deleted along with them, as kcp
+// doesn't cascade the deletion to the owned resources.
+const finalizer = "kuadrant.dev/ingress"
+
+func hasFinalizer(ingress *networkingv1.Ingress) bool {
+	for _, f := range ingress.Finalizers {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// addFinalizer sets the finalizer on the root Ingress, before any resource is
+// created for it.
+func (c *Controller) addFinalizer(ctx context.Context, ingress *networkingv1.Ingress) error {
+	ingress.Finalizers = append(ingress.Finalizers, finalizer)
+	updated, err := c.client.NetworkingV1().Ingresses(ingress.Namespace).Update(ctx, ingress, metav1.UpdateOptions{})
+	if err != nil {
+		return err
+	}
+	ingress.ResourceVersion = updated.ResourceVersion
+	return nil
+}
+
+// removeFinalizer releases the root Ingress, once its resources are deleted.
+func (c *Controller) removeFinalizer(ctx context.Context, ingress *networkingv1.Ingress) error {
+	finalizers := make([]string, 0, len(ingress.Finalizers))
+	for _, f := range ingress.Finalizers {
+		if f != finalizer {
+			finalizers = append(finalizers, f)
+		}
+	}
+	ingress.Finalizers = finalizers
+	_, err := c.client.NetworkingV1().Ingresses(ingress.Namespace).Update(ctx, ingress, metav1.UpdateOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// cleanup deletes the resources of the Ingress with the given key: its leaves
+// if it's a root Ingress, the copies of its TLS Secrets that no other leaf
+// references, the certificate obtained for its generated host, its DNSRecords,
+// and its Envoy virtual hosts.
+func (c *Controller) cleanup(ctx context.Context, key string, ingress *networkingv1.Ingress) error {
+	if c.envoyXDS != nil {
+		c.cache.DeleteIngress(key)
+		if err := c.envoyXDS.SetSnapshot(envoy.NodeID, c.cache.ToEnvoySnapshot()); err != nil {
+			return err
+		}
+	}
+	// Stop triggering the reconciliation of the Ingress when its Services change
+	c.tracker.deleteIngress(key)
+
+	leaves, err := c.lister.Ingresses(ingress.Namespace).List(labels.SelectorFromSet(labels.Set{ownedByLabel: ingress.Name}))
+	if err != nil {
+		return err
+	}
+	for _, leaf := range leaves {
+		if leaf.ClusterName != ingress.ClusterName || leaf.DeletionTimestamp != nil {
+			continue
+		}
+		klog.Infof("Deleting leaf %q of Ingress %q", leaf.Name, ingress.Name)
+		if err := c.client.NetworkingV1().Ingresses(leaf.Namespace).Delete(ctx, leaf.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if err := c.reconcileTLSSecrets(ctx, ingress, nil); err != nil {
+		return err
+	}
+	if err := c.deleteCertificate(ctx, ingress); err != nil {
+		return err
+	}
+
+	// The DNS controller withdraws the records from the zones before releasing them
+	return c.ensureDNSRecords(ctx, ingress, nil)
+}
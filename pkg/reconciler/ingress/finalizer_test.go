@@ -0,0 +1,184 @@
+package ingress
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	networkingv1lister "k8s.io/client-go/listers/networking/v1"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	kuadrantfake "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/clientset/versioned/fake"
+	kuadrantv1lister "github.com/kuadrant/kcp-ingress/pkg/client/kuadrant/listers/kuadrant/v1"
+)
+
+// newTestController returns a Controller which listers and clients hold the
+// given resources.
+func newTestController(t *testing.T, ingresses []*networkingv1.Ingress, secrets []*corev1.Secret, records []*v1.DNSRecord) (*Controller, *kubefake.Clientset, *kuadrantfake.Clientset) {
+	t.Helper()
+	var kubeObjects, kuadrantObjects []runtime.Object
+	ingressIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ingress := range ingresses {
+		if err := ingressIndexer.Add(ingress); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		kubeObjects = append(kubeObjects, ingress)
+	}
+	secretIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, secret := range secrets {
+		if err := secretIndexer.Add(secret); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		kubeObjects = append(kubeObjects, secret)
+	}
+	recordIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, record := range records {
+		if err := recordIndexer.Add(record); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		kuadrantObjects = append(kuadrantObjects, record)
+	}
+
+	client := kubefake.NewSimpleClientset(kubeObjects...)
+	kuadrantClient := kuadrantfake.NewSimpleClientset(kuadrantObjects...)
+	return &Controller{
+		client:          client,
+		dnsRecordClient: kuadrantClient.KuadrantV1(),
+		recorder:        record.NewFakeRecorder(10),
+		lister:          networkingv1lister.NewIngressLister(ingressIndexer),
+		secretLister:    corev1lister.NewSecretLister(secretIndexer),
+		dnsRecordLister: kuadrantv1lister.NewDNSRecordLister(recordIndexer),
+		tracker:         *NewTracker(),
+	}, client, kuadrantClient
+}
+
+// deleted returns the resources deleted with the clients, as resource/name.
+func deleted(actions ...[]clienttesting.Action) []string {
+	var names []string
+	for _, a := range actions {
+		for _, action := range a {
+			if d, ok := action.(clienttesting.DeleteAction); ok {
+				names = append(names, d.GetResource().Resource+"/"+d.GetName())
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCleanup(t *testing.T) {
+	now := metav1.Now()
+	root := newRootIngress("workspace", "default", "app", map[string]string{hostGeneratedAnnotation: "app." + testDomain})
+	root.UID = "uid"
+	root.DeletionTimestamp = &now
+	root.Finalizers = []string{finalizer}
+	root.Spec.TLS = []networkingv1.IngressTLS{{SecretName: "tls"}}
+
+	leaf := func(workspace, owner, cluster string) *networkingv1.Ingress {
+		l := newRootIngress(workspace, "default", owner+"--"+cluster, nil)
+		l.Labels = map[string]string{clusterLabel: cluster, ownedByLabel: owner}
+		l.Spec.TLS = []networkingv1.IngressTLS{{SecretName: leafSecretName("tls", cluster)}}
+		return l
+	}
+	secret := func(name, source string, owners ...metav1.OwnerReference) *corev1.Secret {
+		s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{ClusterName: "workspace", Namespace: "default", Name: name, OwnerReferences: owners}}
+		if source != "" {
+			s.Labels = map[string]string{tlsSecretLabel: source}
+		}
+		return s
+	}
+	dnsRecord := func(name, owner string) *v1.DNSRecord {
+		return &v1.DNSRecord{
+			ObjectMeta: metav1.ObjectMeta{
+				ClusterName:     "workspace",
+				Namespace:       "default",
+				Name:            name,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Ingress", Name: owner, Controller: pointer.Bool(true)}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		acmeCertificates bool
+		ingresses        []*networkingv1.Ingress
+		secrets          []*corev1.Secret
+		records          []*v1.DNSRecord
+		wantDeleted      []string
+	}{
+		{
+			name:      "leaves, copies of the TLS Secrets and DNSRecords",
+			ingresses: []*networkingv1.Ingress{leaf("workspace", "app", "cluster-1"), leaf("workspace", "app", "cluster-2")},
+			secrets:   []*corev1.Secret{secret("tls", ""), secret("tls--cluster-1", "tls"), secret("tls--cluster-2", "tls")},
+			records:   []*v1.DNSRecord{dnsRecord("app", "app"), dnsRecord("app-aaaa", "app")},
+			wantDeleted: []string{
+				"dnsrecords/app", "dnsrecords/app-aaaa",
+				"ingresses/app--cluster-1", "ingresses/app--cluster-2",
+				"secrets/tls--cluster-1", "secrets/tls--cluster-2",
+			},
+		},
+		{
+			name: "leaf being deleted",
+			ingresses: []*networkingv1.Ingress{func() *networkingv1.Ingress {
+				l := leaf("workspace", "app", "cluster-1")
+				l.DeletionTimestamp = &now
+				return l
+			}()},
+		},
+		{
+			name:      "resources of other Ingresses",
+			ingresses: []*networkingv1.Ingress{leaf("other", "app", "cluster-1"), leaf("workspace", "other", "cluster-2")},
+			// The copy referenced by the leaf of the other Ingress is kept
+			secrets: []*corev1.Secret{secret("tls", ""), secret("tls--cluster-2", "tls")},
+			records: []*v1.DNSRecord{dnsRecord("other", "other")},
+		},
+		{
+			name:             "certificate",
+			acmeCertificates: true,
+			secrets: []*corev1.Secret{
+				secret("app-acme-tls", "", metav1.OwnerReference{Kind: "Ingress", Name: "app", UID: "uid"}),
+				secret("app-acme-tls--cluster-1", "app-acme-tls"),
+			},
+			wantDeleted: []string{"secrets/app-acme-tls", "secrets/app-acme-tls--cluster-1"},
+		},
+		{
+			name:             "certificate of another Ingress with the same name",
+			acmeCertificates: true,
+			secrets: []*corev1.Secret{
+				secret("app-acme-tls", "", metav1.OwnerReference{Kind: "Ingress", Name: "app", UID: "other"}),
+				secret("app-acme-tls--cluster-1", "app-acme-tls"),
+			},
+			wantDeleted: []string{"secrets/app-acme-tls--cluster-1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, client, kuadrantClient := newTestController(t, append(tt.ingresses, root), tt.secrets, tt.records)
+			c.acmeCertificates = tt.acmeCertificates
+			key, err := cache.MetaNamespaceKeyFunc(root)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if err := c.cleanup(context.Background(), key, root); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := deleted(client.Actions(), kuadrantClient.Actions())
+			if !equalStrings(got, tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", got, tt.wantDeleted)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package ingress
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DefaultHostnameTemplate is the template of the hosts generated for the root
+// Ingresses, when none is configured.
+const DefaultHostnameTemplate = "{{.Name}}-{{.Namespace}}-{{.Workspace}}.{{.Domain}}"
+
+// hostnameData is the data the hostname template is rendered with.
+type hostnameData struct {
+	// Name is the name of the root Ingress.
+	Name string
+	// Namespace is the namespace of the root Ingress.
+	Namespace string
+	// Workspace is the kcp logical cluster of the root Ingress.
+	Workspace string
+	// Domain is the domain the hosts are generated under.
+	Domain string
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// generateHost returns the host to generate for the root Ingress, i.e., the
+// preferred prefix under the domain if it's requested, or the hostname
+// template rendered for the Ingress otherwise. If the host is already taken
+// by another root Ingress, it's disambiguated with the hash of the Ingress,
+// so that the generated host is stable.
+func (c *Controller) generateHost(root *networkingv1.Ingress) (string, error) {
+	var host string
+	if prefix := root.Annotations[hostPrefixAnnotation]; prefix != "" {
+		host = prefix + "." + *c.domain
+	} else {
+		var b bytes.Buffer
+		err := c.hostnameTemplate.Execute(&b, hostnameData{
+			Name:      root.Name,
+			Namespace: root.Namespace,
+			Workspace: root.ClusterName,
+			Domain:    *c.domain,
+		})
+		if err != nil {
+			return "", err
+		}
+		host = b.String()
+	}
+
+	host = sanitizeHost(host)
+	if host == normalizeHost(*c.domain) || !isSubdomain(host, *c.domain) {
+		return "", fmt.Errorf("generated host %q is not under domain %q", host, *c.domain)
+	}
+
+	taken, err := c.takenHosts(root)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := taken[host]; !ok {
+		return host, nil
+	}
+
+	labels := strings.SplitN(host, ".", 2)
+	suffix := "-" + hashString(root.Namespace+"/"+root.Name+"/"+root.ClusterName)
+	if len(labels[0])+len(suffix) > 63 {
+		labels[0] = strings.TrimRight(labels[0][:63-len(suffix)], "-")
+	}
+	disambiguated := labels[0] + suffix + "." + labels[1]
+	if name, ok := taken[disambiguated]; ok {
+		return "", fmt.Errorf("generated host %q is already taken by Ingress %q", disambiguated, name)
+	}
+	return disambiguated, nil
+}
+
+// takenHosts returns the hosts under the domain that are taken by the root
+// Ingresses other than the given one, i.e., their generated hosts and the
+// hosts of their rules, along with the name of the Ingress.
+func (c *Controller) takenHosts(root *networkingv1.Ingress) (map[string]string, error) {
+	ingresses, err := c.lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := map[string]string{}
+	for _, ingress := range ingresses {
+		if ingress.Labels[clusterLabel] != "" || isSameIngress(ingress, root) {
+			continue
+		}
+		if generated := ingress.Annotations[hostGeneratedAnnotation]; generated != "" {
+			hosts[normalizeHost(generated)] = ingress.Name
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host != "" && isSubdomain(rule.Host, *c.domain) {
+				hosts[normalizeHost(rule.Host)] = ingress.Name
+			}
+		}
+	}
+	return hosts, nil
+}
+
+// generatedHostCollides returns whether the host generated for the root
+// Ingress has also been generated for another root Ingress, created before,
+// e.g., when both have been reconciled concurrently. The older Ingress keeps
+// the host, and a new one has to be generated for the other one.
+func (c *Controller) generatedHostCollides(root *networkingv1.Ingress) (bool, error) {
+	host := normalizeHost(root.Annotations[hostGeneratedAnnotation])
+
+	ingresses, err := c.lister.List(labels.Everything())
+	if err != nil {
+		return false, err
+	}
+	for _, ingress := range ingresses {
+		if ingress.Labels[clusterLabel] != "" || isSameIngress(ingress, root) {
+			continue
+		}
+		if normalizeHost(ingress.Annotations[hostGeneratedAnnotation]) != host {
+			continue
+		}
+		if ingress.CreationTimestamp.Before(&root.CreationTimestamp) ||
+			ingress.CreationTimestamp.Equal(&root.CreationTimestamp) && ingress.UID < root.UID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func isSameIngress(a, b *networkingv1.Ingress) bool {
+	return a.Namespace == b.Namespace && a.Name == b.Name && a.ClusterName == b.ClusterName
+}
+
+// sanitizeHost lower-cases the host, and replaces the characters that are not
+// valid in DNS labels with dashes.
+func sanitizeHost(host string) string {
+	labels := strings.Split(normalizeHost(host), ".")
+	sanitized := make([]string, 0, len(labels))
+	for _, label := range labels {
+		label = strings.Trim(invalidLabelChars.ReplaceAllString(label, "-"), "-")
+		if len(label) > 63 {
+			label = strings.TrimRight(label[:63], "-")
+		}
+		if label != "" {
+			sanitized = append(sanitized, label)
+		}
+	}
+	return strings.Join(sanitized, ".")
+}
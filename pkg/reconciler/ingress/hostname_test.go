@@ -0,0 +1,286 @@
+package ingress
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	networkingv1lister "k8s.io/client-go/listers/networking/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const testDomain = "kcp-apps.example.com"
+
+// newHostController returns a Controller generating the hosts with the
+// template, given the existing Ingresses.
+func newHostController(t *testing.T, hostnameTemplate string, ingresses ...*networkingv1.Ingress) *Controller {
+	t.Helper()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ingress := range ingresses {
+		if err := indexer.Add(ingress); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	tmpl, err := template.New("hostname").Option("missingkey=error").Parse(hostnameTemplate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	domain := testDomain
+	return &Controller{lister: networkingv1lister.NewIngressLister(indexer), hostnameTemplate: tmpl, domain: &domain}
+}
+
+func newRootIngress(workspace, namespace, name string, annotations map[string]string) *networkingv1.Ingress {
+	return &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{ClusterName: workspace, Namespace: namespace, Name: name, Annotations: annotations},
+	}
+}
+
+func TestGenerateHost(t *testing.T) {
+	longName := strings.Repeat("a", 70)
+	// The host the root Ingress named app would be disambiguated with
+	suffix := "-" + hashString("default/app/workspace")
+
+	tests := []struct {
+		name      string
+		template  string
+		ingresses []*networkingv1.Ingress
+		root      *networkingv1.Ingress
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:     "default template",
+			template: DefaultHostnameTemplate,
+			root:     newRootIngress("workspace", "default", "app", nil),
+			want:     "app-default-workspace." + testDomain,
+		},
+		{
+			name:     "custom template",
+			template: "{{.Name}}.{{.Workspace}}.{{.Domain}}",
+			root:     newRootIngress("workspace", "default", "app", nil),
+			want:     "app.workspace." + testDomain,
+		},
+		{
+			name:     "template rendered with invalid characters",
+			template: DefaultHostnameTemplate,
+			root:     newRootIngress("root:org_a", "default", "App", nil),
+			want:     "app-default-root-org-a." + testDomain,
+		},
+		{
+			name:     "template rendered with a label over 63 characters",
+			template: "{{.Name}}.{{.Domain}}",
+			root:     newRootIngress("workspace", "default", longName, nil),
+			want:     strings.Repeat("a", 63) + "." + testDomain,
+		},
+		{
+			name:     "template rendered outside of the domain",
+			template: "{{.Name}}.example.org",
+			root:     newRootIngress("workspace", "default", "app", nil),
+			wantErr:  true,
+		},
+		{
+			name:     "template rendered as the domain",
+			template: "{{.Domain}}",
+			root:     newRootIngress("workspace", "default", "app", nil),
+			wantErr:  true,
+		},
+		{
+			name:     "template with an unknown field",
+			template: "{{.Cluster}}.{{.Domain}}",
+			root:     newRootIngress("workspace", "default", "app", nil),
+			wantErr:  true,
+		},
+		{
+			name:     "preferred prefix",
+			template: DefaultHostnameTemplate,
+			root:     newRootIngress("workspace", "default", "app", map[string]string{hostPrefixAnnotation: "Shop"}),
+			want:     "shop." + testDomain,
+		},
+		{
+			name:     "host generated for another Ingress",
+			template: "{{.Name}}.{{.Domain}}",
+			ingresses: []*networkingv1.Ingress{
+				newRootIngress("other", "default", "app", map[string]string{hostGeneratedAnnotation: "app." + testDomain}),
+			},
+			root: newRootIngress("workspace", "default", "app", nil),
+			want: "app" + suffix + "." + testDomain,
+		},
+		{
+			name:     "host of the rules of another Ingress",
+			template: "{{.Name}}.{{.Domain}}",
+			ingresses: []*networkingv1.Ingress{
+				func() *networkingv1.Ingress {
+					ingress := newRootIngress("other", "default", "other", nil)
+					ingress.Spec.Rules = []networkingv1.IngressRule{{Host: "App." + testDomain}}
+					return ingress
+				}(),
+			},
+			root: newRootIngress("workspace", "default", "app", nil),
+			want: "app" + suffix + "." + testDomain,
+		},
+		{
+			name:     "host generated for a leaf",
+			template: "{{.Name}}.{{.Domain}}",
+			ingresses: []*networkingv1.Ingress{
+				func() *networkingv1.Ingress {
+					leaf := newRootIngress("workspace", "default", "app--cluster-1", map[string]string{hostGeneratedAnnotation: "app." + testDomain})
+					leaf.Labels = map[string]string{clusterLabel: "cluster-1", ownedByLabel: "app"}
+					return leaf
+				}(),
+			},
+			root: newRootIngress("workspace", "default", "app", nil),
+			want: "app." + testDomain,
+		},
+		{
+			name:     "host taken, with a label over 63 characters once disambiguated",
+			template: "{{.Name}}.{{.Domain}}",
+			ingresses: []*networkingv1.Ingress{
+				newRootIngress("other", "default", longName, map[string]string{hostGeneratedAnnotation: strings.Repeat("a", 63) + "." + testDomain}),
+			},
+			root: newRootIngress("workspace", "default", longName, nil),
+			want: strings.Repeat("a", 63-len("-"+hashString("default/"+longName+"/workspace"))) + "-" + hashString("default/"+longName+"/workspace") + "." + testDomain,
+		},
+		{
+			name:     "disambiguated host taken",
+			template: "{{.Name}}.{{.Domain}}",
+			ingresses: []*networkingv1.Ingress{
+				newRootIngress("other", "default", "app", map[string]string{hostGeneratedAnnotation: "app." + testDomain}),
+				newRootIngress("another", "default", "app", map[string]string{hostGeneratedAnnotation: "app" + suffix + "." + testDomain}),
+			},
+			root:    newRootIngress("workspace", "default", "app", nil),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newHostController(t, tt.template, tt.ingresses...)
+
+			got, err := c.generateHost(tt.root)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("generateHost() = %q, want %q", got, tt.want)
+			}
+			for _, label := range strings.Split(got, ".") {
+				if len(label) > 63 {
+					t.Errorf("label %q of %q is over 63 characters", label, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratedHostCollides(t *testing.T) {
+	created := metav1.NewTime(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	ingress := func(workspace string, creation metav1.Time, uid types.UID, host string) *networkingv1.Ingress {
+		i := newRootIngress(workspace, "default", "app", map[string]string{hostGeneratedAnnotation: host})
+		i.CreationTimestamp = creation
+		i.UID = uid
+		return i
+	}
+	root := ingress("workspace", created, "b", "app."+testDomain)
+
+	tests := []struct {
+		name      string
+		ingresses []*networkingv1.Ingress
+		want      bool
+	}{
+		{
+			name: "no other Ingress",
+		},
+		{
+			name:      "same host generated for an older Ingress",
+			ingresses: []*networkingv1.Ingress{ingress("other", metav1.NewTime(created.Add(-time.Minute)), "c", "App."+testDomain+".")},
+			want:      true,
+		},
+		{
+			name:      "same host generated for a newer Ingress",
+			ingresses: []*networkingv1.Ingress{ingress("other", metav1.NewTime(created.Add(time.Minute)), "a", "app."+testDomain)},
+		},
+		{
+			name:      "same host generated for an Ingress created at the same time, with a lower UID",
+			ingresses: []*networkingv1.Ingress{ingress("other", created, "a", "app."+testDomain)},
+			want:      true,
+		},
+		{
+			name:      "same host generated for an Ingress created at the same time, with a higher UID",
+			ingresses: []*networkingv1.Ingress{ingress("other", created, "c", "app."+testDomain)},
+		},
+		{
+			name:      "another host generated for an older Ingress",
+			ingresses: []*networkingv1.Ingress{ingress("other", metav1.NewTime(created.Add(-time.Minute)), "c", "other."+testDomain)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newHostController(t, DefaultHostnameTemplate, append(tt.ingresses, root)...)
+
+			got, err := c.generatedHostCollides(root)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("generatedHostCollides() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeHost(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		want string
+	}{
+		{
+			name: "valid host",
+			host: "app.kcp-apps.example.com",
+			want: "app.kcp-apps.example.com",
+		},
+		{
+			name: "upper case, fully qualified",
+			host: "App.KCP-apps.example.com.",
+			want: "app.kcp-apps.example.com",
+		},
+		{
+			name: "invalid characters",
+			host: "app_1-root:org.kcp-apps.example.com",
+			want: "app-1-root-org.kcp-apps.example.com",
+		},
+		{
+			name: "leading and trailing dashes",
+			host: "-app:.kcp-apps.example.com",
+			want: "app.kcp-apps.example.com",
+		},
+		{
+			name: "empty labels",
+			host: "app..__.kcp-apps.example.com",
+			want: "app.kcp-apps.example.com",
+		},
+		{
+			name: "label over 63 characters",
+			host: strings.Repeat("a", 64) + ".kcp-apps.example.com",
+			want: strings.Repeat("a", 63) + ".kcp-apps.example.com",
+		},
+		{
+			name: "label over 63 characters, truncated before a dash",
+			host: strings.Repeat("a", 62) + "-b.kcp-apps.example.com",
+			want: strings.Repeat("a", 62) + ".kcp-apps.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeHost(tt.host); got != tt.want {
+				t.Errorf("sanitizeHost(%q) = %q, want %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
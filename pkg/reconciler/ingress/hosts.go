@@ -0,0 +1,124 @@
+package ingress
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+// verifiedHosts returns the hosts the root Ingress can be exposed with, i.e.,
+// its generated host, and the hosts of its rules that are under a verified
+// domain claimed in its namespace. The hosts under the domain, other than the
+// generated one, are not exposed, as they cannot be claimed across workspaces.
+// The other hosts are reported in the root Ingress status annotation, along
+// with the reason why they are not exposed, the DomainClaims reporting whether
+// they are verified.
+func (c *Controller) verifiedHosts(root *networkingv1.Ingress) ([]string, error) {
+	hosts, _, err := c.hosts(root)
+	return hosts, err
+}
+
+// unverifiedHost is a host of a root Ingress that is not exposed.
+type unverifiedHost struct {
+	host   string
+	reason error
+}
+
+// hosts returns the verified hosts of the root Ingress, as well as the
+// unverified ones, sorted by host.
+func (c *Controller) hosts(root *networkingv1.Ingress) ([]string, []unverifiedHost, error) {
+	verified := map[string]struct{}{}
+	if generated := root.Annotations[hostGeneratedAnnotation]; generated != "" {
+		verified[normalizeHost(generated)] = struct{}{}
+	}
+
+	claims, err := c.domainClaimLister.DomainClaims(root.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var unverified []unverifiedHost
+	rejected := map[string]struct{}{}
+	for _, rule := range root.Spec.Rules {
+		host := normalizeHost(rule.Host)
+		if _, ok := verified[host]; ok || host == "" {
+			continue
+		}
+		if _, ok := rejected[host]; ok {
+			continue
+		}
+		if err := verifyHost(host, *c.domain, claims, root.ClusterName, c.claimVerifier.isVerified); err != nil {
+			klog.Infof("not exposing host %q of Ingress %q: %v", rule.Host, root.Name, err)
+			unverified = append(unverified, unverifiedHost{host: host, reason: err})
+			rejected[host] = struct{}{}
+			continue
+		}
+		verified[host] = struct{}{}
+	}
+
+	hosts := make([]string, 0, len(verified))
+	for host := range verified {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	sort.Slice(unverified, func(i, j int) bool { return unverified[i].host < unverified[j].host })
+	return hosts, unverified, nil
+}
+
+// verifyHost returns why the host cannot be exposed, if so, given whether the
+// DomainClaims are verified.
+func verifyHost(host, domain string, claims []*v1.DomainClaim, clusterName string, isVerified func(*v1.DomainClaim) bool) error {
+	if isSubdomain(host, domain) {
+		return fmt.Errorf("the host is under the %s domain, which only the generated hosts can be exposed with", domain)
+	}
+
+	var pending []string
+	for _, claim := range claims {
+		if claim.ClusterName != clusterName || !isSubdomain(host, claim.Spec.Domain) {
+			continue
+		}
+		if isVerified(claim) {
+			return nil
+		}
+		pending = append(pending, claim.Name)
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("the DomainClaims covering the host are not verified: %s", strings.Join(pending, ", "))
+	}
+	return fmt.Errorf("no DomainClaim covers the host")
+}
+
+// exposedIngress returns a copy of the root Ingress, with the rules of the
+// verified hosts only.
+func exposedIngress(root *networkingv1.Ingress, hosts []string) *networkingv1.Ingress {
+	verified := map[string]struct{}{}
+	for _, host := range hosts {
+		verified[host] = struct{}{}
+	}
+
+	exposed := root.DeepCopy()
+	exposed.Spec.Rules = nil
+	for _, rule := range root.Spec.Rules {
+		if _, ok := verified[normalizeHost(rule.Host)]; ok {
+			exposed.Spec.Rules = append(exposed.Spec.Rules, rule)
+		}
+	}
+	return exposed
+}
+
+// isSubdomain returns whether the host is the domain, or one of its
+// subdomains.
+func isSubdomain(host, domain string) bool {
+	host, domain = normalizeHost(host), normalizeHost(domain)
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"hash/fnv"
 	"net"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/rs/xid"
 	"k8s.io/apimachinery/pkg/util/json"
 
 	corev1 "k8s.io/api/core/v1"
@@ -29,7 +31,28 @@ const (
 	clusterLabel = "kcp.dev/cluster"
 	ownedByLabel = "kcp.dev/owned-by"
 
+	// annotationPrefix is the prefix of the controller annotations, which are
+	// not propagated to the leaves.
+	annotationPrefix = "kuadrant.dev/"
+
+	// resolveTimeout bounds the duration the reconciliation waits for the
+	// load-balancer hostnames that are not cached yet to resolve.
+	resolveTimeout = 2 * time.Second
+
 	hostGeneratedAnnotation = "kuadrant.dev/host.generated"
+	// hostPrefixAnnotation requests the host generated for the root Ingress
+	// to be the given prefix under the domain, e.g., "shop" for
+	// "shop.kcp-apps.127.0.0.1.nip.io", rather than the hostname template.
+	hostPrefixAnnotation = "kuadrant.dev/host.prefix"
+	// weightsAnnotation holds the relative weights of the clusters in the DNS
+	// answers of the generated host, e.g., "kcp-cluster-a=90,kcp-cluster-b=10".
+	weightsAnnotation = "kuadrant.dev/weights"
+	// The health check annotations configure the probing of the DNS targets
+	// of the generated host, which is enabled by setting the protocol.
+	healthCheckProtocolAnnotation = "kuadrant.dev/health-check.protocol"
+	healthCheckPortAnnotation     = "kuadrant.dev/health-check.port"
+	healthCheckPathAnnotation     = "kuadrant.dev/health-check.path"
+	healthCheckIntervalAnnotation = "kuadrant.dev/health-check.interval"
 
 	manager = "kcp-ingress"
 )
@@ -39,13 +62,22 @@ func (c *Controller) reconcile(ctx context.Context, ingress *networkingv1.Ingres
 
 	if ingress.Labels == nil || ingress.Labels[clusterLabel] == "" {
 		// This is a root Ingress
-		if ingress.Annotations == nil || ingress.Annotations[hostGeneratedAnnotation] == "" {
+		collides, err := c.generatedHostCollides(ingress)
+		if err != nil {
+			return err
+		}
+		if ingress.Annotations == nil || ingress.Annotations[hostGeneratedAnnotation] == "" || collides {
 			// Let's assign it a global hostname if any
-			generatedHost := fmt.Sprintf("%s.%s", xid.New(), *c.domain)
+			generatedHost, err := c.generateHost(ingress)
+			if err != nil {
+				return err
+			}
 			patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, hostGeneratedAnnotation, generatedHost)
-			if err := c.patchIngress(ctx, ingress, []byte(patch)); err != nil {
+			if _, err := c.patchIngress(ctx, ingress, []byte(patch)); err != nil {
 				return err
 			}
+			// The Ingress is reconciled again, with the generated host, once patched
+			return nil
 		}
 
 		// Get the current leaves
@@ -58,43 +90,66 @@ func (c *Controller) reconcile(ctx context.Context, ingress *networkingv1.Ingres
 			return err
 		}
 
+		// Resolve the backends, the missing ones being reported in the root Ingress annotations,
+		// and the leaves still being created for the other ones. The patched root Ingress is
+		// used from now on, so that the status is computed from, and patched over, its
+		// latest version.
+		services, conditions, err := c.getServices(ingress)
+		if err != nil {
+			return err
+		}
+		root, err := c.recordBackendConditions(ctx, ingress, conditions)
+		if err != nil {
+			return err
+		}
+
 		// Generate the desired leaves
-		desiredLeaves, err := c.desiredLeaves(ctx, ingress)
+		desiredLeaves, err := c.desiredLeaves(ctx, root, services, currentLeaves)
 		if err != nil {
 			return err
 		}
 
 		// Delete the leaves that are not desired anymore
-		for _, leftover := range findNonDesiredLeaves(currentLeaves, desiredLeaves) {
-			// The clean-up, i.e., removal of the DNS records, will be done in the next reconciliation cycle
+		leftovers := findNonDesiredLeaves(currentLeaves, desiredLeaves)
+		for _, leftover := range leftovers {
 			klog.Infof("Deleting non desired leaf %q", leftover.Name)
 			if err := c.client.NetworkingV1().Ingresses(leftover.Namespace).Delete(ctx, leftover.Name, metav1.DeleteOptions{}); err != nil {
 				return err
 			}
 		}
 
-		// TODO(jmprusi): ugly. fix. use indexer, etc.
-		// Create and/or update the desired leaves
+		// Withdraw the load-balancers of the deleted leaves from the DNSRecords
+		if err := c.reconcileDNSRecords(ctx, root, findNonDesiredLeaves(currentLeaves, leftovers)); err != nil {
+			return err
+		}
+
+		// Copy the TLS Secrets to the clusters, before the leaves referencing them get created
+		if err := c.reconcileTLSSecrets(ctx, root, desiredLeaves); err != nil {
+			return err
+		}
+
+		// Apply the desired leaves that have changed
 		for _, leaf := range desiredLeaves {
-			if _, err := c.client.NetworkingV1().Ingresses(leaf.Namespace).Create(ctx, leaf, metav1.CreateOptions{}); err != nil {
-				if errors.IsAlreadyExists(err) {
-					existingLeaf, err := c.client.NetworkingV1().Ingresses(leaf.Namespace).Get(ctx, leaf.Name, metav1.GetOptions{})
-					if err != nil {
-						return err
-					}
-
-					// Set the resourceVersion and UID to update the desired leaf.
-					leaf.ResourceVersion = existingLeaf.ResourceVersion
-					leaf.UID = existingLeaf.UID
-
-					if _, err := c.client.NetworkingV1().Ingresses(leaf.Namespace).Update(ctx, leaf, metav1.UpdateOptions{}); err != nil {
-						return err
-					}
-				} else {
-					return err
-				}
+			if err := c.applyLeaf(ctx, leaf); err != nil {
+				return err
 			}
+		}
 
+		// Report the state of the desired leaves, the ones that have just been created
+		// having no load-balancer yet.
+		statusLeaves := make([]*networkingv1.Ingress, 0, len(desiredLeaves))
+		for _, leaf := range desiredLeaves {
+			current := leaf.DeepCopy()
+			current.Status = networkingv1.IngressStatus{}
+			for _, l := range currentLeaves {
+				if l.Name == leaf.Name {
+					current = l
+				}
+			}
+			statusLeaves = append(statusLeaves, current)
+		}
+		if err := c.reconcileStatus(ctx, root, statusLeaves); err != nil {
+			return err
 		}
 	} else {
 		// If the Ingress has the cluster label set, that means that it's a leaf.
@@ -129,62 +184,64 @@ func (c *Controller) reconcile(ctx context.Context, ingress *networkingv1.Ingres
 		}
 
 		rootIngress = rootIf.(*networkingv1.Ingress).DeepCopy()
-		rootHostname := ""
-		if rootIngress.Annotations != nil && rootIngress.Annotations[hostGeneratedAnnotation] != "" {
-			rootHostname = rootIngress.Annotations[hostGeneratedAnnotation]
+
+		// The resources of the root Ingress are being deleted, or it has been switched to another class
+		if rootIngress.DeletionTimestamp != nil || !c.IsHandled(rootIngress) {
+			return nil
 		}
 
-		// Reconcile the DNSRecord for the Ingress
-		if ingress.DeletionTimestamp != nil && !ingress.DeletionTimestamp.IsZero() {
-			// The Ingress is being deleted. KCP doesn't currently cascade deletion to owned resources,
-			// so let's delete the DNSRecord manually.
-			err := c.dnsRecordClient.DNSRecords(ingress.Namespace).Delete(ctx, ingress.Name, metav1.DeleteOptions{})
-			if err != nil && !errors.IsNotFound(err) {
-				return err
-			}
-		} else if rootHostname != "" && len(ingress.Status.LoadBalancer.Ingress) > 0 {
-			// The ingress has been admitted, let's expose the local load-balancing point to the global LB.
-			record, err := getDNSRecord(rootHostname, ingress)
-			if err != nil {
-				return err
-			}
-			_, err = c.dnsRecordClient.DNSRecords(record.Namespace).Create(ctx, record, metav1.CreateOptions{})
-			if err != nil {
-				if !errors.IsAlreadyExists(err) {
-					return err
-				}
-				data, err := json.Marshal(record)
-				if err != nil {
-					return err
-				}
-				_, err = c.dnsRecordClient.DNSRecords(record.Namespace).Patch(ctx, record.Name, types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: manager, Force: pointer.Bool(true)})
-				if err != nil {
-					return err
-				}
-			}
-		} else {
-			err := c.dnsRecordClient.DNSRecords(ingress.Namespace).Delete(ctx, ingress.Name, metav1.DeleteOptions{})
-			if err != nil && !errors.IsNotFound(err) {
-				return err
+		// The DNSRecords of previous versions, controlled by the leaf, are superseded
+		// by the ones of the root Ingress.
+		if err := c.ensureDNSRecords(ctx, ingress, nil); err != nil {
+			return err
+		}
+
+		// Reconcile the DNSRecords of the root Ingress, the leaf being reconciled superseding
+		// its possibly stale copy amongst the others.
+		leaves := []*networkingv1.Ingress{ingress}
+		for _, o := range others {
+			if o.Name != ingress.Name {
+				leaves = append(leaves, o)
 			}
 		}
+		if err := c.reconcileDNSRecords(ctx, rootIngress, leaves); err != nil {
+			return err
+		}
+		// The state of the leaves is reported by the root Ingress reconciliation only, so that
+		// it's computed from a single view of the leaves.
+		c.enqueue(rootIngress)
+
+		// In failover mode, only the active cluster takes traffic
+		active, failover, err := c.activeCluster(rootIngress, leaves)
+		if err != nil {
+			return err
+		}
 
 		// Clean the current status, and then recreate if from the other leafs.
 		rootIngress.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{}
-		for _, o := range others {
+		for _, o := range leaves {
+			if failover && o.Labels[clusterLabel] != active {
+				continue
+			}
 			// Should the root Ingress status be updated only once the DNS record is successfully created / updated?
 			rootIngress.Status.LoadBalancer.Ingress = append(rootIngress.Status.LoadBalancer.Ingress, o.Status.LoadBalancer.Ingress...)
 		}
 
 		// If the envoy control plane is enabled, we update the cache and generate and send to envoy a new snapshot.
 		if c.envoyXDS != nil {
-			c.cache.UpdateIngress(*rootIngress)
+			// Only the verified hosts get virtual hosts
+			hosts, err := c.verifiedHosts(rootIngress)
+			if err != nil {
+				return err
+			}
+			exposed := exposedIngress(rootIngress, hosts)
+			c.cache.UpdateIngress(*exposed)
 			err = c.envoyXDS.SetSnapshot(envoy.NodeID, c.cache.ToEnvoySnapshot())
 			if err != nil {
 				return err
 			}
 
-			statusHost := generateStatusHost(c.domain, rootIngress)
+			statusHost := generateStatusHost(c.domain, exposed)
 			// Now overwrite the Status of the rootIngress with our desired LB
 			rootIngress.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{
 				Hostname: statusHost,
@@ -207,25 +264,152 @@ func (c *Controller) reconcile(ctx context.Context, ingress *networkingv1.Ingres
 	return nil
 }
 
+// reconcileDNSRecords ensures the DNSRecords of the root Ingress verified hosts
+// target the load-balancers of all its admitted leaves, so that they are
+// published atomically, rather than by as many writers as there are leaves.
+func (c *Controller) reconcileDNSRecords(ctx context.Context, root *networkingv1.Ingress, leaves []*networkingv1.Ingress) error {
+	hosts, err := c.verifiedHosts(root)
+	if err != nil {
+		return err
+	}
+
+	weights, err := parseClusterValues(root.Annotations[weightsAnnotation])
+	if err != nil {
+		return fmt.Errorf("invalid %s annotation on Ingress %q: %w", weightsAnnotation, root.Name, err)
+	}
+	// In failover mode, the standby clusters are drained
+	active, failover, err := c.activeCluster(root, leaves)
+	if err != nil {
+		return err
+	}
+	if failover {
+		weights = failoverWeights(weights, active, leaves)
+	}
+
+	var records []*v1.DNSRecord
+	if len(hosts) > 0 {
+		healthCheck, err := getHealthCheck(root)
+		if err != nil {
+			return err
+		}
+		for _, host := range hosts {
+			r, err := c.getDNSRecords(ctx, host, root, leaves, weights, healthCheck)
+			if err != nil {
+				return err
+			}
+			records = append(records, r...)
+		}
+	}
+
+	return c.ensureDNSRecords(ctx, root, records)
+}
+
 //TODO may want to move this to its own package in the future
-func getDNSRecord(hostname string, ingress *networkingv1.Ingress) (*v1.DNSRecord, error) {
-	var targets []string
-	for _, lbs := range ingress.Status.LoadBalancer.Ingress {
+// getDNSRecords returns the DNSRecords exposing the load-balancers of the
+// admitted leaves under the given host of the root Ingress:
+//
+// - If all the load-balancers of the leaves that are not drained, e.g., the
+//   active one in failover mode, report the same hostname, and no IP, a CNAME
+//   record pointing to that hostname, so that the record follows the changes
+//   of the hostname addresses.
+// - Otherwise, as a CNAME cannot coexist with other records for the same name,
+//   an A record for the IPv4 addresses, and an AAAA record for the IPv6
+//   addresses, the hostnames being resolved. The leaves are reconciled again
+//   when the addresses of their hostnames change.
+//
+// The targets are annotated with the cluster of the leaf they come from. A
+// target shared by several clusters is attributed to the first one by name.
+func (c *Controller) getDNSRecords(ctx context.Context, hostname string, root *networkingv1.Ingress, leaves []*networkingv1.Ingress, weights map[string]string, healthCheck *v1.DNSHealthCheck) ([]*v1.DNSRecord, error) {
+	var admitted []*networkingv1.Ingress
+	for _, leaf := range leaves {
+		if leaf.DeletionTimestamp == nil && len(leaf.Status.LoadBalancer.Ingress) > 0 {
+			admitted = append(admitted, leaf)
+		}
+	}
+	if len(admitted) == 0 {
+		return nil, nil
+	}
+	sort.Slice(admitted, func(i, j int) bool { return admitted[i].Name < admitted[j].Name })
+
+	// The drained clusters, e.g., the standby ones in failover mode, don't take part in the CNAME,
+	// which cannot be weighted.
+	var published []*networkingv1.Ingress
+	for _, leaf := range admitted {
+		if !isDrained(weights, leaf.Labels[clusterLabel]) {
+			published = append(published, leaf)
+		}
+	}
+	if target, ok := cnameTarget(published); ok {
+		return []*v1.DNSRecord{newDNSRecord(hostname, v1.CNAMERecordType, []string{target}, nil, root, healthCheck)}, nil
+	}
+
+	targets := map[v1.DNSRecordType][]string{}
+	attributes := map[v1.DNSRecordType][]v1.DNSTargetAttributes{}
+	seen := map[string]struct{}{}
+	for _, leaf := range admitted {
+		a, err := c.targetAttributes(leaf, weights)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s annotation on Ingress %q: %w", weightsAnnotation, root.Name, err)
+		}
+		for _, ip := range c.addresses(ctx, leaf) {
+			target := ip.String()
+			if _, ok := seen[target]; ok {
+				continue
+			}
+			seen[target] = struct{}{}
+
+			recordType := v1.AAAARecordType
+			if ip.To4() != nil {
+				recordType = v1.ARecordType
+			}
+			targets[recordType] = append(targets[recordType], target)
+			ta := *a.DeepCopy()
+			ta.Target = target
+			attributes[recordType] = append(attributes[recordType], ta)
+		}
+	}
+
+	var records []*v1.DNSRecord
+	for _, recordType := range []v1.DNSRecordType{v1.ARecordType, v1.AAAARecordType} {
+		if len(targets[recordType]) > 0 {
+			records = append(records, newDNSRecord(hostname, recordType, targets[recordType], attributes[recordType], root, healthCheck))
+		}
+	}
+
+	return records, nil
+}
+
+// addresses returns the addresses of the leaf Ingress load-balancers, their
+// hostnames being resolved. The load-balancers which addresses cannot be
+// resolved, or parsed, are skipped, rather than failing the other targets. The
+// resolver keeps retrying the failed hostnames in the background, and the leaf
+// is requeued once they resolve.
+func (c *Controller) addresses(ctx context.Context, leaf *networkingv1.Ingress) []net.IP {
+	var addresses []net.IP
+	for _, lbs := range leaf.Status.LoadBalancer.Ingress {
 		if lbs.Hostname != "" {
-			//TODO once we are adding tests abstract to interface
-			ips, err := net.LookupIP(lbs.Hostname)
+			resolveCtx, cancel := context.WithTimeout(ctx, resolveTimeout)
+			ips, _, err := c.resolver.Resolve(resolveCtx, lbs.Hostname)
+			cancel()
 			if err != nil {
-				return nil, err
-			}
-			for _, ip := range ips {
-				targets = append(targets, ip.String())
+				klog.Errorf("skipping load-balancer hostname %q of Ingress %q: %v", lbs.Hostname, leaf.Name, err)
+				continue
 			}
+			addresses = append(addresses, ips...)
 		}
 		if lbs.IP != "" {
-			targets = append(targets, lbs.IP)
+			ip := net.ParseIP(lbs.IP)
+			if ip == nil {
+				klog.Errorf("skipping invalid load-balancer IP %q of Ingress %q", lbs.IP, leaf.Name)
+				continue
+			}
+			addresses = append(addresses, ip)
 		}
 	}
+	return addresses
+}
 
+func newDNSRecord(hostname string, recordType v1.DNSRecordType, targets []string, targetAttributes []v1.DNSTargetAttributes, ingress *networkingv1.Ingress, healthCheck *v1.DNSHealthCheck) *v1.DNSRecord {
 	record := &v1.DNSRecord{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: v1.SchemeGroupVersion.String(),
@@ -233,13 +417,16 @@ func getDNSRecord(hostname string, ingress *networkingv1.Ingress) (*v1.DNSRecord
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: ingress.Namespace,
-			Name:      ingress.Name,
+			Name:      dnsRecordName(ingress, hostname, recordType),
 		},
 		Spec: v1.DNSRecordSpec{
 			DNSName:    hostname,
-			RecordType: "A",
+			RecordType: recordType,
 			Targets:    targets,
 			RecordTTL:  60,
+
+			TargetAttributes: targetAttributes,
+			HealthCheck:      healthCheck,
 		},
 	}
 
@@ -255,21 +442,217 @@ func getDNSRecord(hostname string, ingress *networkingv1.Ingress) (*v1.DNSRecord
 		},
 	})
 
-	return record, nil
+	return record
+}
+
+// cnameTarget returns the hostname the leaves load-balancers all report, if
+// they don't report any IP.
+func cnameTarget(leaves []*networkingv1.Ingress) (string, bool) {
+	hostnames := map[string]struct{}{}
+	for _, leaf := range leaves {
+		for _, lbs := range leaf.Status.LoadBalancer.Ingress {
+			if lbs.IP != "" {
+				return "", false
+			}
+			if lbs.Hostname != "" {
+				hostnames[lbs.Hostname] = struct{}{}
+			}
+		}
+	}
+
+	if len(hostnames) != 1 {
+		return "", false
+	}
+	for hostname := range hostnames {
+		return hostname, true
+	}
+	return "", false
 }
 
-func (c *Controller) desiredLeaves(ctx context.Context, root *networkingv1.Ingress) ([]*networkingv1.Ingress, error) {
-	// This will parse the ingresses and extract all the destination services,
-	// then create a new ingress leaf for each of them.
-	services, err := c.getServices(ctx, root)
+// dnsRecordName returns the name of the DNSRecord of the given type, for the
+// host of the Ingress. The A record of the generated host is named after the
+// Ingress, for compatibility, while the names of the records of the other
+// hosts include the host hash.
+func dnsRecordName(ingress *networkingv1.Ingress, hostname string, recordType v1.DNSRecordType) string {
+	name := ingress.Name
+	if hostname != normalizeHost(ingress.Annotations[hostGeneratedAnnotation]) {
+		name += "-" + hashString(hostname)
+	}
+	if recordType != v1.ARecordType {
+		name += "-" + strings.ToLower(string(recordType))
+	}
+	return name
+}
+
+// ensureDNSRecords creates or updates the DNSRecords of the Ingress, and
+// deletes the ones it controls that are not desired anymore, e.g., of the types
+// or the hosts it is no longer exposed with.
+func (c *Controller) ensureDNSRecords(ctx context.Context, ingress *networkingv1.Ingress, records []*v1.DNSRecord) error {
+	desired := map[string]struct{}{}
+	for _, record := range records {
+		desired[record.Name] = struct{}{}
+		if err := c.applyDNSRecord(ctx, record); err != nil {
+			return err
+		}
+	}
+
+	current, err := c.dnsRecordLister.DNSRecords(ingress.Namespace).List(labels.Everything())
 	if err != nil {
-		return nil, err
+		return err
+	}
+	for _, record := range current {
+		if _, ok := desired[record.Name]; ok || record.DeletionTimestamp != nil || !isControlledBy(record, ingress) {
+			continue
+		}
+		// The DNS controller withdraws the record from the zones before releasing its finalizer
+		err := c.dnsRecordClient.DNSRecords(record.Namespace).Delete(ctx, record.Name, metav1.DeleteOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
 	}
 
-	var clusters []string
+	return nil
+}
+
+// applyDNSRecord creates the DNSRecord, or updates it if it already exists.
+func (c *Controller) applyDNSRecord(ctx context.Context, record *v1.DNSRecord) error {
+	_, err := c.dnsRecordClient.DNSRecords(record.Namespace).Create(ctx, record, metav1.CreateOptions{})
+	if err == nil || !errors.IsAlreadyExists(err) {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	applied, err := c.dnsRecordClient.DNSRecords(record.Namespace).Patch(ctx, record.Name, types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: manager, Force: pointer.Bool(true)})
+	if err != nil {
+		return err
+	}
+	if applied.DeletionTimestamp != nil {
+		// Retry once the previous record has been withdrawn and is gone
+		return fmt.Errorf("DNSRecord %q is being deleted", record.Name)
+	}
+	return nil
+}
+
+// isControlledBy returns whether the record is controlled by the Ingress. The
+// Ingress UID is not checked, as it's not known once the Ingress is deleted.
+func isControlledBy(record *v1.DNSRecord, ingress *networkingv1.Ingress) bool {
+	if record.ClusterName != ingress.ClusterName {
+		return false
+	}
+	ref := metav1.GetControllerOf(record)
+	return ref != nil && ref.Kind == "Ingress" && ref.Name == ingress.Name
+}
+
+// targetAttributes returns the routing attributes of the targets exposed by
+// the leaf Ingress cluster, given the clusters weights.
+func (c *Controller) targetAttributes(leaf *networkingv1.Ingress, weights map[string]string) (v1.DNSTargetAttributes, error) {
+	cluster := leaf.Labels[clusterLabel]
+	attributes := v1.DNSTargetAttributes{
+		Cluster: cluster,
+		Geo:     c.geoOf(cluster),
+	}
+
+	if w, ok := weights[cluster]; ok {
+		weight, err := strconv.ParseInt(w, 10, 64)
+		if err != nil || weight < 0 {
+			return attributes, fmt.Errorf("invalid weight %q for cluster %q", w, cluster)
+		}
+		attributes.Weight = &weight
+	}
+
+	return attributes, nil
+}
+
+// geoOf returns the geographical location of the cluster, held by the location
+// label of the Cluster, or set with the controller configuration otherwise.
+func (c *Controller) geoOf(cluster string) string {
+	if c.clusterLister != nil && c.clusterGeoLabel != "" {
+		clusters, err := c.clusterLister.List(labels.Everything())
+		if err != nil {
+			klog.Errorf("failed to list Clusters: %v", err)
+		}
+		for _, cl := range clusters {
+			if geo := cl.GetLabels()[c.clusterGeoLabel]; cl.GetName() == cluster && geo != "" {
+				return geo
+			}
+		}
+	}
+	return c.clusterGeo[cluster]
+}
+
+// isDrained returns whether the cluster is drained, i.e., its weight is zero.
+func isDrained(weights map[string]string, cluster string) bool {
+	w, ok := weights[cluster]
+	if !ok {
+		return false
+	}
+	weight, err := strconv.ParseInt(w, 10, 64)
+	return err == nil && weight == 0
+}
+
+// getHealthCheck returns the health check of the DNS targets configured with
+// the root Ingress annotations, or nil if the targets are not health checked.
+func getHealthCheck(root *networkingv1.Ingress) (*v1.DNSHealthCheck, error) {
+	protocol, ok := root.Annotations[healthCheckProtocolAnnotation]
+	if !ok {
+		return nil, nil
+	}
+
+	healthCheck := &v1.DNSHealthCheck{
+		Protocol: v1.HealthCheckProtocol(strings.ToUpper(protocol)),
+		Path:     root.Annotations[healthCheckPathAnnotation],
+	}
+	switch healthCheck.Protocol {
+	case v1.HealthCheckProtocolHTTP, v1.HealthCheckProtocolHTTPS, v1.HealthCheckProtocolTCP:
+	default:
+		return nil, fmt.Errorf("invalid %s annotation on Ingress %q: unsupported protocol %q", healthCheckProtocolAnnotation, root.Name, protocol)
+	}
+
+	if p, ok := root.Annotations[healthCheckPortAnnotation]; ok {
+		port, err := strconv.ParseInt(p, 10, 64)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid %s annotation on Ingress %q: invalid port %q", healthCheckPortAnnotation, root.Name, p)
+		}
+		healthCheck.Port = &port
+	}
+
+	if i, ok := root.Annotations[healthCheckIntervalAnnotation]; ok {
+		interval, err := time.ParseDuration(i)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid %s annotation on Ingress %q: invalid interval %q", healthCheckIntervalAnnotation, root.Name, i)
+		}
+		healthCheck.Interval = &metav1.Duration{Duration: interval}
+	}
+
+	return healthCheck, nil
+}
+
+// parseClusterValues parses a comma-separated list of cluster=value pairs.
+func parseClusterValues(s string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid cluster value %q, expected cluster=value", pair)
+		}
+		values[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return values, nil
+}
+
+// desiredLeaves returns a leaf of the root Ingress for each of the clusters it's
+// placed on, given the destination services of the root Ingress.
+func (c *Controller) desiredLeaves(ctx context.Context, root *networkingv1.Ingress, services []*corev1.Service, currentLeaves []*networkingv1.Ingress) ([]*networkingv1.Ingress, error) {
+	var backendClusters []string
 	for _, service := range services {
 		if service.Labels[clusterLabel] != "" {
-			clusters = append(clusters, service.Labels[clusterLabel])
+			backendClusters = append(backendClusters, service.Labels[clusterLabel])
 		} else {
 			klog.Infof("Skipping service %q because it is not assigned to any cluster", service.Name)
 		}
@@ -278,6 +661,16 @@ func (c *Controller) desiredLeaves(ctx context.Context, root *networkingv1.Ingre
 		c.tracker.add(root, service)
 	}
 
+	clusters, err := c.placeIngress(ctx, root, currentLeaves, backendClusters)
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := c.hasCertificate(root)
+	if err != nil {
+		return nil, err
+	}
+
 	desiredLeaves := make([]*networkingv1.Ingress, 0, len(clusters))
 	for _, cl := range clusters {
 		vd := root.DeepCopy()
@@ -303,8 +696,25 @@ func (c *Controller) desiredLeaves(ctx context.Context, root *networkingv1.Ingre
 			}
 			vd.Spec.Rules = append(vd.Spec.Rules, globalRules...)
 		}
+		vd.Spec.TLS = leafTLS(root, cl, certificate)
+		c.setLeafIngressClass(vd)
+
+		// Customize the leaf for its cluster
+		leaf, err := applyLeafOverrides(root, vd, cl)
+		if err != nil {
+			c.recorder.Event(root, corev1.EventTypeWarning, "InvalidLeafOverrides", err.Error())
+			return nil, err
+		}
+
+		// The controller annotations configure, and report the state of, the root Ingress only,
+		// and would otherwise get the leaves applied again whenever the root status changes.
+		for key := range leaf.Annotations {
+			if strings.HasPrefix(key, annotationPrefix) {
+				delete(leaf.Annotations, key)
+			}
+		}
 
-		desiredLeaves = append(desiredLeaves, vd)
+		desiredLeaves = append(desiredLeaves, leaf)
 	}
 
 	return desiredLeaves, nil
@@ -317,10 +727,9 @@ func hashString(s string) string {
 }
 
 func generateStatusHost(domain *string, ingress *networkingv1.Ingress) string {
-	// TODO(jmprusi): using "contains" is a bad idea as it could be abused by crafting a malicious hostname, but for a PoC it should be good enough?
-	allRulesAreDomain := true
+	allRulesAreDomain := len(ingress.Spec.Rules) > 0
 	for _, rule := range ingress.Spec.Rules {
-		if !strings.Contains(rule.Host, *domain) {
+		if !isSubdomain(rule.Host, *domain) {
 			allRulesAreDomain = false
 			break
 		}
@@ -334,30 +743,11 @@ func generateStatusHost(domain *string, ingress *networkingv1.Ingress) string {
 	return hashString(ingress.Name+ingress.Namespace+ingress.ClusterName) + "." + *domain
 }
 
-// getServices will parse the ingress object and return a list of the services.
-func (c *Controller) getServices(ctx context.Context, ingress *networkingv1.Ingress) ([]*corev1.Service, error) {
-	var services []*corev1.Service
-	for _, rule := range ingress.Spec.Rules {
-		for _, path := range rule.HTTP.Paths {
-			svc, err := c.client.CoreV1().Services(ingress.Namespace).Get(ctx, path.Backend.Service.Name, metav1.GetOptions{})
-			// TODO(jmprusi): If one of the services doesn't exist, we invalidate all the other ones.. review this.
-			if err != nil {
-				return nil, err
-			}
-			services = append(services, svc)
-		}
-	}
-	return services, nil
-}
-
-func (c *Controller) patchIngress(ctx context.Context, ingress *networkingv1.Ingress, data []byte) error {
-	i, err := c.client.NetworkingV1().Ingresses(ingress.Namespace).
+// patchIngress patches the Ingress, and returns its patched version, which the
+// subsequent changes must be made over.
+func (c *Controller) patchIngress(ctx context.Context, ingress *networkingv1.Ingress, data []byte) (*networkingv1.Ingress, error) {
+	return c.client.NetworkingV1().Ingresses(ingress.Namespace).
 		Patch(ctx, ingress.Name, types.MergePatchType, data, metav1.PatchOptions{FieldManager: manager})
-	if err != nil {
-		return err
-	}
-	ingress = i
-	return nil
 }
 
 func findNonDesiredLeaves(current, desired []*networkingv1.Ingress) []*networkingv1.Ingress {
@@ -0,0 +1,469 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic/dynamiclister"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/pointer"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/resolver"
+)
+
+// newClusterLister returns a lister of the Clusters with the given labels.
+func newClusterLister(t *testing.T, clusterLabels map[string]map[string]string) dynamiclister.Lister {
+	t.Helper()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for name, l := range clusterLabels {
+		cluster := &unstructured.Unstructured{}
+		cluster.SetName(name)
+		cluster.SetLabels(l)
+		if err := indexer.Add(cluster); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	return dynamiclister.New(indexer, clusterResource)
+}
+
+func TestTargetAttributes(t *testing.T) {
+	leaf := func(cluster string) *networkingv1.Ingress {
+		return &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{clusterLabel: cluster}}}
+	}
+
+	tests := []struct {
+		name       string
+		clusters   map[string]map[string]string
+		clusterGeo map[string]string
+		leaf       *networkingv1.Ingress
+		weights    map[string]string
+		wantGeo    string
+		wantWeight *int64
+		wantErr    bool
+	}{
+		{
+			name:     "location of the Cluster",
+			clusters: map[string]map[string]string{"cluster-a": {"region": "eu"}, "cluster-b": {"region": "us"}},
+			leaf:     leaf("cluster-a"),
+			wantGeo:  "eu",
+		},
+		{
+			name:       "location of the Cluster, ahead of the configured one",
+			clusters:   map[string]map[string]string{"cluster-a": {"region": "eu"}},
+			clusterGeo: map[string]string{"cluster-a": "us"},
+			leaf:       leaf("cluster-a"),
+			wantGeo:    "eu",
+		},
+		{
+			name:       "Cluster without location",
+			clusters:   map[string]map[string]string{"cluster-a": {"zone": "a"}},
+			clusterGeo: map[string]string{"cluster-a": "us"},
+			leaf:       leaf("cluster-a"),
+			wantGeo:    "us",
+		},
+		{
+			name:       "no Clusters",
+			clusterGeo: map[string]string{"cluster-a": "us"},
+			leaf:       leaf("cluster-a"),
+			wantGeo:    "us",
+		},
+		{
+			name: "no location",
+			leaf: leaf("cluster-a"),
+		},
+		{
+			name:       "weighted cluster",
+			leaf:       leaf("cluster-a"),
+			weights:    map[string]string{"cluster-a": "90", "cluster-b": "10"},
+			wantWeight: func() *int64 { w := int64(90); return &w }(),
+		},
+		{
+			name:    "invalid weight",
+			leaf:    leaf("cluster-a"),
+			weights: map[string]string{"cluster-a": "-1"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Controller{clusterGeoLabel: "region", clusterGeo: tt.clusterGeo}
+			if tt.clusters != nil {
+				c.clusterLister = newClusterLister(t, tt.clusters)
+			}
+
+			got, err := c.targetAttributes(tt.leaf, tt.weights)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Cluster != tt.leaf.Labels[clusterLabel] || got.Geo != tt.wantGeo {
+				t.Errorf("targetAttributes() = %+v, want cluster %q and geo %q", got, tt.leaf.Labels[clusterLabel], tt.wantGeo)
+			}
+			if (got.Weight == nil) != (tt.wantWeight == nil) || (got.Weight != nil && *got.Weight != *tt.wantWeight) {
+				t.Errorf("weight = %v, want %v", got.Weight, tt.wantWeight)
+			}
+		})
+	}
+}
+
+func TestAddresses(t *testing.T) {
+	leaf := func(lbs ...corev1.LoadBalancerIngress) *networkingv1.Ingress {
+		ingress := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "app"}}
+		ingress.Status.LoadBalancer.Ingress = lbs
+		return ingress
+	}
+
+	tests := []struct {
+		name string
+		leaf *networkingv1.Ingress
+		want []string
+	}{
+		{
+			name: "IP and resolved hostname",
+			leaf: leaf(
+				corev1.LoadBalancerIngress{IP: "10.0.0.1"},
+				corev1.LoadBalancerIngress{Hostname: "lb.example.com"},
+			),
+			want: []string{"10.0.0.1", "10.0.0.2"},
+		},
+		{
+			name: "unresolvable hostname",
+			leaf: leaf(
+				corev1.LoadBalancerIngress{Hostname: "missing.example.com"},
+				corev1.LoadBalancerIngress{IP: "10.0.0.1"},
+			),
+			want: []string{"10.0.0.1"},
+		},
+		{
+			name: "invalid IP",
+			leaf: leaf(
+				corev1.LoadBalancerIngress{IP: "invalid"},
+				corev1.LoadBalancerIngress{Hostname: "lb.example.com"},
+			),
+			want: []string{"10.0.0.2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := resolver.NewFakeResolver(time.Minute)
+			fake.Set("lb.example.com", "10.0.0.2")
+			c := &Controller{resolver: resolver.NewCachingResolver(fake, func(string) {})}
+
+			got := c.addresses(context.Background(), tt.leaf)
+			if len(got) != len(tt.want) {
+				t.Fatalf("addresses = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i].String() != tt.want[i] {
+					t.Errorf("addresses = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetHealthCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		wantProtocol v1.HealthCheckProtocol
+		wantInterval time.Duration
+		wantErr      bool
+	}{
+		{
+			name: "no health check",
+		},
+		{
+			name:         "health check with the default interval",
+			annotations:  map[string]string{healthCheckProtocolAnnotation: "https"},
+			wantProtocol: v1.HealthCheckProtocolHTTPS,
+		},
+		{
+			name:         "health check with an interval",
+			annotations:  map[string]string{healthCheckProtocolAnnotation: "TCP", healthCheckIntervalAnnotation: "10s"},
+			wantProtocol: v1.HealthCheckProtocolTCP,
+			wantInterval: 10 * time.Second,
+		},
+		{
+			name:        "invalid interval",
+			annotations: map[string]string{healthCheckProtocolAnnotation: "TCP", healthCheckIntervalAnnotation: "10"},
+			wantErr:     true,
+		},
+		{
+			name:        "negative interval",
+			annotations: map[string]string{healthCheckProtocolAnnotation: "TCP", healthCheckIntervalAnnotation: "-10s"},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid port",
+			annotations: map[string]string{healthCheckProtocolAnnotation: "TCP", healthCheckPortAnnotation: "65536"},
+			wantErr:     true,
+		},
+		{
+			name:        "unsupported protocol",
+			annotations: map[string]string{healthCheckProtocolAnnotation: "UDP"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := getHealthCheck(newRootIngress("workspace", "default", "app", tt.annotations))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if got == nil {
+				if tt.wantProtocol != "" {
+					t.Errorf("health check = nil, want protocol %s", tt.wantProtocol)
+				}
+				return
+			}
+			if got.Protocol != tt.wantProtocol {
+				t.Errorf("protocol = %s, want %s", got.Protocol, tt.wantProtocol)
+			}
+			var interval time.Duration
+			if got.Interval != nil {
+				interval = got.Interval.Duration
+			}
+			if interval != tt.wantInterval {
+				t.Errorf("interval = %v, want %v", interval, tt.wantInterval)
+			}
+		})
+	}
+}
+
+func TestGetDNSRecords(t *testing.T) {
+	const host = "app." + testDomain
+	root := newRootIngress("workspace", "default", "app", map[string]string{hostGeneratedAnnotation: host})
+	leaf := func(cluster string, lbs ...corev1.LoadBalancerIngress) *networkingv1.Ingress {
+		l := newRootIngress("workspace", "default", "app--"+cluster, nil)
+		l.Labels = map[string]string{clusterLabel: cluster, ownedByLabel: "app"}
+		l.Status.LoadBalancer.Ingress = lbs
+		return l
+	}
+	ip := func(ip string) corev1.LoadBalancerIngress { return corev1.LoadBalancerIngress{IP: ip} }
+	hostname := func(hostname string) corev1.LoadBalancerIngress { return corev1.LoadBalancerIngress{Hostname: hostname} }
+
+	tests := []struct {
+		name    string
+		leaves  []*networkingv1.Ingress
+		weights map[string]string
+		// want are the records, formatted as "name type target@cluster..."
+		want []string
+	}{
+		{
+			name:   "IPv4 and IPv6 addresses",
+			leaves: []*networkingv1.Ingress{leaf("cluster-2", ip("10.0.0.2"), ip("2001:db8::1")), leaf("cluster-1", ip("10.0.0.1"))},
+			want:   []string{"app A 10.0.0.1@cluster-1 10.0.0.2@cluster-2", "app-aaaa AAAA 2001:db8::1@cluster-2"},
+		},
+		{
+			name:   "address shared by several clusters",
+			leaves: []*networkingv1.Ingress{leaf("cluster-2", ip("10.0.0.1")), leaf("cluster-1", ip("10.0.0.1"))},
+			want:   []string{"app A 10.0.0.1@cluster-1"},
+		},
+		{
+			name: "leaves not admitted, or being deleted",
+			leaves: []*networkingv1.Ingress{leaf("cluster-1"), func() *networkingv1.Ingress {
+				l := leaf("cluster-2", ip("10.0.0.2"))
+				now := metav1.Now()
+				l.DeletionTimestamp = &now
+				return l
+			}()},
+		},
+		{
+			name:   "same load-balancer hostname",
+			leaves: []*networkingv1.Ingress{leaf("cluster-1", hostname("lb.example.com")), leaf("cluster-2", hostname("lb.example.com"))},
+			want:   []string{"app-cname CNAME lb.example.com"},
+		},
+		{
+			name:   "different load-balancer hostnames",
+			leaves: []*networkingv1.Ingress{leaf("cluster-1", hostname("lb.example.com")), leaf("cluster-2", hostname("lb-2.example.com"))},
+			want:   []string{"app A 10.0.0.2@cluster-1 10.0.0.3@cluster-2"},
+		},
+		{
+			name:   "load-balancer hostname and IP",
+			leaves: []*networkingv1.Ingress{leaf("cluster-1", hostname("lb.example.com")), leaf("cluster-2", ip("10.0.0.1"))},
+			want:   []string{"app A 10.0.0.2@cluster-1 10.0.0.1@cluster-2"},
+		},
+		{
+			name:    "load-balancer hostnames, with a drained cluster",
+			leaves:  []*networkingv1.Ingress{leaf("cluster-1", hostname("lb.example.com")), leaf("cluster-2", hostname("lb-2.example.com"))},
+			weights: map[string]string{"cluster-2": "0"},
+			want:    []string{"app-cname CNAME lb.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := resolver.NewFakeResolver(time.Minute)
+			fake.Set("lb.example.com", "10.0.0.2")
+			fake.Set("lb-2.example.com", "10.0.0.3")
+			c := &Controller{resolver: resolver.NewCachingResolver(fake, func(string) {})}
+
+			records, err := c.getDNSRecords(context.Background(), host, root, tt.leaves, tt.weights, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var got []string
+			for _, record := range records {
+				if record.Spec.DNSName != host {
+					t.Errorf("DNS name = %q, want %q", record.Spec.DNSName, host)
+				}
+				r := record.Name + " " + string(record.Spec.RecordType)
+				for i, target := range record.Spec.Targets {
+					r += " " + target
+					if i < len(record.Spec.TargetAttributes) {
+						r += "@" + record.Spec.TargetAttributes[i].Cluster
+					}
+				}
+				got = append(got, r)
+			}
+			if !equalStrings(got, tt.want) {
+				t.Errorf("records = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureDNSRecords(t *testing.T) {
+	root := newRootIngress("workspace", "default", "app", nil)
+	dnsRecord := func(workspace, name, owner string) *v1.DNSRecord {
+		return &v1.DNSRecord{
+			ObjectMeta: metav1.ObjectMeta{
+				ClusterName:     workspace,
+				Namespace:       "default",
+				Name:            name,
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Ingress", Name: owner, Controller: pointer.Bool(true)}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		existing    []*v1.DNSRecord
+		desired     []*v1.DNSRecord
+		wantCreated []string
+		wantDeleted []string
+	}{
+		{
+			name:        "new records",
+			desired:     []*v1.DNSRecord{dnsRecord("workspace", "app", "app"), dnsRecord("workspace", "app-aaaa", "app")},
+			wantCreated: []string{"app", "app-aaaa"},
+		},
+		{
+			name:        "records no longer desired",
+			existing:    []*v1.DNSRecord{dnsRecord("workspace", "app-aaaa", "app"), dnsRecord("workspace", "app-cname", "app")},
+			desired:     []*v1.DNSRecord{dnsRecord("workspace", "app", "app")},
+			wantCreated: []string{"app"},
+			wantDeleted: []string{"dnsrecords/app-aaaa", "dnsrecords/app-cname"},
+		},
+		{
+			name: "records of other Ingresses, or being deleted",
+			existing: []*v1.DNSRecord{
+				dnsRecord("workspace", "other", "other"),
+				dnsRecord("other", "app-aaaa", "app"),
+				func() *v1.DNSRecord {
+					record := dnsRecord("workspace", "app-cname", "app")
+					now := metav1.Now()
+					record.DeletionTimestamp = &now
+					return record
+				}(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _, kuadrantClient := newTestController(t, nil, nil, tt.existing)
+
+			if err := c.ensureDNSRecords(context.Background(), root, tt.desired); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var created []string
+			for _, action := range kuadrantClient.Actions() {
+				if a, ok := action.(clienttesting.CreateAction); ok {
+					created = append(created, a.GetObject().(*v1.DNSRecord).Name)
+				}
+			}
+			if !equalStrings(created, tt.wantCreated) {
+				t.Errorf("created = %v, want %v", created, tt.wantCreated)
+			}
+			if got := deleted(kuadrantClient.Actions()); !equalStrings(got, tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", got, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestParseClusterValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "empty",
+			want: map[string]string{},
+		},
+		{
+			name:  "values",
+			value: "kcp-cluster-a=90,kcp-cluster-b=10",
+			want:  map[string]string{"kcp-cluster-a": "90", "kcp-cluster-b": "10"},
+		},
+		{
+			name:  "values with spaces and empty pairs",
+			value: " kcp-cluster-a = 90 ,, kcp-cluster-b=eu-west,",
+			want:  map[string]string{"kcp-cluster-a": "90", "kcp-cluster-b": "eu-west"},
+		},
+		{
+			name:  "empty value",
+			value: "kcp-cluster-a=",
+			want:  map[string]string{"kcp-cluster-a": ""},
+		},
+		{
+			name:    "missing value",
+			value:   "kcp-cluster-a=90,kcp-cluster-b",
+			wantErr: true,
+		},
+		{
+			name:    "missing cluster",
+			value:   "=90",
+			wantErr: true,
+		},
+		{
+			name:    "blank cluster",
+			value:   " = 90",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseClusterValues(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseClusterValues(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+			for cluster, value := range tt.want {
+				if v, ok := got[cluster]; !ok || v != value {
+					t.Errorf("parseClusterValues(%q) = %v, want %v", tt.value, got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package ingress
+
+import (
+	"context"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+)
+
+const (
+	// DefaultIngressClass is the name of the IngressClass registered for the
+	// controller by default.
+	DefaultIngressClass = "kcp-ingress"
+	// ingressClassController is the controller name of the IngressClass.
+	ingressClassController = "kuadrant.dev/kcp-ingress"
+	// ingressClassAnnotation is the legacy annotation selecting the class of
+	// an Ingress, superseded by spec.ingressClassName.
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+)
+
+// registerIngressClass creates or updates the IngressClass of the controller,
+// marking it as the default one if the Ingresses without a class are handled.
+func (c *Controller) registerIngressClass(ctx context.Context) error {
+	desired := &networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: c.ingressClass,
+		},
+		Spec: networkingv1.IngressClassSpec{
+			Controller: ingressClassController,
+		},
+	}
+	if c.defaultIngressClass {
+		desired.Annotations = map[string]string{networkingv1.AnnotationIsDefaultIngressClass: "true"}
+	}
+
+	existing, err := c.client.NetworkingV1().IngressClasses().Get(ctx, desired.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		klog.Infof("Registering IngressClass %q", desired.Name)
+		_, err = c.client.NetworkingV1().IngressClasses().Create(ctx, desired, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(existing.Spec, desired.Spec) &&
+		existing.Annotations[networkingv1.AnnotationIsDefaultIngressClass] == desired.Annotations[networkingv1.AnnotationIsDefaultIngressClass] {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Spec = desired.Spec
+	if c.defaultIngressClass {
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[networkingv1.AnnotationIsDefaultIngressClass] = "true"
+	} else {
+		delete(updated.Annotations, networkingv1.AnnotationIsDefaultIngressClass)
+	}
+	klog.Infof("Updating IngressClass %q", desired.Name)
+	_, err = c.client.NetworkingV1().IngressClasses().Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// IsHandled returns whether the Ingress is meant for the controller, i.e., it's
+// a leaf, or a root Ingress of the controller class, or without class if the
+// controller class is the default one.
+func (c *Controller) IsHandled(ingress *networkingv1.Ingress) bool {
+	if ingress.Labels[clusterLabel] != "" {
+		return true
+	}
+
+	class := ingress.Annotations[ingressClassAnnotation]
+	if ingress.Spec.IngressClassName != nil {
+		class = *ingress.Spec.IngressClassName
+	}
+	if class == "" {
+		return c.defaultIngressClass
+	}
+	return class == c.ingressClass
+}
+
+// setLeafIngressClass sets the class of the leaf, for the controller of its
+// cluster to pick it up, or removes the root Ingress class, so that the default
+// class of the cluster applies.
+func (c *Controller) setLeafIngressClass(leaf *networkingv1.Ingress) {
+	delete(leaf.Annotations, ingressClassAnnotation)
+	leaf.Spec.IngressClassName = nil
+	if c.leafIngressClass != "" {
+		class := c.leafIngressClass
+		leaf.Spec.IngressClassName = &class
+	}
+}
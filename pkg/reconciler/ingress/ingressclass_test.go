@@ -0,0 +1,135 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestIsHandled(t *testing.T) {
+	ingress := func(className, annotation string, labels map[string]string) *networkingv1.Ingress {
+		i := &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "app", Labels: labels}}
+		if className != "" {
+			i.Spec.IngressClassName = &className
+		}
+		if annotation != "" {
+			i.Annotations = map[string]string{ingressClassAnnotation: annotation}
+		}
+		return i
+	}
+
+	tests := []struct {
+		name         string
+		ingress      *networkingv1.Ingress
+		defaultClass bool
+		want         bool
+	}{
+		{
+			name:    "controller class",
+			ingress: ingress(DefaultIngressClass, "", nil),
+			want:    true,
+		},
+		{
+			name:    "controller class, set with the legacy annotation",
+			ingress: ingress("", DefaultIngressClass, nil),
+			want:    true,
+		},
+		{
+			name:    "another class",
+			ingress: ingress("nginx", "", nil),
+		},
+		{
+			name:    "another class, set with the legacy annotation",
+			ingress: ingress("", "nginx", nil),
+		},
+		{
+			name:    "another class, overriding the legacy annotation",
+			ingress: ingress("nginx", DefaultIngressClass, nil),
+		},
+		{
+			name:         "no class, the controller class being the default one",
+			ingress:      ingress("", "", nil),
+			defaultClass: true,
+			want:         true,
+		},
+		{
+			name:    "no class, the controller class not being the default one",
+			ingress: ingress("", "", nil),
+		},
+		{
+			name:    "leaf of another class",
+			ingress: ingress("nginx", "", map[string]string{clusterLabel: "cluster-1", ownedByLabel: "app"}),
+			want:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &Controller{ingressClass: DefaultIngressClass, defaultIngressClass: tt.defaultClass}
+			if got := c.IsHandled(tt.ingress); got != tt.want {
+				t.Errorf("IsHandled() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterIngressClass(t *testing.T) {
+	class := func(annotations map[string]string) *networkingv1.IngressClass {
+		return &networkingv1.IngressClass{
+			ObjectMeta: metav1.ObjectMeta{Name: DefaultIngressClass, Annotations: annotations},
+			Spec:       networkingv1.IngressClassSpec{Controller: ingressClassController},
+		}
+	}
+	isDefault := map[string]string{networkingv1.AnnotationIsDefaultIngressClass: "true"}
+
+	tests := []struct {
+		name         string
+		objects      []runtime.Object
+		defaultClass bool
+		wantDefault  bool
+	}{
+		{
+			name:         "new default class",
+			defaultClass: true,
+			wantDefault:  true,
+		},
+		{
+			name: "new class",
+		},
+		{
+			name:         "class marked as the default one",
+			objects:      []runtime.Object{class(nil)},
+			defaultClass: true,
+			wantDefault:  true,
+		},
+		{
+			name:    "class no longer the default one",
+			objects: []runtime.Object{class(isDefault)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := kubefake.NewSimpleClientset(tt.objects...)
+			c := &Controller{client: client, ingressClass: DefaultIngressClass, defaultIngressClass: tt.defaultClass}
+
+			if err := c.registerIngressClass(context.Background()); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := client.NetworkingV1().IngressClasses().Get(context.Background(), DefaultIngressClass, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Spec.Controller != ingressClassController {
+				t.Errorf("controller = %q, want %q", got.Spec.Controller, ingressClassController)
+			}
+			if isDefault := got.Annotations[networkingv1.AnnotationIsDefaultIngressClass] == "true"; isDefault != tt.wantDefault {
+				t.Errorf("default = %v, want %v", isDefault, tt.wantDefault)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package ingress
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/json"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog"
+	"k8s.io/utils/pointer"
+)
+
+// applyLeaf applies the desired leaf Ingress server-side, under the controller
+// field manager, so that the fields owned by other actors, like the status
+// written by the syncer, are left untouched. The leaf is not written if the
+// copy in the informer cache is already up-to-date.
+func (c *Controller) applyLeaf(ctx context.Context, leaf *networkingv1.Ingress) error {
+	current, err := c.lister.Ingresses(leaf.Namespace).Get(clusters.ToClusterAwareKey(leaf.ClusterName, leaf.Name))
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && isLeafUpToDate(current, leaf) {
+		return nil
+	}
+
+	// Only the fields the controller owns are part of the apply configuration
+	data, err := json.Marshal(map[string]interface{}{
+		"apiVersion": networkingv1.SchemeGroupVersion.String(),
+		"kind":       "Ingress",
+		"metadata": map[string]interface{}{
+			"name":        leaf.Name,
+			"namespace":   leaf.Namespace,
+			"labels":      leaf.Labels,
+			"annotations": leaf.Annotations,
+		},
+		"spec": leaf.Spec,
+	})
+	if err != nil {
+		return err
+	}
+	klog.Infof("Applying leaf Ingress %q on cluster %q", leaf.Name, leaf.Labels[clusterLabel])
+	_, err = c.client.NetworkingV1().Ingresses(leaf.Namespace).Patch(ctx, leaf.Name, types.ApplyPatchType, data, metav1.PatchOptions{FieldManager: manager, Force: pointer.Bool(true)})
+	return err
+}
+
+// isLeafUpToDate returns whether the current leaf holds the desired spec,
+// labels and annotations, and whether the controller already manages it with
+// server-side apply, without owning any label or annotation that is no longer
+// desired, which the next apply would remove.
+// The class defaulted by the server, when the leaf has none, is ignored, as
+// applying the leaf again would not remove it.
+func isLeafUpToDate(current, desired *networkingv1.Ingress) bool {
+	spec := desired.Spec.DeepCopy()
+	if spec.IngressClassName == nil {
+		spec.IngressClassName = current.Spec.IngressClassName
+	}
+	if !equality.Semantic.DeepEqual(current.Spec, *spec) {
+		return false
+	}
+	if !isSubset(desired.Labels, current.Labels) || !isSubset(desired.Annotations, current.Annotations) {
+		return false
+	}
+
+	for _, entry := range current.ManagedFields {
+		if entry.Manager != manager || entry.Operation != metav1.ManagedFieldsOperationApply || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			return false
+		}
+		metadata, _ := fields["f:metadata"].(map[string]interface{})
+		return ownsOnly(metadata["f:labels"], desired.Labels) && ownsOnly(metadata["f:annotations"], desired.Annotations)
+	}
+	return false
+}
+
+// isSubset returns whether all the entries of a are in b.
+func isSubset(a, b map[string]string) bool {
+	for k, v := range a {
+		if value, ok := b[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ownsOnly returns whether the keys of the managed fields set are all in the
+// desired map.
+func ownsOnly(set interface{}, desired map[string]string) bool {
+	fields, _ := set.(map[string]interface{})
+	for field := range fields {
+		if !strings.HasPrefix(field, "f:") {
+			continue
+		}
+		if _, ok := desired[strings.TrimPrefix(field, "f:")]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package ingress
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsLeafUpToDate(t *testing.T) {
+	pathType := networkingv1.PathTypePrefix
+	nginx := "nginx"
+	desired := func() *networkingv1.Ingress {
+		return &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "default",
+				Name:        "app--cluster-1",
+				Labels:      map[string]string{clusterLabel: "cluster-1", ownedByLabel: "app"},
+				Annotations: map[string]string{"nginx.ingress.kubernetes.io/proxy-read-timeout": "120"},
+			},
+			Spec: networkingv1.IngressSpec{
+				Rules: []networkingv1.IngressRule{{
+					Host: "app." + testDomain,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &pathType,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{Name: "httpecho", Port: networkingv1.ServiceBackendPort{Number: 80}},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+	}
+	// applied returns the leaf as stored once applied, with the fields managed by
+	// the controller, as well as the status written by the syncer.
+	applied := func(leaf *networkingv1.Ingress, operation metav1.ManagedFieldsOperationType, fields string) *networkingv1.Ingress {
+		current := leaf.DeepCopy()
+		current.UID = "uid"
+		current.ResourceVersion = "2"
+		current.Generation = 1
+		current.ManagedFields = []metav1.ManagedFieldsEntry{
+			{
+				Manager:   "syncer",
+				Operation: metav1.ManagedFieldsOperationUpdate,
+				FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:status":{"f:loadBalancer":{"f:ingress":{}}}}`)},
+			},
+			{
+				Manager:   manager,
+				Operation: operation,
+				FieldsV1:  &metav1.FieldsV1{Raw: []byte(fields)},
+			},
+		}
+		current.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "10.0.0.1"}}
+		return current
+	}
+	const ownedFields = `{"f:metadata":{"f:labels":{"f:kcp.dev/cluster":{},"f:kcp.dev/owned-by":{}},"f:annotations":{"f:nginx.ingress.kubernetes.io/proxy-read-timeout":{}}},"f:spec":{"f:rules":{}}}`
+
+	tests := []struct {
+		name    string
+		current *networkingv1.Ingress
+		desired *networkingv1.Ingress
+		want    bool
+	}{
+		{
+			name:    "up-to-date leaf",
+			current: applied(desired(), metav1.ManagedFieldsOperationApply, ownedFields),
+			desired: desired(),
+			want:    true,
+		},
+		{
+			name: "up-to-date leaf, with empty rather than unset fields",
+			current: func() *networkingv1.Ingress {
+				leaf := applied(desired(), metav1.ManagedFieldsOperationApply, ownedFields)
+				leaf.Spec.TLS = []networkingv1.IngressTLS{}
+				return leaf
+			}(),
+			desired: desired(),
+			want:    true,
+		},
+		{
+			name: "up-to-date leaf, with the class defaulted by the server",
+			current: func() *networkingv1.Ingress {
+				leaf := applied(desired(), metav1.ManagedFieldsOperationApply, ownedFields)
+				leaf.Spec.IngressClassName = &nginx
+				return leaf
+			}(),
+			desired: desired(),
+			want:    true,
+		},
+		{
+			name: "up-to-date leaf, with labels and annotations of other actors",
+			current: func() *networkingv1.Ingress {
+				leaf := applied(desired(), metav1.ManagedFieldsOperationApply, ownedFields)
+				leaf.Labels["other"] = "value"
+				leaf.Annotations["other"] = "value"
+				return leaf
+			}(),
+			desired: desired(),
+			want:    true,
+		},
+		{
+			name:    "leaf not applied yet",
+			current: applied(desired(), metav1.ManagedFieldsOperationUpdate, ownedFields),
+			desired: desired(),
+		},
+		{
+			name: "leaf without managed fields",
+			current: func() *networkingv1.Ingress {
+				leaf := applied(desired(), metav1.ManagedFieldsOperationApply, ownedFields)
+				leaf.ManagedFields = nil
+				return leaf
+			}(),
+			desired: desired(),
+		},
+		{
+			name: "mutated rules",
+			current: func() *networkingv1.Ingress {
+				leaf := applied(desired(), metav1.ManagedFieldsOperationApply, ownedFields)
+				leaf.Spec.Rules[0].Host = "other." + testDomain
+				return leaf
+			}(),
+			desired: desired(),
+		},
+		{
+			name:    "changed class",
+			current: applied(desired(), metav1.ManagedFieldsOperationApply, ownedFields),
+			desired: func() *networkingv1.Ingress {
+				leaf := desired()
+				leaf.Spec.IngressClassName = &nginx
+				return leaf
+			}(),
+		},
+		{
+			name: "mutated label",
+			current: func() *networkingv1.Ingress {
+				leaf := applied(desired(), metav1.ManagedFieldsOperationApply, ownedFields)
+				leaf.Labels[ownedByLabel] = "other"
+				return leaf
+			}(),
+			desired: desired(),
+		},
+		{
+			name: "removed annotation",
+			current: func() *networkingv1.Ingress {
+				leaf := applied(desired(), metav1.ManagedFieldsOperationApply, ownedFields)
+				delete(leaf.Annotations, "nginx.ingress.kubernetes.io/proxy-read-timeout")
+				return leaf
+			}(),
+			desired: desired(),
+		},
+		{
+			name:    "owned annotation no longer desired",
+			current: applied(desired(), metav1.ManagedFieldsOperationApply, ownedFields),
+			desired: func() *networkingv1.Ingress {
+				leaf := desired()
+				leaf.Annotations = nil
+				return leaf
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLeafUpToDate(tt.current, tt.desired); got != tt.want {
+				t.Errorf("isLeafUpToDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package ingress
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// leafOverridesAnnotationPrefix is prepended to the name of a cluster, to form
+// the annotation of the root Ingress holding the JSON merge patch applied to
+// its leaf on that cluster, e.g., "kuadrant.dev/leaf-overrides.kcp-cluster-a".
+const leafOverridesAnnotationPrefix = "kuadrant.dev/leaf-overrides."
+
+// applyLeafOverrides applies the overrides of the root Ingress for the cluster
+// to the leaf, if any. The name, namespace and labels identifying the leaf are
+// preserved.
+func applyLeafOverrides(root, leaf *networkingv1.Ingress, cluster string) (*networkingv1.Ingress, error) {
+	patch, ok := root.Annotations[leafOverridesAnnotationPrefix+cluster]
+	if !ok {
+		return leaf, nil
+	}
+
+	original, err := json.Marshal(leaf)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := jsonpatch.MergePatch(original, []byte(patch))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation on Ingress %q: %w", leafOverridesAnnotationPrefix+cluster, root.Name, err)
+	}
+	overridden := &networkingv1.Ingress{}
+	if err := json.Unmarshal(patched, overridden); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation on Ingress %q: %w", leafOverridesAnnotationPrefix+cluster, root.Name, err)
+	}
+
+	overridden.Name = leaf.Name
+	overridden.Namespace = leaf.Namespace
+	overridden.ClusterName = leaf.ClusterName
+	if overridden.Labels == nil {
+		overridden.Labels = map[string]string{}
+	}
+	overridden.Labels[clusterLabel] = leaf.Labels[clusterLabel]
+	overridden.Labels[ownedByLabel] = leaf.Labels[ownedByLabel]
+
+	return overridden, nil
+}
@@ -0,0 +1,102 @@
+package ingress
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+func TestApplyLeafOverrides(t *testing.T) {
+	nginx := "nginx"
+	leaf := func() *networkingv1.Ingress {
+		l := newRootIngress("workspace", "default", "app--cluster-1", map[string]string{"nginx.ingress.kubernetes.io/proxy-read-timeout": "60"})
+		l.Labels = map[string]string{clusterLabel: "cluster-1", ownedByLabel: "app"}
+		l.Spec.IngressClassName = &nginx
+		return l
+	}
+	root := func(overrides string) *networkingv1.Ingress {
+		return newRootIngress("workspace", "default", "app", map[string]string{leafOverridesAnnotationPrefix + "cluster-1": overrides})
+	}
+
+	tests := []struct {
+		name        string
+		root        *networkingv1.Ingress
+		wantClass   string
+		wantTimeout string
+		wantErr     bool
+	}{
+		{
+			name:        "no overrides",
+			root:        newRootIngress("workspace", "default", "app", nil),
+			wantClass:   "nginx",
+			wantTimeout: "60",
+		},
+		{
+			name:        "overrides for another cluster",
+			root:        newRootIngress("workspace", "default", "app", map[string]string{leafOverridesAnnotationPrefix + "cluster-2": `{"spec":{"ingressClassName":"traefik"}}`}),
+			wantClass:   "nginx",
+			wantTimeout: "60",
+		},
+		{
+			name:        "overridden annotation and class",
+			root:        root(`{"metadata":{"annotations":{"nginx.ingress.kubernetes.io/proxy-read-timeout":"120"}},"spec":{"ingressClassName":"traefik"}}`),
+			wantClass:   "traefik",
+			wantTimeout: "120",
+		},
+		{
+			name:      "removed annotation",
+			root:      root(`{"metadata":{"annotations":{"nginx.ingress.kubernetes.io/proxy-read-timeout":null}}}`),
+			wantClass: "nginx",
+		},
+		{
+			name:        "overridden identity",
+			root:        root(`{"metadata":{"name":"other","namespace":"other","labels":null}}`),
+			wantClass:   "nginx",
+			wantTimeout: "60",
+		},
+		{
+			name:    "invalid JSON",
+			root:    root(`{"spec":`),
+			wantErr: true,
+		},
+		{
+			name:    "invalid Ingress",
+			root:    root(`{"spec":{"rules":"invalid"}}`),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := leaf()
+			got, err := applyLeafOverrides(tt.root, original, "cluster-1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Name != original.Name || got.Namespace != original.Namespace || got.ClusterName != original.ClusterName {
+				t.Errorf("leaf = %s/%s in %q, want %s/%s in %q", got.Namespace, got.Name, got.ClusterName, original.Namespace, original.Name, original.ClusterName)
+			}
+			if got.Labels[clusterLabel] != "cluster-1" || got.Labels[ownedByLabel] != "app" {
+				t.Errorf("labels = %v, want the leaf identifying labels", got.Labels)
+			}
+			class := ""
+			if got.Spec.IngressClassName != nil {
+				class = *got.Spec.IngressClassName
+			}
+			if class != tt.wantClass {
+				t.Errorf("class = %q, want %q", class, tt.wantClass)
+			}
+			if timeout := got.Annotations["nginx.ingress.kubernetes.io/proxy-read-timeout"]; timeout != tt.wantTimeout {
+				t.Errorf("timeout annotation = %q, want %q", timeout, tt.wantTimeout)
+			}
+			// The leaf the overrides are applied to is left untouched
+			if *original.Spec.IngressClassName != "nginx" || original.Annotations["nginx.ingress.kubernetes.io/proxy-read-timeout"] != "60" {
+				t.Errorf("original leaf = %v, want it unchanged", original)
+			}
+		})
+	}
+}
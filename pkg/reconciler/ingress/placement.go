@@ -0,0 +1,283 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/discovery"
+	"k8s.io/klog"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+// clusterResource is the resource of the kcp physical clusters.
+var clusterResource = schema.GroupVersionResource{Group: "cluster.example.dev", Version: "v1alpha1", Resource: "clusters"}
+
+const (
+	placementSatisfiedReason   = "PlacementSatisfied"
+	placementUnsatisfiedReason = "PlacementUnsatisfied"
+)
+
+// placeIngress returns the clusters the root Ingress is placed on. Unless an
+// IngressPlacement selects the Ingress, these are the clusters its backend
+// Services are assigned to.
+func (c *Controller) placeIngress(ctx context.Context, root *networkingv1.Ingress, currentLeaves []*networkingv1.Ingress, backendClusters []string) ([]string, error) {
+	placement, err := c.placementFor(root)
+	if err != nil {
+		return nil, err
+	}
+	if placement == nil {
+		return uniqueClusters(backendClusters), nil
+	}
+	if c.clusterLister == nil {
+		klog.Infof("IngressPlacement %q is ignored for Ingress %q, as the Clusters are not available", placement.Name, root.Name)
+		return uniqueClusters(backendClusters), nil
+	}
+
+	selector := labels.Everything()
+	if placement.Spec.ClusterSelector != nil {
+		selector, err = metav1.LabelSelectorAsSelector(placement.Spec.ClusterSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster selector of IngressPlacement %q: %w", placement.Name, err)
+		}
+	}
+	candidates, err := c.clusterLister.List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	// The clusters the Ingress is currently placed on are preferred, so that the placement is
+	// stable, and then the clusters its backends are assigned to.
+	preference := map[string]int{}
+	for _, cluster := range backendClusters {
+		preference[cluster] = 1
+	}
+	var currentClusters []string
+	for _, leaf := range currentLeaves {
+		preference[leaf.Labels[clusterLabel]] = 2
+		currentClusters = append(currentClusters, leaf.Labels[clusterLabel])
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		pi, pj := preference[candidates[i].GetName()], preference[candidates[j].GetName()]
+		if pi != pj {
+			return pi > pj
+		}
+		return candidates[i].GetName() < candidates[j].GetName()
+	})
+
+	clusters := selectClusters(candidates, placement.Spec)
+	satisfied := placement.Spec.MinReplicas == nil || len(clusters) >= int(*placement.Spec.MinReplicas)
+	if err := c.reportPlacement(ctx, placement, satisfied, len(clusters)); err != nil {
+		return nil, err
+	}
+	if !satisfied {
+		// The current placement is kept, until enough clusters can be selected
+		c.recorder.Eventf(root, corev1.EventTypeWarning, placementUnsatisfiedReason, "IngressPlacement %q cannot be satisfied: %d clusters can be selected, at least %d are required", placement.Name, len(clusters), *placement.Spec.MinReplicas)
+		return uniqueClusters(currentClusters), nil
+	}
+
+	klog.Infof("IngressPlacement %q places Ingress %q on clusters %v", placement.Name, root.Name, clusters)
+	return clusters, nil
+}
+
+// reportPlacement sets the Satisfied condition of the IngressPlacement, given
+// whether the Ingress it places can be placed on enough clusters.
+func (c *Controller) reportPlacement(ctx context.Context, placement *v1.IngressPlacement, satisfied bool, selectable int) error {
+	condition := v1.IngressPlacementCondition{
+		Type:    v1.IngressPlacementSatisfiedConditionType,
+		Status:  string(metav1.ConditionTrue),
+		Reason:  placementSatisfiedReason,
+		Message: fmt.Sprintf("%d clusters can be selected", selectable),
+	}
+	if !satisfied {
+		condition.Status = string(metav1.ConditionFalse)
+		condition.Reason = placementUnsatisfiedReason
+		condition.Message = fmt.Sprintf("%d clusters can be selected, at least %d are required", selectable, *placement.Spec.MinReplicas)
+	}
+
+	current := placement.DeepCopy()
+	condition.LastTransitionTime = metav1.Now()
+	for _, cond := range current.Status.Conditions {
+		if cond.Type == condition.Type && cond.Status == condition.Status {
+			condition.LastTransitionTime = cond.LastTransitionTime
+		}
+	}
+	current.Status.Conditions = []v1.IngressPlacementCondition{condition}
+	current.Status.ObservedGeneration = current.Generation
+	if equality.Semantic.DeepEqual(placement.Status, current.Status) {
+		return nil
+	}
+	_, err := c.dnsRecordClient.IngressPlacements(current.Namespace).UpdateStatus(ctx, current, metav1.UpdateOptions{})
+	return err
+}
+
+// placementFor returns the IngressPlacement that applies to the root Ingress,
+// i.e., the oldest one selecting it, or nil if there is none.
+func (c *Controller) placementFor(root *networkingv1.Ingress) (*v1.IngressPlacement, error) {
+	placements, err := c.placementLister.IngressPlacements(root.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(placements, func(i, j int) bool {
+		if placements[i].CreationTimestamp.Equal(&placements[j].CreationTimestamp) {
+			return placements[i].Name < placements[j].Name
+		}
+		return placements[i].CreationTimestamp.Before(&placements[j].CreationTimestamp)
+	})
+
+	for _, placement := range placements {
+		if placement.ClusterName != root.ClusterName {
+			continue
+		}
+		if placement.Spec.IngressSelector == nil {
+			return placement, nil
+		}
+		selector, err := metav1.LabelSelectorAsSelector(placement.Spec.IngressSelector)
+		if err != nil {
+			klog.Errorf("invalid Ingress selector of IngressPlacement %q: %v", placement.Name, err)
+			continue
+		}
+		if selector.Matches(labels.Set(root.Labels)) {
+			return placement, nil
+		}
+	}
+	return nil, nil
+}
+
+// isDiscoverable returns whether the resource is served by the API server.
+func isDiscoverable(client discovery.DiscoveryInterface, resource schema.GroupVersionResource) bool {
+	resources, err := client.ServerResourcesForGroupVersion(resource.GroupVersion().String())
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			klog.Errorf("failed to discover %s: %v", resource, err)
+		}
+		return false
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == resource.Resource {
+			return true
+		}
+	}
+	return false
+}
+
+// selectClusters selects the clusters amongst the candidates, in order of
+// preference, up to the maximum number of replicas, and so that the spread
+// constraints are honoured.
+func selectClusters(candidates []*unstructured.Unstructured, spec v1.IngressPlacementSpec) []string {
+	// The clusters without the topology labels cannot be spread
+	var eligible []*unstructured.Unstructured
+	for _, candidate := range candidates {
+		ok := true
+		for _, constraint := range spec.SpreadConstraints {
+			if _, has := candidate.GetLabels()[constraint.TopologyKey]; !has {
+				ok = false
+			}
+		}
+		if ok {
+			eligible = append(eligible, candidate)
+		}
+	}
+
+	// counts holds the number of selected clusters, per constraint and topology value
+	counts := make([]map[string]int, len(spec.SpreadConstraints))
+	for i, constraint := range spec.SpreadConstraints {
+		counts[i] = map[string]int{}
+		for _, candidate := range eligible {
+			counts[i][candidate.GetLabels()[constraint.TopologyKey]] = 0
+		}
+	}
+
+	var selected []string
+	remaining := eligible
+	for spec.MaxReplicas == nil || len(selected) < int(*spec.MaxReplicas) {
+		next := -1
+		for i, candidate := range remaining {
+			if fitsSpread(candidate, spec.SpreadConstraints, counts) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+
+		candidate := remaining[next]
+		for i, constraint := range spec.SpreadConstraints {
+			counts[i][candidate.GetLabels()[constraint.TopologyKey]]++
+		}
+		selected = append(selected, candidate.GetName())
+		remaining = append(remaining[:next:next], remaining[next+1:]...)
+	}
+
+	return selected
+}
+
+// fitsSpread returns whether selecting the cluster keeps the difference
+// between the number of clusters selected for any two topology values within
+// the maximum skew of each constraint.
+func fitsSpread(cluster *unstructured.Unstructured, constraints []v1.SpreadConstraint, counts []map[string]int) bool {
+	for i, constraint := range constraints {
+		maxSkew := 1
+		if constraint.MaxSkew != nil {
+			maxSkew = int(*constraint.MaxSkew)
+		}
+		min := -1
+		for _, count := range counts[i] {
+			if min < 0 || count < min {
+				min = count
+			}
+		}
+		if counts[i][cluster.GetLabels()[constraint.TopologyKey]]+1-min > maxSkew {
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueClusters returns the clusters, without duplicates, in order.
+func uniqueClusters(clusters []string) []string {
+	seen := map[string]struct{}{}
+	var unique []string
+	for _, cluster := range clusters {
+		if _, ok := seen[cluster]; ok {
+			continue
+		}
+		seen[cluster] = struct{}{}
+		unique = append(unique, cluster)
+	}
+	return unique
+}
+
+// placedIngresses enqueues the root Ingresses selected by an IngressPlacement,
+// when the clusters they can be placed on change.
+func (c *Controller) placedIngresses() {
+	ingresses, err := c.lister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, ingress := range ingresses {
+		if ingress.Labels[clusterLabel] != "" {
+			continue
+		}
+		placement, err := c.placementFor(ingress)
+		if err != nil {
+			runtime.HandleError(err)
+			return
+		}
+		if placement != nil {
+			c.enqueue(ingress)
+		}
+	}
+}
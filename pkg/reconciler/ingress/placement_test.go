@@ -0,0 +1,193 @@
+package ingress
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+)
+
+func newCluster(name string, clusterLabels map[string]string) *unstructured.Unstructured {
+	cluster := &unstructured.Unstructured{}
+	cluster.SetName(name)
+	cluster.SetLabels(clusterLabels)
+	return cluster
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestSelectClusters(t *testing.T) {
+	zone := func(key string, maxSkew *int32) []v1.SpreadConstraint {
+		return []v1.SpreadConstraint{{TopologyKey: key, MaxSkew: maxSkew}}
+	}
+	candidates := []*unstructured.Unstructured{
+		newCluster("a", map[string]string{"region": "eu", "zone": "eu-1"}),
+		newCluster("b", map[string]string{"region": "eu", "zone": "eu-1"}),
+		newCluster("c", map[string]string{"region": "eu", "zone": "eu-2"}),
+		newCluster("d", map[string]string{"region": "us"}),
+	}
+
+	tests := []struct {
+		name string
+		spec v1.IngressPlacementSpec
+		want []string
+	}{
+		{
+			name: "all the candidates, in order of preference",
+			want: []string{"a", "b", "c", "d"},
+		},
+		{
+			name: "up to the maximum number of replicas",
+			spec: v1.IngressPlacementSpec{MaxReplicas: int32Ptr(2)},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "spread across the zones",
+			spec: v1.IngressPlacementSpec{MaxReplicas: int32Ptr(2), SpreadConstraints: zone("zone", nil)},
+			want: []string{"a", "c"},
+		},
+		{
+			name: "spread across the zones, without maximum number of replicas",
+			spec: v1.IngressPlacementSpec{SpreadConstraints: zone("zone", nil)},
+			// d has no zone, and cannot be spread
+			want: []string{"a", "c", "b"},
+		},
+		{
+			name: "spread across the zones, with a larger skew",
+			spec: v1.IngressPlacementSpec{MaxReplicas: int32Ptr(2), SpreadConstraints: zone("zone", int32Ptr(2))},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "spread across the regions and the zones",
+			spec: v1.IngressPlacementSpec{
+				SpreadConstraints: []v1.SpreadConstraint{{TopologyKey: "region"}, {TopologyKey: "zone"}},
+			},
+			// Only the clusters of the eu region have a zone, and are spread across the zones
+			want: []string{"a", "c", "b"},
+		},
+		{
+			name: "spread across a label no cluster has",
+			spec: v1.IngressPlacementSpec{SpreadConstraints: zone("rack", nil)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectClusters(candidates, tt.spec)
+			if !equalStrings(got, tt.want) {
+				t.Errorf("selectClusters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFitsSpread(t *testing.T) {
+	tests := []struct {
+		name        string
+		cluster     *unstructured.Unstructured
+		constraints []v1.SpreadConstraint
+		counts      []map[string]int
+		want        bool
+	}{
+		{
+			name:    "no constraint",
+			cluster: newCluster("a", nil),
+			want:    true,
+		},
+		{
+			name:        "least selected value",
+			cluster:     newCluster("a", map[string]string{"zone": "eu-2"}),
+			constraints: []v1.SpreadConstraint{{TopologyKey: "zone"}},
+			counts:      []map[string]int{{"eu-1": 1, "eu-2": 0}},
+			want:        true,
+		},
+		{
+			name:        "most selected value",
+			cluster:     newCluster("a", map[string]string{"zone": "eu-1"}),
+			constraints: []v1.SpreadConstraint{{TopologyKey: "zone"}},
+			counts:      []map[string]int{{"eu-1": 1, "eu-2": 0}},
+		},
+		{
+			name:        "most selected value, within the skew",
+			cluster:     newCluster("a", map[string]string{"zone": "eu-1"}),
+			constraints: []v1.SpreadConstraint{{TopologyKey: "zone", MaxSkew: int32Ptr(2)}},
+			counts:      []map[string]int{{"eu-1": 1, "eu-2": 0}},
+			want:        true,
+		},
+		{
+			name:        "evenly selected values",
+			cluster:     newCluster("a", map[string]string{"zone": "eu-1"}),
+			constraints: []v1.SpreadConstraint{{TopologyKey: "zone"}},
+			counts:      []map[string]int{{"eu-1": 1, "eu-2": 1}},
+			want:        true,
+		},
+		{
+			name:        "one of several constraints not honoured",
+			cluster:     newCluster("a", map[string]string{"region": "eu", "zone": "eu-1"}),
+			constraints: []v1.SpreadConstraint{{TopologyKey: "region"}, {TopologyKey: "zone"}},
+			counts:      []map[string]int{{"eu": 0, "us": 0}, {"eu-1": 1, "eu-2": 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fitsSpread(tt.cluster, tt.constraints, tt.counts); got != tt.want {
+				t.Errorf("fitsSpread() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDiscoverable(t *testing.T) {
+	tests := []struct {
+		name      string
+		resources []*metav1.APIResourceList
+		want      bool
+	}{
+		{
+			name: "served resource",
+			resources: []*metav1.APIResourceList{{
+				GroupVersion: clusterResource.GroupVersion().String(),
+				APIResources: []metav1.APIResource{{Name: "clusters"}},
+			}},
+			want: true,
+		},
+		{
+			name: "other resource of the group",
+			resources: []*metav1.APIResourceList{{
+				GroupVersion: clusterResource.GroupVersion().String(),
+				APIResources: []metav1.APIResource{{Name: "others"}},
+			}},
+		},
+		{
+			name: "group not served",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: tt.resources}}
+			if got := isDiscoverable(client, clusterResource); got != tt.want {
+				t.Errorf("isDiscoverable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,370 @@
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	v1 "github.com/kuadrant/kcp-ingress/pkg/apis/kuadrant/v1"
+	"github.com/kuadrant/kcp-ingress/pkg/dns"
+)
+
+// statusAnnotation reports the state of the leaves of the root Ingress, per
+// cluster, as a JSON list, since the Ingress status can only hold the
+// load-balancers.
+const statusAnnotation = "kuadrant.dev/status"
+
+// The conditions of the leaves of a root Ingress.
+const (
+	leafCreatedConditionType    = "Created"
+	leafAdmittedConditionType   = "Admitted"
+	dnsPublishedConditionType   = "DNSPublished"
+	backendMissingConditionType = "BackendMissing"
+	hostsVerifiedConditionType  = "HostsVerified"
+	activeConditionType         = "Active"
+)
+
+// clusterStatus is the state of the leaf of a root Ingress on a cluster.
+type clusterStatus struct {
+	Cluster    string             `json:"cluster"`
+	Leaf       string             `json:"leaf"`
+	Conditions []metav1.Condition `json:"conditions"`
+}
+
+// reconcileStatus records the state of the given leaves of the root Ingress in
+// its annotations, and reports the conditions that have changed as events.
+func (c *Controller) reconcileStatus(ctx context.Context, root *networkingv1.Ingress, leaves []*networkingv1.Ingress) error {
+	var current []clusterStatus
+	if value, ok := root.Annotations[statusAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &current); err != nil {
+			current = nil
+		}
+	}
+
+	records, err := c.dnsRecordLister.DNSRecords(root.Namespace).List(labels.Everything())
+	if err != nil {
+		return err
+	}
+	var owned []*v1.DNSRecord
+	for _, record := range records {
+		if isControlledBy(record, root) {
+			owned = append(owned, record)
+		}
+	}
+
+	var backends []backendCondition
+	if value, ok := root.Annotations[backendsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(value), &backends); err != nil {
+			backends = nil
+		}
+	}
+
+	active, failover, err := c.activeCluster(root, leaves)
+	if err != nil {
+		return err
+	}
+
+	_, unverified, err := c.hosts(root)
+	if err != nil {
+		return err
+	}
+	hosts := hostsVerifiedCondition(unverified)
+
+	statuses := make([]clusterStatus, 0, len(leaves))
+	for _, leaf := range leaves {
+		cluster := leaf.Labels[clusterLabel]
+		var previous []metav1.Condition
+		for _, s := range current {
+			if s.Cluster == cluster {
+				previous = s.Conditions
+			}
+		}
+
+		conditions := []metav1.Condition{
+			leafCreatedCondition(leaf),
+			leafAdmittedCondition(leaf),
+			dnsPublishedCondition(leaf, owned),
+			backendMissingCondition(backends),
+			hosts,
+		}
+		if failover {
+			conditions = append(conditions, activeCondition(cluster, active))
+		}
+		for i := range conditions {
+			conditions[i] = c.transitionCondition(root, cluster, previous, conditions[i])
+		}
+		statuses = append(statuses, clusterStatus{Cluster: cluster, Leaf: leaf.Name, Conditions: conditions})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Cluster < statuses[j].Cluster })
+
+	// The hosts are the root Ingress ones, so their changes are reported once rather than per cluster
+	if len(statuses) > 0 {
+		c.reportHostsVerified(root, current, hosts)
+	}
+
+	for _, s := range current {
+		found := false
+		for _, status := range statuses {
+			found = found || status.Cluster == s.Cluster
+		}
+		if !found {
+			c.recorder.Eventf(root, corev1.EventTypeNormal, "LeafDeleted", "Leaf Ingress %q deleted from cluster %q", s.Leaf, s.Cluster)
+		}
+	}
+
+	if equality.Semantic.DeepEqual(current, statuses) {
+		return nil
+	}
+	value, err := json.Marshal(statuses)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{statusAnnotation: string(value)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = c.patchIngress(ctx, root, patch)
+	return err
+}
+
+// transitionCondition sets the condition transition time, preserving the
+// previous one if the condition status has not changed. Otherwise, the change
+// is reported as an event on the root Ingress, as a warning if the condition
+// was true, except for the missing backends which are reported per backend, and
+// the unverified hosts which are reported once for all the clusters.
+func (c *Controller) transitionCondition(root *networkingv1.Ingress, cluster string, previous []metav1.Condition, condition metav1.Condition) metav1.Condition {
+	condition.LastTransitionTime = metav1.Now()
+	degraded := false
+	for _, p := range previous {
+		if p.Type != condition.Type {
+			continue
+		}
+		if p.Status == condition.Status {
+			condition.LastTransitionTime = p.LastTransitionTime
+			return condition
+		}
+		degraded = p.Status == metav1.ConditionTrue
+	}
+
+	if condition.Type == backendMissingConditionType || condition.Type == hostsVerifiedConditionType {
+		return condition
+	}
+	eventType := corev1.EventTypeNormal
+	if degraded {
+		eventType = corev1.EventTypeWarning
+	}
+	c.recorder.Eventf(root, eventType, condition.Reason, "Cluster %q: %s", cluster, condition.Message)
+	return condition
+}
+
+// reportHostsVerified reports the change of the verification of the root
+// Ingress hosts as an event, given the current status.
+func (c *Controller) reportHostsVerified(root *networkingv1.Ingress, current []clusterStatus, condition metav1.Condition) {
+	var previous *metav1.Condition
+	for _, s := range current {
+		for i := range s.Conditions {
+			if s.Conditions[i].Type == hostsVerifiedConditionType {
+				previous = &s.Conditions[i]
+			}
+		}
+	}
+
+	switch {
+	case condition.Status == metav1.ConditionFalse && (previous == nil || previous.Status != condition.Status || previous.Message != condition.Message):
+		c.recorder.Event(root, corev1.EventTypeWarning, condition.Reason, condition.Message)
+	case condition.Status == metav1.ConditionTrue && previous != nil && previous.Status != condition.Status:
+		c.recorder.Event(root, corev1.EventTypeNormal, condition.Reason, condition.Message)
+	}
+}
+
+func leafCreatedCondition(leaf *networkingv1.Ingress) metav1.Condition {
+	if leaf.UID == "" {
+		return metav1.Condition{
+			Type:    leafCreatedConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  "LeafPending",
+			Message: fmt.Sprintf("The leaf Ingress %q has not been created yet", leaf.Name),
+		}
+	}
+	return metav1.Condition{
+		Type:    leafCreatedConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "LeafCreated",
+		Message: fmt.Sprintf("The leaf Ingress %q has been created", leaf.Name),
+	}
+}
+
+func leafAdmittedCondition(leaf *networkingv1.Ingress) metav1.Condition {
+	if len(leaf.Status.LoadBalancer.Ingress) == 0 {
+		return metav1.Condition{
+			Type:    leafAdmittedConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  "LoadBalancerPending",
+			Message: fmt.Sprintf("The leaf Ingress %q has no load-balancer yet", leaf.Name),
+		}
+	}
+	return metav1.Condition{
+		Type:    leafAdmittedConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "LoadBalancerReady",
+		Message: fmt.Sprintf("The leaf Ingress %q has been admitted", leaf.Name),
+	}
+}
+
+// dnsPublishedCondition returns whether the load-balancer of the leaf is
+// published, i.e., its targets are answered by the DNSRecords of the root
+// Ingress, which have been published to their zones.
+func dnsPublishedCondition(leaf *networkingv1.Ingress, records []*v1.DNSRecord) metav1.Condition {
+	condition := metav1.Condition{
+		Type:    dnsPublishedConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  "NotInDNSRecords",
+		Message: "The load-balancer is not in the DNS records",
+	}
+
+	cluster := leaf.Labels[clusterLabel]
+	hostnames := map[string]bool{}
+	for _, lb := range leaf.Status.LoadBalancer.Ingress {
+		if lb.Hostname != "" {
+			hostnames[lb.Hostname] = true
+		}
+	}
+
+	var published []string
+	for _, record := range records {
+		var targets []dns.Target
+		for _, target := range dns.Targets(record) {
+			// The CNAME targets are not attributed to the clusters
+			if target.Cluster == cluster || target.Cluster == "" && hostnames[target.Value] {
+				targets = append(targets, target)
+			}
+		}
+		if len(targets) == 0 {
+			continue
+		}
+
+		drained, unhealthy := true, true
+		for _, target := range targets {
+			drained = drained && target.Weight == 0
+			unhealthy = unhealthy && !target.Healthy
+		}
+		switch {
+		case drained:
+			condition.Reason = "Drained"
+			condition.Message = fmt.Sprintf("The targets of DNSRecord %q are drained", record.Name)
+			return condition
+		case unhealthy:
+			condition.Reason = "Unhealthy"
+			condition.Message = fmt.Sprintf("The targets of DNSRecord %q are unhealthy", record.Name)
+			return condition
+		case len(record.Status.Zones) == 0:
+			condition.Reason = "NoManagedZone"
+			condition.Message = fmt.Sprintf("The DNSRecord %q is not published to any zone", record.Name)
+			return condition
+		}
+		for _, zone := range record.Status.Zones {
+			for _, c := range zone.Conditions {
+				if c.Type == v1.DNSRecordPublishedConditionType && c.Status != string(metav1.ConditionTrue) {
+					condition.Reason = "PublishFailed"
+					condition.Message = fmt.Sprintf("The DNSRecord %q failed to be published to zone %q: %s", record.Name, zone.DNSZone.ID, c.Message)
+					return condition
+				}
+			}
+		}
+		published = append(published, record.Spec.DNSName+" "+string(record.Spec.RecordType))
+	}
+
+	if len(published) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Published"
+		condition.Message = "The load-balancer is published in " + strings.Join(published, ", ")
+	}
+	return condition
+}
+
+// activeCondition returns whether the cluster is the active one of the root
+// Ingress in failover mode.
+func activeCondition(cluster, active string) metav1.Condition {
+	if cluster == active {
+		return metav1.Condition{
+			Type:    activeConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Active",
+			Message: "The cluster is active and takes the traffic",
+		}
+	}
+	message := "The cluster stands by, no cluster being active"
+	if active != "" {
+		message = fmt.Sprintf("The cluster stands by, cluster %q being active", active)
+	}
+	return metav1.Condition{
+		Type:    activeConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  "Standby",
+		Message: message,
+	}
+}
+
+func backendMissingCondition(backends []backendCondition) metav1.Condition {
+	var missing []string
+	for _, backend := range backends {
+		if backend.Status != metav1.ConditionTrue {
+			missing = append(missing, backend.Service)
+		}
+	}
+	if len(missing) > 0 {
+		return metav1.Condition{
+			Type:    backendMissingConditionType,
+			Status:  metav1.ConditionTrue,
+			Reason:  "BackendMissing",
+			Message: "The backend Services cannot be resolved: " + strings.Join(missing, ", "),
+		}
+	}
+	return metav1.Condition{
+		Type:    backendMissingConditionType,
+		Status:  metav1.ConditionFalse,
+		Reason:  "BackendsResolved",
+		Message: "All the backend Services have been resolved",
+	}
+}
+
+// hostsVerifiedCondition returns whether all the hosts of the root Ingress are
+// verified, and the reason why the others are not exposed otherwise.
+func hostsVerifiedCondition(unverified []unverifiedHost) metav1.Condition {
+	if len(unverified) > 0 {
+		reasons := make([]string, 0, len(unverified))
+		for _, u := range unverified {
+			reasons = append(reasons, fmt.Sprintf("%s (%v)", u.host, u.reason))
+		}
+		return metav1.Condition{
+			Type:    hostsVerifiedConditionType,
+			Status:  metav1.ConditionFalse,
+			Reason:  "HostsNotVerified",
+			Message: "The hosts are not exposed: " + strings.Join(reasons, ", "),
+		}
+	}
+	return metav1.Condition{
+		Type:    hostsVerifiedConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  "HostsVerified",
+		Message: "All the hosts are verified",
+	}
+}
+
+// isPublicationChanged returns whether the publication of the DNSRecord to its
+// zones has changed.
+func isPublicationChanged(old, new *v1.DNSRecord) bool {
+	return !equality.Semantic.DeepEqual(old.Status.Zones, new.Status.Zones)
+}
@@ -0,0 +1,86 @@
+package ingress
+
+import (
+	"errors"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestReportHostsVerified(t *testing.T) {
+	unverified := []unverifiedHost{
+		{host: "app.example.com", reason: errors.New("no DomainClaim covers the host")},
+		{host: "app.example.org", reason: errors.New("no DomainClaim covers the host")},
+	}
+	status := func(condition metav1.Condition) []clusterStatus {
+		return []clusterStatus{{Cluster: "cluster-1", Leaf: "app--cluster-1", Conditions: []metav1.Condition{condition}}}
+	}
+
+	tests := []struct {
+		name        string
+		current     []clusterStatus
+		unverified  []unverifiedHost
+		wantStatus  metav1.ConditionStatus
+		wantMessage string
+		wantEvents  []string
+	}{
+		{
+			name:        "verified hosts",
+			wantStatus:  metav1.ConditionTrue,
+			wantMessage: "All the hosts are verified",
+		},
+		{
+			name:        "unverified hosts",
+			unverified:  unverified,
+			wantStatus:  metav1.ConditionFalse,
+			wantMessage: "The hosts are not exposed: app.example.com (no DomainClaim covers the host), app.example.org (no DomainClaim covers the host)",
+			wantEvents:  []string{"Warning HostsNotVerified The hosts are not exposed: app.example.com (no DomainClaim covers the host), app.example.org (no DomainClaim covers the host)"},
+		},
+		{
+			name:        "hosts still unverified",
+			current:     status(hostsVerifiedCondition(unverified)),
+			unverified:  unverified,
+			wantStatus:  metav1.ConditionFalse,
+			wantMessage: "The hosts are not exposed: app.example.com (no DomainClaim covers the host), app.example.org (no DomainClaim covers the host)",
+		},
+		{
+			name:        "some hosts verified",
+			current:     status(hostsVerifiedCondition(unverified)),
+			unverified:  unverified[1:],
+			wantStatus:  metav1.ConditionFalse,
+			wantMessage: "The hosts are not exposed: app.example.org (no DomainClaim covers the host)",
+			wantEvents:  []string{"Warning HostsNotVerified The hosts are not exposed: app.example.org (no DomainClaim covers the host)"},
+		},
+		{
+			name:        "all hosts verified",
+			current:     status(hostsVerifiedCondition(unverified)),
+			wantStatus:  metav1.ConditionTrue,
+			wantMessage: "All the hosts are verified",
+			wantEvents:  []string{"Normal HostsVerified All the hosts are verified"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			c := &Controller{recorder: recorder}
+
+			condition := hostsVerifiedCondition(tt.unverified)
+			if condition.Status != tt.wantStatus || condition.Message != tt.wantMessage {
+				t.Errorf("condition = %v, want status %s and message %q", condition, tt.wantStatus, tt.wantMessage)
+			}
+
+			c.reportHostsVerified(&networkingv1.Ingress{}, tt.current, condition)
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			if !equalStrings(events, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", events, tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+package ingress
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clusters"
+	"k8s.io/klog"
+
+	"github.com/kuadrant/kcp-ingress/pkg/acme"
+)
+
+// tlsSecretLabel is set on the copies of the TLS Secrets placed on the leaf
+// clusters, to the name of the Secret they are copied from. The Secrets without
+// it are not owned by the controller, and are never overwritten.
+const tlsSecretLabel = "kuadrant.dev/tls-secret"
+
+const tlsSecretConflictReason = "TLSSecretConflict"
+
+// leafSecretName returns the name of the copy of the TLS Secret placed on the
+// cluster, which is shared by the leaves on that cluster referencing it.
+func leafSecretName(secret, cluster string) string {
+	return fmt.Sprintf("%s--%s", secret, cluster)
+}
+
+// leafTLS returns the TLS configuration of the leaf on the cluster, i.e., the
+// root Ingress TLS configuration, with the Secrets copied to the cluster. The
+// global host gets the certificate obtained for it if any, or is otherwise
+// added to the entries covering the rules hosts, as the global rules duplicate
+// them.
+func leafTLS(root *networkingv1.Ingress, cluster string, certificate bool) []networkingv1.IngressTLS {
+	hostname := root.Annotations[hostGeneratedAnnotation]
+	if len(root.Spec.TLS) == 0 && !certificate {
+		return nil
+	}
+
+	ruleHosts := sets.NewString()
+	for _, rule := range root.Spec.Rules {
+		if rule.Host != "" {
+			ruleHosts.Insert(rule.Host)
+		}
+	}
+
+	tls := make([]networkingv1.IngressTLS, 0, len(root.Spec.TLS)+1)
+	for _, t := range root.Spec.TLS {
+		leafTLS := *t.DeepCopy()
+		if t.SecretName != "" {
+			leafTLS.SecretName = leafSecretName(t.SecretName, cluster)
+		}
+		hosts := sets.NewString(t.Hosts...)
+		if hostname != "" && !certificate && hosts.HasAny(ruleHosts.UnsortedList()...) && !hosts.Has(hostname) {
+			leafTLS.Hosts = append(leafTLS.Hosts, hostname)
+		}
+		tls = append(tls, leafTLS)
+	}
+	if certificate {
+		tls = append(tls, networkingv1.IngressTLS{
+			Hosts:      []string{hostname},
+			SecretName: leafSecretName(acme.CertificateSecretName(root.Name), cluster),
+		})
+	}
+	return tls
+}
+
+// tlsSecretNames returns the names of the TLS Secrets referenced by the root
+// Ingress TLS configuration.
+func tlsSecretNames(root *networkingv1.Ingress) []string {
+	var names []string
+	for _, t := range root.Spec.TLS {
+		if t.SecretName != "" {
+			names = append(names, t.SecretName)
+		}
+	}
+	return names
+}
+
+// certificateSecretName returns the name of the Secret holding the certificate
+// obtained for the generated host of the root Ingress, or an empty string if
+// the certificates are not obtained from an ACME server.
+func (c *Controller) certificateSecretName(root *networkingv1.Ingress) string {
+	if !c.acmeCertificates || root.Annotations[hostGeneratedAnnotation] == "" {
+		return ""
+	}
+	return acme.CertificateSecretName(root.Name)
+}
+
+// hasCertificate returns whether a certificate has been obtained for the
+// generated host of the root Ingress.
+func (c *Controller) hasCertificate(root *networkingv1.Ingress) (bool, error) {
+	name := c.certificateSecretName(root)
+	if name == "" {
+		return false, nil
+	}
+	_, err := c.secretLister.Secrets(root.Namespace).Get(clusters.ToClusterAwareKey(root.ClusterName, name))
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// deleteCertificate deletes the Secret holding the certificate obtained for the
+// generated host of the root Ingress, as kcp doesn't garbage collect it along
+// with the Ingress that owns it.
+func (c *Controller) deleteCertificate(ctx context.Context, root *networkingv1.Ingress) error {
+	if !c.acmeCertificates {
+		return nil
+	}
+	name := acme.CertificateSecretName(root.Name)
+	secret, err := c.secretLister.Secrets(root.Namespace).Get(clusters.ToClusterAwareKey(root.ClusterName, name))
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	// Only the certificate obtained for this very Ingress is deleted
+	if !isOwnedBy(secret.OwnerReferences, root) {
+		return nil
+	}
+	klog.Infof("Deleting certificate Secret %q of Ingress %q", name, root.Name)
+	if err := c.client.CoreV1().Secrets(root.Namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// isOwnedBy returns whether the owner references include the Ingress.
+func isOwnedBy(owners []metav1.OwnerReference, ingress *networkingv1.Ingress) bool {
+	for _, owner := range owners {
+		if owner.Kind == "Ingress" && owner.Name == ingress.Name && owner.UID == ingress.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileTLSSecrets copies the TLS Secrets referenced by the root Ingress to
+// the clusters of its desired leaves, and deletes the copies that are no longer
+// referenced by any leaf, or which Secret has been deleted. The copies are
+// updated when their Secret changes, e.g., when the certificate is rotated.
+func (c *Controller) reconcileTLSSecrets(ctx context.Context, root *networkingv1.Ingress, desiredLeaves []*networkingv1.Ingress) error {
+	names := tlsSecretNames(root)
+	certificate, err := c.hasCertificate(root)
+	if err != nil {
+		return err
+	}
+	if certificate {
+		names = append(names, c.certificateSecretName(root))
+	}
+
+	desired := map[string]*corev1.Secret{}
+	for _, name := range names {
+		secret, err := c.secretLister.Secrets(root.Namespace).Get(clusters.ToClusterAwareKey(root.ClusterName, name))
+		if errors.IsNotFound(err) {
+			klog.Infof("TLS Secret %q of Ingress %q not found", name, root.Name)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		for _, leaf := range desiredLeaves {
+			cluster := leaf.Labels[clusterLabel]
+			desired[leafSecretName(secret.Name, cluster)] = &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        leafSecretName(secret.Name, cluster),
+					Namespace:   root.Namespace,
+					ClusterName: root.ClusterName,
+					Labels: map[string]string{
+						clusterLabel:   cluster,
+						tlsSecretLabel: secret.Name,
+					},
+				},
+				Type: secret.Type,
+				Data: secret.Data,
+			}
+		}
+	}
+
+	for _, secret := range desired {
+		if err := c.applySecret(ctx, root, secret); err != nil {
+			return err
+		}
+	}
+
+	// Delete the copies of the Secrets referenced by the root Ingress that are not desired
+	// anymore, unless the leaves of other root Ingresses reference them.
+	referenced, err := c.referencedSecrets(root)
+	if err != nil {
+		return err
+	}
+	// The copies of the certificate are deleted along with it
+	if name := c.certificateSecretName(root); name != "" && !certificate {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		copies, err := c.secretLister.Secrets(root.Namespace).List(labels.SelectorFromSet(labels.Set{tlsSecretLabel: name}))
+		if err != nil {
+			return err
+		}
+		for _, leafSecret := range copies {
+			if leafSecret.ClusterName != root.ClusterName || desired[leafSecret.Name] != nil || referenced.Has(leafSecret.Name) {
+				continue
+			}
+			klog.Infof("Deleting TLS Secret %q", leafSecret.Name)
+			if err := c.client.CoreV1().Secrets(leafSecret.Namespace).Delete(ctx, leafSecret.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// referencedSecrets returns the names of the TLS Secrets referenced by the
+// leaves of the other root Ingresses in the namespace of the root Ingress.
+func (c *Controller) referencedSecrets(root *networkingv1.Ingress) (sets.String, error) {
+	leaves, err := c.lister.Ingresses(root.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	referenced := sets.NewString()
+	for _, leaf := range leaves {
+		if leaf.ClusterName != root.ClusterName || leaf.Labels[clusterLabel] == "" || leaf.Labels[ownedByLabel] == root.Name {
+			continue
+		}
+		for _, t := range leaf.Spec.TLS {
+			referenced.Insert(t.SecretName)
+		}
+	}
+	return referenced, nil
+}
+
+// applySecret creates or updates the copy of a TLS Secret, unless a Secret
+// with the same name, which is not a copy of that TLS Secret, already exists,
+// in which case a warning event is recorded on the root Ingress.
+func (c *Controller) applySecret(ctx context.Context, root *networkingv1.Ingress, secret *corev1.Secret) error {
+	existing, err := c.secretLister.Secrets(secret.Namespace).Get(clusters.ToClusterAwareKey(secret.ClusterName, secret.Name))
+	if errors.IsNotFound(err) {
+		klog.Infof("Copying TLS Secret %q to cluster %q", secret.Labels[tlsSecretLabel], secret.Labels[clusterLabel])
+		_, err = c.client.CoreV1().Secrets(secret.Namespace).Create(ctx, secret, metav1.CreateOptions{})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	if existing.Labels[tlsSecretLabel] != secret.Labels[tlsSecretLabel] {
+		klog.Infof("Secret %q is not a copy of TLS Secret %q, not overwriting it", secret.Name, secret.Labels[tlsSecretLabel])
+		c.recorder.Eventf(root, corev1.EventTypeWarning, tlsSecretConflictReason, "Secret %q already exists, and is not a copy of TLS Secret %q for cluster %q", secret.Name, secret.Labels[tlsSecretLabel], secret.Labels[clusterLabel])
+		return nil
+	}
+
+	if existing.Type == secret.Type && equality.Semantic.DeepEqual(existing.Data, secret.Data) && equality.Semantic.DeepEqual(existing.Labels, secret.Labels) {
+		return nil
+	}
+	updated := existing.DeepCopy()
+	updated.Labels = secret.Labels
+	updated.Type = secret.Type
+	updated.Data = secret.Data
+	klog.Infof("Updating TLS Secret %q on cluster %q", secret.Labels[tlsSecretLabel], secret.Labels[clusterLabel])
+	_, err = c.client.CoreV1().Secrets(secret.Namespace).Update(ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+// ingressesFromSecret enqueues the root Ingresses referencing the TLS Secret,
+// or the Secret a copy has been made from, so that the copies are kept in sync,
+// as well as the root Ingress the certificate of which it holds.
+func (c *Controller) ingressesFromSecret(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+	name := secret.Name
+	if source := secret.Labels[tlsSecretLabel]; source != "" {
+		name = source
+	}
+
+	ingresses, err := c.lister.Ingresses(secret.Namespace).List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(err)
+		return
+	}
+	for _, ingress := range ingresses {
+		if ingress.ClusterName != secret.ClusterName || ingress.Labels[clusterLabel] != "" {
+			continue
+		}
+		for _, secretName := range append(tlsSecretNames(ingress), c.certificateSecretName(ingress)) {
+			if secretName == name {
+				klog.Infof("TLS Secret %q triggered Ingress %q reconciliation", secret.Name, ingress.Name)
+				c.enqueue(ingress)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package ingress
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+// newSecretController returns a Controller which Secret lister and client
+// hold the given Secrets.
+func newSecretController(t *testing.T, secrets ...*corev1.Secret) (*Controller, *kubefake.Clientset, *record.FakeRecorder) {
+	t.Helper()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	objects := make([]runtime.Object, 0, len(secrets))
+	for _, secret := range secrets {
+		if err := indexer.Add(secret); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		objects = append(objects, secret)
+	}
+	client := kubefake.NewSimpleClientset(objects...)
+	recorder := record.NewFakeRecorder(10)
+	return &Controller{client: client, recorder: recorder, secretLister: corev1lister.NewSecretLister(indexer)}, client, recorder
+}
+
+func TestApplySecret(t *testing.T) {
+	newSecret := func(labels map[string]string, data string) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{ClusterName: "workspace", Namespace: "default", Name: leafSecretName("tls", "cluster-1"), Labels: labels},
+			Type:       corev1.SecretTypeTLS,
+			Data:       map[string][]byte{corev1.TLSCertKey: []byte(data)},
+		}
+	}
+	copyLabels := map[string]string{clusterLabel: "cluster-1", tlsSecretLabel: "tls"}
+
+	tests := []struct {
+		name       string
+		existing   *corev1.Secret
+		wantAction string
+		wantData   string
+		wantEvents []string
+	}{
+		{
+			name:       "missing copy",
+			wantAction: "create",
+			wantData:   "certificate",
+		},
+		{
+			name:       "outdated copy",
+			existing:   newSecret(copyLabels, "expired"),
+			wantAction: "update",
+			wantData:   "certificate",
+		},
+		{
+			name:     "up-to-date copy",
+			existing: newSecret(copyLabels, "certificate"),
+			wantData: "certificate",
+		},
+		{
+			name:       "Secret that is not a copy",
+			existing:   newSecret(nil, "other"),
+			wantData:   "other",
+			wantEvents: []string{`Warning TLSSecretConflict Secret "tls--cluster-1" already exists, and is not a copy of TLS Secret "tls" for cluster "cluster-1"`},
+		},
+		{
+			name:       "copy of another Secret",
+			existing:   newSecret(map[string]string{clusterLabel: "cluster-1", tlsSecretLabel: "other"}, "other"),
+			wantData:   "other",
+			wantEvents: []string{`Warning TLSSecretConflict Secret "tls--cluster-1" already exists, and is not a copy of TLS Secret "tls" for cluster "cluster-1"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var existing []*corev1.Secret
+			if tt.existing != nil {
+				existing = append(existing, tt.existing)
+			}
+			c, client, recorder := newSecretController(t, existing...)
+			root := newBackendIngress(nil)
+
+			if err := c.applySecret(context.Background(), root, newSecret(copyLabels, "certificate")); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var action string
+			for _, a := range client.Actions() {
+				if a.GetVerb() != "get" && a.GetVerb() != "list" && a.GetVerb() != "watch" {
+					action = a.GetVerb()
+				}
+			}
+			if action != tt.wantAction {
+				t.Errorf("action = %q, want %q", action, tt.wantAction)
+			}
+			secret, err := client.CoreV1().Secrets("default").Get(context.Background(), leafSecretName("tls", "cluster-1"), metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := string(secret.Data[corev1.TLSCertKey]); got != tt.wantData {
+				t.Errorf("data = %q, want %q", got, tt.wantData)
+			}
+
+			close(recorder.Events)
+			var events []string
+			for event := range recorder.Events {
+				events = append(events, event)
+			}
+			if !equalStrings(events, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", events, tt.wantEvents)
+			}
+		})
+	}
+}
+
+func TestDeleteCertificate(t *testing.T) {
+	root := newRootIngress("workspace", "default", "app", nil)
+	root.UID = "uid"
+	certificate := func(workspace string, owner types.UID) *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				ClusterName:     workspace,
+				Namespace:       "default",
+				Name:            "app-acme-tls",
+				OwnerReferences: []metav1.OwnerReference{{Kind: "Ingress", Name: "app", UID: owner}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name             string
+		acmeCertificates bool
+		certificate      *corev1.Secret
+		wantDeleted      bool
+	}{
+		{
+			name:             "certificate of the Ingress",
+			acmeCertificates: true,
+			certificate:      certificate("workspace", "uid"),
+			wantDeleted:      true,
+		},
+		{
+			name:             "certificate of another Ingress with the same name",
+			acmeCertificates: true,
+			certificate:      certificate("workspace", "other"),
+		},
+		{
+			name:             "certificate in another workspace",
+			acmeCertificates: true,
+			certificate:      certificate("other", "uid"),
+		},
+		{
+			name:             "no certificate",
+			acmeCertificates: true,
+		},
+		{
+			name:        "certificates not obtained from an ACME server",
+			certificate: certificate("workspace", "uid"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var secrets []*corev1.Secret
+			if tt.certificate != nil {
+				secrets = append(secrets, tt.certificate)
+			}
+			c, client, _ := newSecretController(t, secrets...)
+			c.acmeCertificates = tt.acmeCertificates
+
+			if err := c.deleteCertificate(context.Background(), root); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := len(deleted(client.Actions())) > 0; got != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", got, tt.wantDeleted)
+			}
+		})
+	}
+}
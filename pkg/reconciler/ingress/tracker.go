@@ -40,7 +40,9 @@ func (t *Tracker) add(ingress *networkingv1.Ingress, s *v1.Service) {
 		}
 	}
 	t.trackedServices[serviceToKey(s)] = append(t.trackedServices[serviceToKey(s)], *ingress)
-	t.ingressToServices[ingressToKey(ingress)] = make(map[string]struct{})
+	if t.ingressToServices[ingressToKey(ingress)] == nil {
+		t.ingressToServices[ingressToKey(ingress)] = make(map[string]struct{})
+	}
 	t.ingressToServices[ingressToKey(ingress)][serviceToKey(s)] = struct{}{}
 }
 
@@ -0,0 +1,158 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+)
+
+const (
+	// minTTL bounds the frequency at which a hostname is re-resolved.
+	minTTL = 5 * time.Second
+	// refreshPeriod is the period at which the expired entries are refreshed.
+	refreshPeriod = time.Second
+	// resolveTimeout bounds the duration of a refresh.
+	resolveTimeout = 10 * time.Second
+)
+
+// CachingResolver is a Resolver that caches the addresses for their TTL, and
+// keeps re-resolving the cached hostnames as they expire, so that the changes
+// of their addresses are notified. The resolution failures are cached as well,
+// and retried in the background, so that the hostnames are notified once they
+// resolve.
+type CachingResolver struct {
+	resolver Resolver
+	onChange func(hostname string)
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+type entry struct {
+	ips     []net.IP
+	err     error
+	expires time.Time
+}
+
+var _ Resolver = &CachingResolver{}
+
+// NewCachingResolver returns a CachingResolver that calls onChange with the
+// hostnames which addresses have changed.
+func NewCachingResolver(resolver Resolver, onChange func(hostname string)) *CachingResolver {
+	return &CachingResolver{
+		resolver: resolver,
+		onChange: onChange,
+		entries:  map[string]*entry{},
+	}
+}
+
+// Start refreshes the cached hostnames until stopCh is closed.
+func (r *CachingResolver) Start(stopCh <-chan struct{}) {
+	wait.Until(r.refresh, refreshPeriod, stopCh)
+}
+
+// Resolve returns the cached addresses of the hostname, or resolves them if
+// they are not cached yet.
+func (r *CachingResolver) Resolve(ctx context.Context, hostname string) ([]net.IP, time.Duration, error) {
+	r.mu.Lock()
+	e, ok := r.entries[hostname]
+	r.mu.Unlock()
+	if ok {
+		return e.ips, time.Until(e.expires), e.err
+	}
+
+	ips, ttl, err := r.resolver.Resolve(ctx, hostname)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.entries[hostname] = &entry{err: err, expires: time.Now().Add(minTTL)}
+		return nil, 0, err
+	}
+	r.entries[hostname] = newEntry(ips, ttl)
+	return ips, ttl, nil
+}
+
+// Forget stops caching, and refreshing, the hostname.
+func (r *CachingResolver) Forget(hostname string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.entries, hostname)
+}
+
+func (r *CachingResolver) refresh() {
+	now := time.Now()
+
+	r.mu.Lock()
+	var expired []string
+	for hostname, e := range r.entries {
+		if now.After(e.expires) {
+			expired = append(expired, hostname)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, hostname := range expired {
+		ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+		ips, ttl, err := r.resolver.Resolve(ctx, hostname)
+		cancel()
+
+		r.mu.Lock()
+		e, ok := r.entries[hostname]
+		if !ok {
+			// Forgotten in the meantime
+			r.mu.Unlock()
+			continue
+		}
+		if err != nil {
+			// Keep the current addresses, or error, and retry later
+			klog.Errorf("failed to refresh the addresses of %q: %v", hostname, err)
+			e.expires = time.Now().Add(minTTL)
+			r.mu.Unlock()
+			continue
+		}
+		changed := e.err != nil || !equal(e.ips, ips)
+		r.entries[hostname] = newEntry(ips, ttl)
+		r.mu.Unlock()
+
+		if changed {
+			klog.Infof("addresses of %q changed to %v", hostname, ips)
+			r.onChange(hostname)
+		}
+	}
+}
+
+func newEntry(ips []net.IP, ttl time.Duration) *entry {
+	if ttl < minTTL {
+		ttl = minTTL
+	}
+	return &entry{
+		ips:     ips,
+		expires: time.Now().Add(ttl),
+	}
+}
+
+// equal returns whether the two lists contain the same addresses, in any order.
+func equal(a, b []net.IP) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	as, bs := make([]string, len(a)), make([]string, len(b))
+	for i := range a {
+		as[i], bs[i] = a[i].String(), b[i].String()
+	}
+	sort.Strings(as)
+	sort.Strings(bs)
+	for i := range as {
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCachingResolverRefresh(t *testing.T) {
+	const hostname = "lb.example.com"
+
+	tests := []struct {
+		name string
+		// update changes the addresses of the hostname, once it's been cached
+		update      func(r *FakeResolver)
+		forget      bool
+		wantIPs     []string
+		wantChanged bool
+	}{
+		{
+			name:        "unchanged addresses",
+			update:      func(r *FakeResolver) {},
+			wantIPs:     []string{"10.0.0.1", "10.0.0.2"},
+			wantChanged: false,
+		},
+		{
+			name:        "reordered addresses",
+			update:      func(r *FakeResolver) { r.Set(hostname, "10.0.0.2", "10.0.0.1") },
+			wantIPs:     []string{"10.0.0.2", "10.0.0.1"},
+			wantChanged: false,
+		},
+		{
+			name:        "changed addresses",
+			update:      func(r *FakeResolver) { r.Set(hostname, "10.0.0.3") },
+			wantIPs:     []string{"10.0.0.3"},
+			wantChanged: true,
+		},
+		{
+			name:        "resolution failure keeps the addresses",
+			update:      func(r *FakeResolver) { r.Set(hostname) },
+			wantIPs:     []string{"10.0.0.1", "10.0.0.2"},
+			wantChanged: false,
+		},
+		{
+			name:        "forgotten hostname",
+			update:      func(r *FakeResolver) { r.Set(hostname, "10.0.0.3") },
+			forget:      true,
+			wantIPs:     []string{"10.0.0.3"},
+			wantChanged: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFakeResolver(time.Minute)
+			fake.Set(hostname, "10.0.0.1", "10.0.0.2")
+
+			var changed []string
+			r := NewCachingResolver(fake, func(hostname string) { changed = append(changed, hostname) })
+			if _, _, err := r.Resolve(context.Background(), hostname); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			tt.update(fake)
+			if tt.forget {
+				r.Forget(hostname)
+			} else {
+				// The addresses are cached until they expire
+				ips, _, err := r.Resolve(context.Background(), hostname)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				assertIPs(t, ips, "10.0.0.1", "10.0.0.2")
+				r.entries[hostname].expires = time.Now().Add(-time.Second)
+			}
+			r.refresh()
+
+			if got := len(changed) > 0; got != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", got, tt.wantChanged)
+			}
+			ips, _, err := r.Resolve(context.Background(), hostname)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			assertIPs(t, ips, tt.wantIPs...)
+		})
+	}
+}
+
+func TestCachingResolverTTL(t *testing.T) {
+	tests := []struct {
+		name    string
+		ttl     time.Duration
+		wantTTL time.Duration
+	}{
+		{
+			name:    "record TTL",
+			ttl:     time.Minute,
+			wantTTL: time.Minute,
+		},
+		{
+			name:    "TTL below the minimum",
+			ttl:     time.Second,
+			wantTTL: minTTL,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFakeResolver(tt.ttl)
+			fake.Set("lb.example.com", "10.0.0.1")
+			r := NewCachingResolver(fake, func(string) {})
+
+			if _, _, err := r.Resolve(context.Background(), "lb.example.com"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			_, ttl, err := r.Resolve(context.Background(), "lb.example.com")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ttl > tt.wantTTL || ttl < tt.wantTTL-time.Second {
+				t.Errorf("TTL = %v, want %v", ttl, tt.wantTTL)
+			}
+		})
+	}
+}
+
+func TestCachingResolverNotFound(t *testing.T) {
+	const hostname = "missing.example.com"
+
+	fake := NewFakeResolver(time.Minute)
+	var changed []string
+	r := NewCachingResolver(fake, func(hostname string) { changed = append(changed, hostname) })
+
+	if _, _, err := r.Resolve(context.Background(), hostname); err == nil {
+		t.Fatal("expected an error")
+	}
+	// The failure is cached, rather than resolved again
+	fake.Set(hostname, "10.0.0.1")
+	if _, _, err := r.Resolve(context.Background(), hostname); err == nil {
+		t.Fatal("expected the cached error")
+	}
+
+	// The hostname is notified once it resolves
+	r.entries[hostname].expires = time.Now().Add(-time.Second)
+	r.refresh()
+	if len(changed) != 1 || changed[0] != hostname {
+		t.Errorf("changed = %v, want [%s]", changed, hostname)
+	}
+	ips, _, err := r.Resolve(context.Background(), hostname)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertIPs(t, ips, "10.0.0.1")
+}
+
+func assertIPs(t *testing.T, ips []net.IP, want ...string) {
+	t.Helper()
+	if len(ips) != len(want) {
+		t.Fatalf("addresses = %v, want %v", ips, want)
+	}
+	for i := range ips {
+		if !ips[i].Equal(net.ParseIP(want[i])) {
+			t.Fatalf("addresses = %v, want %v", ips, want)
+		}
+	}
+}
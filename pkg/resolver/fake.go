@@ -0,0 +1,83 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+var _ Resolver = &FakeResolver{}
+var _ TXTResolver = &FakeResolver{}
+
+// FakeResolver is a Resolver and a TXTResolver that returns the addresses and
+// TXT records it is configured with, meant for tests.
+type FakeResolver struct {
+	mu        sync.RWMutex
+	addresses map[string][]net.IP
+	txt       map[string][]string
+	ttl       time.Duration
+}
+
+// NewFakeResolver returns a FakeResolver that returns the addresses with the
+// given TTL.
+func NewFakeResolver(ttl time.Duration) *FakeResolver {
+	return &FakeResolver{
+		addresses: map[string][]net.IP{},
+		txt:       map[string][]string{},
+		ttl:       ttl,
+	}
+}
+
+// Set sets the addresses of the hostname. The hostname is not found if no
+// address is given.
+func (r *FakeResolver) Set(hostname string, ips ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(ips) == 0 {
+		delete(r.addresses, hostname)
+		return
+	}
+	addresses := make([]net.IP, 0, len(ips))
+	for _, ip := range ips {
+		addresses = append(addresses, net.ParseIP(ip))
+	}
+	r.addresses[hostname] = addresses
+}
+
+func (r *FakeResolver) Resolve(_ context.Context, hostname string) ([]net.IP, time.Duration, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ips, ok := r.addresses[hostname]
+	if !ok {
+		return nil, 0, fmt.Errorf("no such host %q", hostname)
+	}
+	return append([]net.IP(nil), ips...), r.ttl, nil
+}
+
+// SetTXT sets the values of the TXT records of the name. The name is not found
+// if no value is given.
+func (r *FakeResolver) SetTXT(name string, values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(values) == 0 {
+		delete(r.txt, name)
+		return
+	}
+	r.txt[name] = values
+}
+
+func (r *FakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	values, ok := r.txt[name]
+	if !ok {
+		return nil, fmt.Errorf("no such host %q", name)
+	}
+	return append([]string(nil), values...), nil
+}
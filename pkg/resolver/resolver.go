@@ -0,0 +1,179 @@
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// DefaultTTL is the duration the addresses are valid for, when the
+	// resolver does not know their TTL.
+	DefaultTTL = 30 * time.Second
+
+	resolvConf = "/etc/resolv.conf"
+)
+
+// Resolver resolves hostnames to IP addresses.
+type Resolver interface {
+	// Resolve returns the addresses of the hostname, and the duration they
+	// are valid for.
+	Resolve(ctx context.Context, hostname string) ([]net.IP, time.Duration, error)
+}
+
+// TXTResolver looks up the TXT records of names.
+type TXTResolver interface {
+	// LookupTXT returns the values of the TXT records of the name.
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// NewResolver returns a Resolver that queries the nameservers configured in
+// /etc/resolv.conf, and honours the TTL of the records. If the configuration
+// cannot be read, or the nameservers fail to resolve a hostname, e.g., listed
+// in /etc/hosts, it falls back to the Go resolver, with the default TTL.
+func NewResolver() Resolver {
+	if r := newDNSResolver(); r != nil {
+		return r
+	}
+	return &netResolver{}
+}
+
+// NewTXTResolver returns a TXTResolver that queries the nameservers configured
+// in /etc/resolv.conf, or the Go resolver if the configuration cannot be read.
+// The records are not cached, so that they are looked up afresh.
+func NewTXTResolver() TXTResolver {
+	if r := newDNSResolver(); r != nil {
+		return r
+	}
+	return &netResolver{}
+}
+
+// newDNSResolver returns a dnsResolver for the nameservers configured in
+// /etc/resolv.conf, or nil if there is none.
+func newDNSResolver() *dnsResolver {
+	config, err := dns.ClientConfigFromFile(resolvConf)
+	if err != nil || len(config.Servers) == 0 {
+		return nil
+	}
+
+	return &dnsResolver{
+		config:   config,
+		client:   &dns.Client{Timeout: time.Duration(config.Timeout) * time.Second},
+		fallback: &netResolver{},
+	}
+}
+
+// dnsResolver is a Resolver that sends A and AAAA queries to the nameservers,
+// and returns the lowest TTL of the answers. It's also a TXTResolver.
+type dnsResolver struct {
+	config   *dns.ClientConfig
+	client   *dns.Client
+	fallback Resolver
+}
+
+func (r *dnsResolver) Resolve(ctx context.Context, hostname string) ([]net.IP, time.Duration, error) {
+	ips, ttl, err := r.resolve(ctx, hostname)
+	if err != nil {
+		if ips, ttl, fallbackErr := r.fallback.Resolve(ctx, hostname); fallbackErr == nil {
+			return ips, ttl, nil
+		}
+	}
+	return ips, ttl, err
+}
+
+func (r *dnsResolver) resolve(ctx context.Context, hostname string) ([]net.IP, time.Duration, error) {
+	var ips []net.IP
+	var ttl uint32
+	for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+		answer, err := r.exchange(ctx, hostname, qtype)
+		if err != nil {
+			return nil, 0, err
+		}
+		// The answer may include the CNAME chain, let's only keep the addresses
+		for _, rr := range answer {
+			var ip net.IP
+			switch rr := rr.(type) {
+			case *dns.A:
+				ip = rr.A
+			case *dns.AAAA:
+				ip = rr.AAAA
+			default:
+				continue
+			}
+			ips = append(ips, ip)
+			if ttl == 0 || rr.Header().Ttl < ttl {
+				ttl = rr.Header().Ttl
+			}
+		}
+	}
+
+	if len(ips) == 0 {
+		return nil, 0, fmt.Errorf("no address found for %q", hostname)
+	}
+	return ips, time.Duration(ttl) * time.Second, nil
+}
+
+func (r *dnsResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	answer, err := r.exchange(ctx, name, dns.TypeTXT)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	for _, rr := range answer {
+		if txt, ok := rr.(*dns.TXT); ok {
+			values = append(values, strings.Join(txt.Txt, ""))
+		}
+	}
+	return values, nil
+}
+
+// exchange sends the query to the nameservers in turn, until one answers.
+func (r *dnsResolver) exchange(ctx context.Context, hostname string, qtype uint16) ([]dns.RR, error) {
+	m := new(dns.Msg)
+	m.SetQuestion(dns.Fqdn(hostname), qtype)
+
+	var err error
+	for _, server := range r.config.Servers {
+		var in *dns.Msg
+		in, _, err = r.client.ExchangeContext(ctx, m, net.JoinHostPort(server, r.config.Port))
+		if err != nil {
+			continue
+		}
+		switch in.Rcode {
+		case dns.RcodeSuccess:
+			return in.Answer, nil
+		case dns.RcodeNameError:
+			return nil, fmt.Errorf("no such host %q", hostname)
+		default:
+			err = fmt.Errorf("failed to resolve %q: %s", hostname, dns.RcodeToString[in.Rcode])
+		}
+	}
+
+	return nil, err
+}
+
+// netResolver is a Resolver, and a TXTResolver, that relies on the Go
+// resolver, which does not expose the TTL of the records.
+type netResolver struct{}
+
+func (r *netResolver) Resolve(ctx context.Context, hostname string) ([]net.IP, time.Duration, error) {
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, hostname)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+	return ips, DefaultTTL, nil
+}
+
+func (r *netResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return net.DefaultResolver.LookupTXT(ctx, name)
+}